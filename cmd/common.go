@@ -8,6 +8,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/bootstrap/data"
+	"github.com/alist-org/alist/v3/internal/cache"
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -17,6 +18,7 @@ func Init() {
 	bootstrap.InitConfig()
 	bootstrap.Log()
 	bootstrap.InitDB()
+	bootstrap.InitCache()
 
 	if v3_46_0.IsLegacyRoleDetected() {
 		utils.Log.Warnf("Detected legacy role format, executing ConvertLegacyRoles patch early...")
@@ -31,6 +33,9 @@ func Init() {
 
 func Release() {
 	db.Close()
+	if err := cache.Close(); err != nil {
+		log.Errorf("failed to close cache: %+v", err)
+	}
 }
 
 var pid = -1
@@ -0,0 +1,69 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alist-org/alist/v3/internal/bootstrap"
+	"github.com/alist-org/alist/v3/internal/fuse"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command, it requires building with `-tags fuse`
+// and libfuse (or WinFsp on Windows) installed.
+var MountCmd = &cobra.Command{
+	Use:   "mount <mountpoint>",
+	Short: "Mount the storages to a local directory with FUSE",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		bootstrap.LoadStorages()
+		bootstrap.InitTaskManager()
+		username, _ := cmd.Flags().GetString("user")
+		src, _ := cmd.Flags().GetString("src")
+		opts, _ := cmd.Flags().GetStringArray("option")
+		user, err := op.GetUserByName(username)
+		if err != nil {
+			utils.Log.Fatalf("failed to get user %s: %+v", username, err)
+		}
+		if user.Disabled {
+			utils.Log.Fatalf("user %s is disabled", username)
+		}
+		src, err = user.JoinPath(src)
+		if err != nil {
+			utils.Log.Fatalf("invalid src path: %+v", err)
+		}
+		var fuseOpts []string
+		for _, o := range opts {
+			fuseOpts = append(fuseOpts, "-o", o)
+		}
+		host, done := fuse.Mount(src, args[0], user, fuseOpts)
+		utils.Log.Infof("mount %s to %s", src, args[0])
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case ok := <-done:
+			if !ok {
+				utils.Log.Errorf("failed to mount %s", args[0])
+			}
+			return
+		case <-quit:
+		}
+		utils.Log.Println("Unmount...")
+		host.Unmount()
+		<-done
+	},
+}
+
+func init() {
+	MountCmd.Flags().String("user", "admin", "Username whose permissions and base path are used")
+	MountCmd.Flags().String("src", "/", "AList path to mount, relative to the user's base path")
+	MountCmd.Flags().StringArrayP("option", "o", nil, "Extra FUSE mount options")
+	RootCmd.AddCommand(MountCmd)
+}
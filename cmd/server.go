@@ -45,6 +45,14 @@ the address is defined in config file`,
 		bootstrap.InitOfflineDownloadTools()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
+		bootstrap.InitTrash()
+		bootstrap.InitSyncJobs()
+		bootstrap.InitTusUploads()
+		bootstrap.InitVersions()
+		bootstrap.InitWebhooks()
+		bootstrap.InitStorageHealth()
+		bootstrap.InitIndexRefresh()
+		bootstrap.InitShareAccessLogs()
 		bootstrap.InitFRP()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
@@ -28,6 +28,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/cloudreve"
 	_ "github.com/alist-org/alist/v3/drivers/cloudreve_v4"
 	_ "github.com/alist-org/alist/v3/drivers/crypt"
+	_ "github.com/alist-org/alist/v3/drivers/dedup"
 	_ "github.com/alist-org/alist/v3/drivers/cstcloud_capsule"
 	_ "github.com/alist-org/alist/v3/drivers/darkibox"
 	_ "github.com/alist-org/alist/v3/drivers/doubao"
@@ -72,6 +73,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/seafile"
 	_ "github.com/alist-org/alist/v3/drivers/sftp"
 	_ "github.com/alist-org/alist/v3/drivers/sjtu_netdisk"
+	_ "github.com/alist-org/alist/v3/drivers/smart"
 	_ "github.com/alist-org/alist/v3/drivers/smb"
 	_ "github.com/alist-org/alist/v3/drivers/streamtape"
 	_ "github.com/alist-org/alist/v3/drivers/strm"
@@ -81,6 +83,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/thunder_browser"
 	_ "github.com/alist-org/alist/v3/drivers/thunderx"
 	_ "github.com/alist-org/alist/v3/drivers/trainbit"
+	_ "github.com/alist-org/alist/v3/drivers/union"
 	_ "github.com/alist-org/alist/v3/drivers/url_tree"
 	_ "github.com/alist-org/alist/v3/drivers/uss"
 	_ "github.com/alist-org/alist/v3/drivers/virtual"
@@ -27,6 +27,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/avast/retry-go"
+	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -507,4 +508,22 @@ func (d *BaiduNetdisk) doUploadSlice(ctx context.Context, uploadUrl string, para
 	return body, nil
 }
 
+func (d *BaiduNetdisk) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var resp QuotaResp
+	_, err := d.request("https://pan.baidu.com/api/quota", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParams(map[string]string{
+			"checkfree":   "1",
+			"checkexpire": "1",
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: resp.Total,
+		UsedSpace:  resp.Used,
+		FreeSpace:  resp.Free,
+	}, nil
+}
+
 var _ driver.Driver = (*BaiduNetdisk)(nil)
@@ -0,0 +1,100 @@
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// gear is the table of the rolling hash of FastCDC, generated from a fixed seed
+// since the boundaries of the chunks must never change
+var gear = func() (table [256]uint64) {
+	for i := range table {
+		sum := sha256.Sum256([]byte{byte(i)})
+		table[i] = binary.BigEndian.Uint64(sum[:8])
+	}
+	return
+}()
+
+type cdcParams struct {
+	min, avg, max int
+	// a boundary is harder to find before the average size and easier after it
+	maskS, maskL uint64
+}
+
+func newCdcParams(avgSize int64) cdcParams {
+	n := bits.Len64(uint64(avgSize)) - 1
+	if avgSize-int64(1)<<n > int64(1)<<n/2 {
+		n++
+	}
+	avg := 1 << n
+	return cdcParams{
+		min:   avg / 4,
+		avg:   avg,
+		max:   avg * 4,
+		maskS: ^uint64(0) << (64 - n - 1),
+		maskL: ^uint64(0) << (64 - n + 1),
+	}
+}
+
+// cut returns the size of the chunk at the start of data, data is at most max long
+func (p cdcParams) cut(data []byte) int {
+	n := len(data)
+	if n <= p.min {
+		return n
+	}
+	normal := min(p.avg, n)
+	var h uint64
+	i := p.min
+	for ; i < normal; i++ {
+		h = h<<1 + gear[data[i]]
+		if h&p.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		h = h<<1 + gear[data[i]]
+		if h&p.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// cdcReader splits the content of r into chunks with FastCDC,
+// so that the chunks of the unchanged parts of similar contents are the same
+type cdcReader struct {
+	r          io.Reader
+	params     cdcParams
+	buf        []byte
+	start, end int
+	eof        bool
+}
+
+func newCdcReader(r io.Reader, params cdcParams) *cdcReader {
+	return &cdcReader{r: r, params: params, buf: make([]byte, params.max)}
+}
+
+// Next returns the next chunk, which is valid until the next call, or io.EOF at the end
+func (c *cdcReader) Next() ([]byte, error) {
+	if c.end-c.start < c.params.max && !c.eof {
+		copy(c.buf, c.buf[c.start:c.end])
+		c.end -= c.start
+		c.start = 0
+		n, err := io.ReadFull(c.r, c.buf[c.end:])
+		c.end += n
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			c.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.start == c.end {
+		return nil, io.EOF
+	}
+	n := c.params.cut(c.buf[c.start:c.end])
+	chunk := c.buf[c.start : c.start+n]
+	c.start += n
+	return chunk, nil
+}
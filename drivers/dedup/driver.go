@@ -0,0 +1,256 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Dedup struct {
+	model.Storage
+	Addition
+	remotes []string
+	params  cdcParams
+	// the uploads wait for the garbage collection, or the chunks they store could be collected
+	gcMu sync.RWMutex
+}
+
+func (d *Dedup) Config() driver.Config {
+	return config
+}
+
+func (d *Dedup) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Dedup) Init(ctx context.Context) error {
+	if d.AvgChunkSize == 0 {
+		d.AvgChunkSize = defaultAvgChunkSize
+	}
+	if d.AvgChunkSize < 4096 {
+		return errors.New("avg_chunk_size must be at least 4096")
+	}
+	d.params = newCdcParams(d.AvgChunkSize)
+	d.remotes = nil
+	for _, p := range strings.Split(d.RemotePaths, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = utils.FixAndCleanPath(p)
+		if _, _, err := op.GetStorageAndActualPath(p); err != nil {
+			return errors.WithMessagef(err, "can't find remote storage of %s", p)
+		}
+		d.remotes = append(d.remotes, p)
+	}
+	if len(d.remotes) == 0 {
+		return errors.New("remote_paths is empty")
+	}
+	return nil
+}
+
+func (d *Dedup) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Dedup) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &model.Object{
+		ID:       "0",
+		Path:     "/",
+		Name:     op.RootName,
+		Modified: d.Modified,
+		IsFolder: true,
+	}, nil
+}
+
+func (d *Dedup) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	entries, err := db.GetDedupEntries(d.ID, entryId(dir))
+	if err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(entries, func(e model.DedupEntry) (model.Obj, error) {
+		return toObj(&e, dir.GetPath()), nil
+	})
+}
+
+func (d *Dedup) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	chunks, err := d.getChunkRefs(entryId(file))
+	if err != nil {
+		return nil, err
+	}
+	size := file.GetSize()
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{
+			RangeReader: func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+				if httpRange.Start < 0 || httpRange.Start > size {
+					return nil, errors.New("range start out of bound")
+				}
+				if httpRange.Length < 0 || httpRange.Start+httpRange.Length > size {
+					httpRange.Length = size - httpRange.Start
+				}
+				// the first chunk ending after the start
+				i := sort.Search(len(chunks), func(i int) bool {
+					return chunks[i].Offset+chunks[i].Size > httpRange.Start
+				})
+				r := &chunksReader{ctx: ctx, d: d, chunks: chunks[i:], remaining: httpRange.Length}
+				if i < len(chunks) {
+					r.skip = httpRange.Start - chunks[i].Offset
+				}
+				return io.NopCloser(r), nil
+			},
+		},
+	}, nil
+}
+
+func (d *Dedup) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	now := time.Now()
+	return db.CreateDedupEntry(&model.DedupEntry{
+		StorageID: d.ID,
+		ParentID:  entryId(parentDir),
+		Name:      dirName,
+		IsDir:     true,
+		Modified:  now,
+		Created:   now,
+	})
+}
+
+func (d *Dedup) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return db.MoveDedupEntry(d.ID, entryId(srcObj), entryId(dstDir))
+}
+
+func (d *Dedup) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return db.RenameDedupEntry(d.ID, entryId(srcObj), newName)
+}
+
+// Copy only copies the manifests, the copies are made of the same chunks
+func (d *Dedup) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	_, err := db.CopyDedupEntry(d.ID, entryId(srcObj), entryId(dstDir))
+	return err
+}
+
+// Remove only removes the manifests, the chunks no file refers to are removed by CollectGarbage
+func (d *Dedup) Remove(ctx context.Context, obj model.Obj) error {
+	return db.DeleteDedupEntry(d.ID, entryId(obj))
+}
+
+func (d *Dedup) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) error {
+	d.gcMu.RLock()
+	defer d.gcMu.RUnlock()
+	whole := sha256.New()
+	r := newCdcReader(io.TeeReader(file, io.MultiWriter(whole, driver.NewProgress(file.GetSize(), up))), d.params)
+	var (
+		chunks []model.DedupFileChunk
+		offset int64
+	)
+	for {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		data, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		if err = d.storeChunk(ctx, hash, data); err != nil {
+			return err
+		}
+		chunks = append(chunks, model.DedupFileChunk{
+			Seq:    len(chunks),
+			Hash:   hash,
+			Offset: offset,
+			Size:   int64(len(data)),
+		})
+		offset += int64(len(data))
+	}
+	now := time.Now()
+	entry := &model.DedupEntry{
+		StorageID: d.ID,
+		ParentID:  entryId(dstDir),
+		Name:      file.GetName(),
+		Size:      offset,
+		Hash:      hex.EncodeToString(whole.Sum(nil)),
+		Modified:  file.ModTime(),
+		Created:   file.CreateTime(),
+	}
+	if entry.Modified.IsZero() {
+		entry.Modified = now
+	}
+	if entry.Created.IsZero() {
+		entry.Created = now
+	}
+	return db.PutDedupFile(entry, chunks)
+}
+
+func (d *Dedup) Other(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	switch args.Method {
+	case "verify":
+		if args.Obj.IsDir() {
+			return nil, errs.NotFile
+		}
+		return d.verify(ctx, args.Obj)
+	case "stats":
+		filesSize, storedSize, err := db.GetDedupStats(d.ID)
+		if err != nil {
+			return nil, err
+		}
+		return StatsResult{FilesSize: filesSize, StoredSize: storedSize}, nil
+	default:
+		return nil, errs.NotSupport
+	}
+}
+
+// CollectGarbage removes the chunks no file is made of anymore
+func (d *Dedup) CollectGarbage(ctx context.Context, up driver.UpdateProgress) (int, int64, error) {
+	d.gcMu.Lock()
+	defer d.gcMu.Unlock()
+	chunks, err := db.GetUnusedDedupChunks(d.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	var (
+		count int
+		size  int64
+	)
+	for i, chunk := range chunks {
+		if utils.IsCanceled(ctx) {
+			return count, size, ctx.Err()
+		}
+		storage, actualPath, err := op.GetStorageAndActualPath(chunkPath(chunk.Remote, chunk.Hash))
+		if err == nil {
+			err = op.Remove(ctx, storage, actualPath)
+		}
+		if err != nil {
+			return count, size, errors.WithMessagef(err, "failed remove chunk %s", chunk.Hash)
+		}
+		if err = db.DeleteDedupChunkById(chunk.ID); err != nil {
+			return count, size, err
+		}
+		count++
+		size += chunk.Size
+		up(float64(i+1) / float64(len(chunks)) * 100)
+	}
+	return count, size, nil
+}
+
+var _ driver.Driver = (*Dedup)(nil)
+var _ driver.GetRooter = (*Dedup)(nil)
+var _ driver.Other = (*Dedup)(nil)
+var _ driver.GarbageCollector = (*Dedup)(nil)
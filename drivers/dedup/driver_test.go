@@ -0,0 +1,159 @@
+package dedup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestCdcBoundaries(t *testing.T) {
+	params := newCdcParams(8192)
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	chunks := func(data []byte) map[string]bool {
+		res := map[string]bool{}
+		r := newCdcReader(bytes.NewReader(data), params)
+		for {
+			chunk, err := r.Next()
+			if err == io.EOF {
+				return res
+			}
+			if len(chunk) > params.max {
+				t.Fatalf("chunk of %d bytes is too large", len(chunk))
+			}
+			res[string(chunk)] = true
+		}
+	}
+	before := chunks(data)
+	// inserting some bytes only changes the chunks around them
+	after := chunks(append(append(append([]byte{}, data[:500000]...), "inserted"...), data[500000:]...))
+	shared := 0
+	for chunk := range after {
+		if before[chunk] {
+			shared++
+		}
+	}
+	if shared < len(after)-3 {
+		t.Errorf("only %d of %d chunks are shared", shared, len(after))
+	}
+}
+
+func TestDedup(t *testing.T) {
+	ctx := context.Background()
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/chunks",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, t.TempDir()),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	_, err = op.CreateStorage(ctx, model.Storage{
+		Driver:    "Dedup",
+		MountPath: "/pool",
+		Addition:  `{"remote_paths":"/chunks/a\n/chunks/b","avg_chunk_size":8192,"verify_chunks":true}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := make([]byte, 256<<10)
+	rand.New(rand.NewSource(2)).Read(base)
+	similar := append(append([]byte{}, base...), "a few more bytes"...)
+	put := func(name string, content []byte) {
+		err := op.Put(ctx, storage, "/dir", &stream.FileStream{
+			Obj: &model.Object{
+				Name:     name,
+				Size:     int64(len(content)),
+				Modified: time.Now(),
+			},
+			Reader: bytes.NewReader(content),
+		}, nil)
+		if err != nil {
+			t.Fatalf("failed put: %+v", err)
+		}
+	}
+	read := func(path string, start, length int64) []byte {
+		link, _, err := op.Link(ctx, storage, path, model.LinkArgs{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, err := link.RangeReadCloser.RangeRead(ctx, http_range.Range{Start: start, Length: length})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	put("base.img", base)
+	put("similar.img", similar)
+	stats, err := op.Other(ctx, storage, model.FsOtherArgs{Path: "/dir", Method: "stats"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := stats.(StatsResult); s.FilesSize != int64(len(base)+len(similar)) || s.StoredSize > s.FilesSize*3/4 {
+		t.Errorf("the content is not deduplicated: %+v", s)
+	}
+	if !bytes.Equal(read("/dir/similar.img", 0, -1), similar) {
+		t.Errorf("the content read is different")
+	}
+	if !bytes.Equal(read("/dir/base.img", 100000, 50000), base[100000:150000]) {
+		t.Errorf("the range read is different")
+	}
+
+	if err = op.Copy(ctx, storage, "/dir/base.img", "/"); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Remove(ctx, storage, "/dir"); err != nil {
+		t.Fatal(err)
+	}
+	gc := storage.(*Dedup)
+	removed, _, err := gc.CollectGarbage(ctx, func(float64) {})
+	if err != nil {
+		t.Fatalf("failed collect garbage: %+v", err)
+	}
+	// only the last chunks of similar.img are not shared with the copy
+	if removed == 0 || removed > 2 {
+		t.Errorf("%d chunks are removed", removed)
+	}
+	if !bytes.Equal(read("/base.img", 0, -1), base) {
+		t.Errorf("the copy is broken by the garbage collection")
+	}
+	res, err := op.Other(ctx, storage, model.FsOtherArgs{Path: "/base.img", Method: "verify"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := res.(*VerifyResult); v.Chunks == 0 || len(v.Corrupted) > 0 {
+		t.Errorf("unexpected verify result: %+v", v)
+	}
+}
@@ -0,0 +1,34 @@
+package dedup
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+const defaultAvgChunkSize int64 = 1 << 20
+
+type Addition struct {
+	RemotePaths  string `json:"remote_paths" type:"text" required:"true" help:"AList mounted folder paths storing the chunks, one per line. New chunks are spread across them by their hash"`
+	AvgChunkSize int64  `json:"avg_chunk_size" type:"number" default:"1048576" help:"Rounded to a power of 2, the chunks are cut by their content between 1/4 and 4 times of it"`
+	VerifyChunks bool   `json:"verify_chunks" type:"bool" default:"true" help:"Check the SHA-256 of every chunk read, a corrupted chunk fails the download"`
+}
+
+var config = driver.Config{
+	Name:              "Dedup",
+	LocalSort:         true,
+	OnlyLocal:         false,
+	OnlyProxy:         true,
+	NoCache:           true,
+	NoUpload:          false,
+	NeedMs:            false,
+	DefaultRoot:       "/",
+	CheckStatus:       false,
+	Alert:             "info|The files are stored as chunks shared between them, the manifests of the files are kept in the database of AList. The chunks of the removed files are freed by the garbage collection.",
+	NoOverwriteUpload: false,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Dedup{}
+	})
+}
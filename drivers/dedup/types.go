@@ -0,0 +1,22 @@
+package dedup
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// VerifyResult is returned by the verify method of Other
+type VerifyResult struct {
+	Chunks    int      `json:"chunks"`
+	Corrupted []string `json:"corrupted"`
+}
+
+// StatsResult is returned by the stats method of Other
+type StatsResult struct {
+	FilesSize  int64 `json:"files_size"`
+	StoredSize int64 `json:"stored_size"`
+}
+
+type chunkRef struct {
+	model.DedupFileChunk
+	remote string
+}
@@ -0,0 +1,207 @@
+package dedup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func entryId(obj model.Obj) uint {
+	id, _ := strconv.ParseUint(obj.GetID(), 10, 64)
+	return uint(id)
+}
+
+func toObj(e *model.DedupEntry, dirPath string) model.Obj {
+	obj := &model.Object{
+		ID:       strconv.FormatUint(uint64(e.ID), 10),
+		Path:     stdpath.Join(dirPath, e.Name),
+		Name:     e.Name,
+		Size:     e.Size,
+		Modified: e.Modified,
+		Ctime:    e.Created,
+		IsFolder: e.IsDir,
+	}
+	if e.Hash != "" {
+		obj.HashInfo = utils.NewHashInfo(utils.SHA256, e.Hash)
+	}
+	return obj
+}
+
+// chunkPath returns the path of a chunk, the chunks are spread into 256 folders by their hash
+func chunkPath(remote, hash string) string {
+	return stdpath.Join(remote, hash[:2], hash)
+}
+
+// storeChunk uploads the chunk unless it's already stored
+func (d *Dedup) storeChunk(ctx context.Context, hash string, data []byte) error {
+	stored, err := db.HasDedupChunk(d.ID, hash)
+	if err != nil || stored {
+		return err
+	}
+	sum, _ := hex.DecodeString(hash[:2])
+	remote := d.remotes[int(sum[0])%len(d.remotes)]
+	storage, dirActualPath, err := op.GetStorageAndActualPath(stdpath.Dir(chunkPath(remote, hash)))
+	if err != nil {
+		return errors.WithMessagef(err, "failed get remote storage of %s", remote)
+	}
+	err = op.Put(ctx, storage, dirActualPath, &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     hash,
+			Size:     int64(len(data)),
+			Modified: time.Now(),
+		},
+		Reader:   bytes.NewReader(data),
+		Mimetype: "application/octet-stream",
+	}, nil, false)
+	if err != nil {
+		return errors.WithMessagef(err, "failed store chunk %s", hash)
+	}
+	return db.CreateDedupChunk(&model.DedupChunk{
+		StorageID: d.ID,
+		Hash:      hash,
+		Size:      int64(len(data)),
+		Remote:    remote,
+	})
+}
+
+// getChunkRefs returns the chunks of the file with where they are stored
+func (d *Dedup) getChunkRefs(id uint) ([]chunkRef, error) {
+	chunks, err := db.GetDedupFileChunks(id)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := db.GetDedupChunksOfEntry(d.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	remotes := make(map[string]string, len(stored))
+	for _, c := range stored {
+		remotes[c.Hash] = c.Remote
+	}
+	refs := make([]chunkRef, len(chunks))
+	for i, c := range chunks {
+		remote, ok := remotes[c.Hash]
+		if !ok {
+			return nil, errors.Errorf("chunk %s is missing", c.Hash)
+		}
+		refs[i] = chunkRef{DedupFileChunk: c, remote: remote}
+	}
+	return refs, nil
+}
+
+// readChunk reads a whole chunk, so that it can be checked before being used
+func (d *Dedup) readChunk(ctx context.Context, chunk chunkRef, verify bool) ([]byte, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(chunkPath(chunk.remote, chunk.Hash))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get remote storage of %s", chunk.remote)
+	}
+	link, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get link of chunk %s", chunk.Hash)
+	}
+	rc, err := openLink(ctx, link, obj.GetSize())
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, chunk.Size+1))
+	_ = rc.Close()
+	if link.MFile != nil {
+		_ = link.MFile.Close()
+	}
+	if link.RangeReadCloser != nil {
+		_ = link.RangeReadCloser.Close()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed read chunk %s", chunk.Hash)
+	}
+	if int64(len(data)) != chunk.Size {
+		return nil, errors.Errorf("chunk %s is corrupted: size %d, expected %d", chunk.Hash, len(data), chunk.Size)
+	}
+	if verify {
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != chunk.Hash {
+			return nil, errors.Errorf("chunk %s is corrupted: hash mismatch", chunk.Hash)
+		}
+	}
+	return data, nil
+}
+
+func openLink(ctx context.Context, link *model.Link, size int64) (io.ReadCloser, error) {
+	switch {
+	case link.MFile != nil:
+		return io.NopCloser(io.NewSectionReader(link.MFile, 0, size)), nil
+	case link.RangeReadCloser != nil:
+		return link.RangeReadCloser.RangeRead(ctx, http_range.Range{Length: size})
+	case link.URL != "":
+		rrc, err := stream.GetRangeReadCloserFromLink(size, link)
+		if err != nil {
+			return nil, err
+		}
+		return rrc.RangeRead(ctx, http_range.Range{Length: size})
+	default:
+		return nil, errs.NotSupport
+	}
+}
+
+// verify reads all the chunks of the file and reports the corrupted ones
+func (d *Dedup) verify(ctx context.Context, file model.Obj) (*VerifyResult, error) {
+	chunks, err := d.getChunkRefs(entryId(file))
+	if err != nil {
+		return nil, err
+	}
+	res := &VerifyResult{Chunks: len(chunks), Corrupted: []string{}}
+	for _, chunk := range chunks {
+		if utils.IsCanceled(ctx) {
+			return nil, ctx.Err()
+		}
+		if _, err := d.readChunk(ctx, chunk, true); err != nil {
+			res.Corrupted = append(res.Corrupted, chunk.Hash)
+		}
+	}
+	return res, nil
+}
+
+// chunksReader reads the content of a file from its chunks one by one
+type chunksReader struct {
+	ctx       context.Context
+	d         *Dedup
+	chunks    []chunkRef
+	skip      int64
+	remaining int64
+	buf       []byte
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.remaining <= 0 || len(r.chunks) == 0 {
+			return 0, io.EOF
+		}
+		data, err := r.d.readChunk(r.ctx, r.chunks[0], r.d.VerifyChunks)
+		if err != nil {
+			return 0, err
+		}
+		r.buf = data[r.skip:]
+		r.chunks, r.skip = r.chunks[1:], 0
+	}
+	if int64(len(r.buf)) > r.remaining {
+		r.buf = r.buf[:r.remaining]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.remaining -= int64(n)
+	return n, nil
+}
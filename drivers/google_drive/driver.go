@@ -3,7 +3,9 @@ package google_drive
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/alist-org/alist/v3/drivers/base"
@@ -167,4 +169,84 @@ func (d *GoogleDrive) Put(ctx context.Context, dstDir model.Obj, stream model.Fi
 	return err
 }
 
+func (d *GoogleDrive) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var about About
+	_, err := d.request("https://www.googleapis.com/drive/v3/about", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("fields", "storageQuota")
+	}, &about)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: about.StorageQuota.Limit,
+		UsedSpace:  about.StorageQuota.Usage,
+	}, nil
+}
+
+func (d *GoogleDrive) ChangeFeedEnabled() bool {
+	return true
+}
+
+// Changes reports the parents of the files changed by the changes api, the cursor is the page token.
+// A file moved away is only reported in its new folder, and a file deleted forever without
+// being trashed first can't be located.
+func (d *GoogleDrive) Changes(ctx context.Context, cursor string) ([]string, string, error) {
+	if cursor == "" {
+		var start struct {
+			StartPageToken string `json:"startPageToken"`
+		}
+		_, err := d.request("https://www.googleapis.com/drive/v3/changes/startPageToken", http.MethodGet, func(req *resty.Request) {
+			req.SetContext(ctx)
+		}, &start)
+		return nil, start.StartPageToken, err
+	}
+	var root struct {
+		Id string `json:"id"`
+	}
+	_, err := d.request("https://www.googleapis.com/drive/v3/files/"+d.RootFolderID, http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("fields", "id")
+	}, &root)
+	if err != nil {
+		return nil, "", err
+	}
+	paths := map[string]string{root.Id: "/"}
+	dirs := make(map[string]struct{})
+	pageToken := cursor
+	for {
+		var resp Changes
+		_, err := d.request("https://www.googleapis.com/drive/v3/changes", http.MethodGet, func(req *resty.Request) {
+			req.SetContext(ctx).SetQueryParams(map[string]string{
+				"pageToken": pageToken,
+				"pageSize":  "1000",
+				"fields":    "nextPageToken,newStartPageToken,changes(fileId,removed,file(name,parents))",
+			})
+		}, &resp)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, change := range resp.Changes {
+			if change.File == nil {
+				continue
+			}
+			for _, parent := range change.File.Parents {
+				p, err := d.folderPath(ctx, parent, paths)
+				if err != nil {
+					return nil, "", err
+				}
+				if p != "" {
+					dirs[p] = struct{}{}
+				}
+			}
+		}
+		if resp.NewStartPageToken != "" {
+			return slices.Collect(maps.Keys(dirs)), resp.NewStartPageToken, nil
+		}
+		if resp.NextPageToken == "" {
+			return nil, "", fmt.Errorf("no page token in the response")
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
 var _ driver.Driver = (*GoogleDrive)(nil)
+var _ driver.ChangeFeed = (*GoogleDrive)(nil)
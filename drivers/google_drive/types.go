@@ -78,3 +78,27 @@ type Error struct {
 		Message string `json:"message"`
 	} `json:"error"`
 }
+
+type About struct {
+	StorageQuota struct {
+		// Limit is missing if the storage is unlimited
+		Limit        int64 `json:"limit,string"`
+		Usage        int64 `json:"usage,string"`
+		UsageInDrive int64 `json:"usageInDrive,string"`
+	} `json:"storageQuota"`
+}
+
+type Change struct {
+	FileId  string `json:"fileId"`
+	Removed bool   `json:"removed"`
+	File    *struct {
+		Name    string   `json:"name"`
+		Parents []string `json:"parents"`
+	} `json:"file"`
+}
+
+type Changes struct {
+	NextPageToken     string   `json:"nextPageToken"`
+	NewStartPageToken string   `json:"newStartPageToken"`
+	Changes           []Change `json:"changes"`
+}
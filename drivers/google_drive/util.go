@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path"
 	"regexp"
 	"strconv"
 	"time"
@@ -242,3 +243,33 @@ func (d *GoogleDrive) chunkUpload(ctx context.Context, stream model.FileStreamer
 	}
 	return nil
 }
+
+// folderPath returns the path of the folder relative to the root folder, or an empty path if it's out of the root folder,
+// the paths found are cached in paths by the ids
+func (d *GoogleDrive) folderPath(ctx context.Context, id string, paths map[string]string) (string, error) {
+	if p, ok := paths[id]; ok {
+		return p, nil
+	}
+	var f struct {
+		Name    string   `json:"name"`
+		Parents []string `json:"parents"`
+	}
+	_, err := d.request("https://www.googleapis.com/drive/v3/files/"+id, http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("fields", "name,parents")
+	}, &f)
+	if err != nil {
+		return "", err
+	}
+	p := ""
+	if len(f.Parents) > 0 {
+		parent, err := d.folderPath(ctx, f.Parents[0], paths)
+		if err != nil {
+			return "", err
+		}
+		if parent != "" {
+			p = path.Join(parent, f.Name)
+		}
+	}
+	paths[id] = p
+	return p, nil
+}
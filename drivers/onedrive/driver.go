@@ -2,10 +2,13 @@ package onedrive
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"sync"
 
 	"github.com/alist-org/alist/v3/drivers/base"
@@ -207,4 +210,68 @@ func (d *Onedrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileS
 	return err
 }
 
+func (d *Onedrive) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var drive Drive
+	_, err := d.Request(d.GetDriveUrl()+"?$select=quota", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx)
+	}, &drive)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: drive.Quota.Total,
+		UsedSpace:  drive.Quota.Used,
+		FreeSpace:  drive.Quota.Remaining,
+	}, nil
+}
+
+func (d *Onedrive) ChangeFeedEnabled() bool {
+	return true
+}
+
+// Changes reports the parent folders of the items changed by the delta api of the drive,
+// the cursor is the delta link
+func (d *Onedrive) Changes(ctx context.Context, cursor string) ([]string, string, error) {
+	next := cursor
+	if next == "" {
+		next = d.GetDriveUrl() + "/root/delta?token=latest&$select=id,name,deleted,root,parentReference"
+	}
+	paths := make(map[string]string)
+	dirs := make(map[string]struct{})
+	for {
+		var delta Delta
+		_, err := d.Request(next, http.MethodGet, func(req *resty.Request) {
+			req.SetContext(ctx)
+		}, &delta)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, item := range delta.Value {
+			if item.Root != nil || item.ParentReference.Id == "" {
+				continue
+			}
+			// the deleted items may come without the path of their parent
+			parent, ok := drivePath(item.ParentReference.Path)
+			if !ok {
+				parent, err = d.itemPath(ctx, item.ParentReference.Id, paths)
+				if err != nil {
+					return nil, "", err
+				}
+			}
+			rel, ok := utils.RelativePath(d.RootFolderPath, parent)
+			if ok {
+				dirs[rel] = struct{}{}
+			}
+		}
+		if delta.DeltaLink != "" {
+			return slices.Collect(maps.Keys(dirs)), delta.DeltaLink, nil
+		}
+		if delta.NextLink == "" {
+			return nil, "", errors.New("no delta link in the response")
+		}
+		next = delta.NextLink
+	}
+}
+
 var _ driver.Driver = (*Onedrive)(nil)
+var _ driver.ChangeFeed = (*Onedrive)(nil)
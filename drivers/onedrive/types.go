@@ -89,3 +89,29 @@ type FileSystemInfoFacet struct {
 	CreatedDateTime      time.Time `json:"createdDateTime,omitempty"`      // The UTC date and time the file was created on a client.
 	LastModifiedDateTime time.Time `json:"lastModifiedDateTime,omitempty"` // The UTC date and time the file was last modified on a client.
 }
+
+type Drive struct {
+	Quota struct {
+		Total     int64 `json:"total"`
+		Used      int64 `json:"used"`
+		Remaining int64 `json:"remaining"`
+		Deleted   int64 `json:"deleted"`
+	} `json:"quota"`
+}
+
+type DeltaItem struct {
+	Id              string    `json:"id"`
+	Name            string    `json:"name"`
+	Deleted         *struct{} `json:"deleted"`
+	Root            *struct{} `json:"root"`
+	ParentReference struct {
+		Id   string `json:"id"`
+		Path string `json:"path"`
+	} `json:"parentReference"`
+}
+
+type Delta struct {
+	Value     []DeltaItem `json:"value"`
+	NextLink  string      `json:"@odata.nextLink"`
+	DeltaLink string      `json:"@odata.deltaLink"`
+}
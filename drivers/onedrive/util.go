@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	stdpath "path"
+	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/drivers/base"
@@ -60,6 +62,15 @@ func (d *Onedrive) GetMetaUrl(auth bool, path string) string {
 	}
 }
 
+// GetDriveUrl returns the url of the drive resource, which carries the quota
+func (d *Onedrive) GetDriveUrl() string {
+	host, _ := onedriveHostMap[d.Region]
+	if d.IsSharepoint {
+		return fmt.Sprintf("%s/v1.0/sites/%s/drive", host.Api, d.SiteId)
+	}
+	return fmt.Sprintf("%s/v1.0/me/drive", host.Api)
+}
+
 func (d *Onedrive) refreshToken() error {
 	var err error
 	for i := 0; i < 3; i++ {
@@ -255,3 +266,39 @@ func (d *Onedrive) upBig(ctx context.Context, dstDir model.Obj, stream model.Fil
 	}
 	return nil
 }
+
+// drivePath returns the path in the drive of a parent reference path like /drive/root:/a/b
+func drivePath(parentPath string) (string, bool) {
+	_, p, ok := strings.Cut(parentPath, "root:")
+	if !ok {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	return utils.FixAndCleanPath(p), true
+}
+
+// itemPath returns the path in the drive of the item, the paths found are cached in paths by the ids
+func (d *Onedrive) itemPath(ctx context.Context, id string, paths map[string]string) (string, error) {
+	if p, ok := paths[id]; ok {
+		return p, nil
+	}
+	var item DeltaItem
+	_, err := d.Request(d.GetDriveUrl()+"/items/"+id+"?$select=id,name,root,parentReference", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx)
+	}, &item)
+	if err != nil {
+		return "", err
+	}
+	p := "/"
+	if item.Root == nil {
+		parent, ok := drivePath(item.ParentReference.Path)
+		if !ok {
+			return "", fmt.Errorf("unknown path of item %s", id)
+		}
+		p = stdpath.Join(parent, item.Name)
+	}
+	paths[id] = p
+	return p, nil
+}
@@ -206,4 +206,19 @@ func (d *OnedriveAPP) Put(ctx context.Context, dstDir model.Obj, stream model.Fi
 	return err
 }
 
+func (d *OnedriveAPP) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var drive Drive
+	_, err := d.Request(d.GetDriveUrl()+"?$select=quota", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx)
+	}, &drive)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: drive.Quota.Total,
+		UsedSpace:  drive.Quota.Used,
+		FreeSpace:  drive.Quota.Remaining,
+	}, nil
+}
+
 var _ driver.Driver = (*OnedriveAPP)(nil)
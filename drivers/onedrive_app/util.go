@@ -51,6 +51,12 @@ func (d *OnedriveAPP) GetMetaUrl(auth bool, path string) string {
 	return fmt.Sprintf("%s/v1.0/users/%s/drive/root:%s:", host.Api, d.Email, path)
 }
 
+// GetDriveUrl returns the url of the drive resource, which carries the quota
+func (d *OnedriveAPP) GetDriveUrl() string {
+	host, _ := onedriveHostMap[d.Region]
+	return fmt.Sprintf("%s/v1.0/users/%s/drive", host.Api, d.Email)
+}
+
 func (d *OnedriveAPP) accessToken() error {
 	var err error
 	for i := 0; i < 3; i++ {
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	stdpath "path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
@@ -31,8 +32,16 @@ type S3 struct {
 
 	config driver.Config
 	cron   *cron.Cron
+
+	// the bucket is listed to get its size, which is too slow to do every time
+	detailsMu   sync.Mutex
+	details     *model.StorageDetails
+	detailsTime time.Time
 }
 
+// detailsExpiration is how long the estimated size of the bucket is kept
+const detailsExpiration = 30 * time.Minute
+
 var storageClassLookup = map[string]string{
 	"standard":            s3.ObjectStorageClassStandard,
 	"reduced_redundancy":  s3.ObjectStorageClassReducedRedundancy,
@@ -111,6 +120,11 @@ func (d *S3) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]mo
 }
 
 func (d *S3) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return d.link(file, "")
+}
+
+// link returns the link of the version of the file, or of the current one if versionID is empty
+func (d *S3) link(file model.Obj, versionID string) (*model.Link, error) {
 	path := getKey(file.GetPath(), false)
 	filename := stdpath.Base(path)
 	disposition := fmt.Sprintf(`attachment; filename*=UTF-8''%s`, url.PathEscape(filename))
@@ -122,6 +136,9 @@ func (d *S3) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*mo
 		Key:    &path,
 		//ResponseContentDisposition: &disposition,
 	}
+	if versionID != "" {
+		input.VersionId = &versionID
+	}
 	if d.CustomHost == "" {
 		input.ResponseContentDisposition = &disposition
 	}
@@ -241,7 +258,31 @@ func (d *S3) createDirMarker(ctx context.Context, dirPath string) error {
 	return d.putEmptyObject(ctx, getKey(dirPath, true))
 }
 
+// GetDetails estimates the used space from the size of the objects,
+// the capacity of a bucket is unlimited
+func (d *S3) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	d.detailsMu.Lock()
+	if d.details != nil && time.Since(d.detailsTime) < detailsExpiration {
+		defer d.detailsMu.Unlock()
+		details := *d.details
+		return &details, nil
+	}
+	d.detailsMu.Unlock()
+	// the listing may take long, it's not done under the lock
+	size, truncated, err := d.usedSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.detailsMu.Lock()
+	defer d.detailsMu.Unlock()
+	d.details, d.detailsTime = &model.StorageDetails{UsedSpace: size, UsedSpaceTruncated: truncated}, time.Now()
+	details := *d.details
+	return &details, nil
+}
+
 var (
-	_ driver.Driver = (*S3)(nil)
-	_ driver.Other  = (*S3)(nil)
+	_ driver.Driver         = (*S3)(nil)
+	_ driver.Other          = (*S3)(nil)
+	_ driver.StorageDetails = (*S3)(nil)
+	_ driver.ChangeFeed     = (*S3)(nil)
 )
@@ -24,6 +24,7 @@ type Addition struct {
 	UserAgent                string `json:"user_agent" help:"Some providers validate the client by User-Agent, e.g. CSTCloud data capsule (s3.cstcloud.cn) requires it to contain the app type the AccessKey was created for (such as rclone). Such providers usually gate presigned URLs the same way, so also enable Web Proxy for the storage. Leave empty to use the SDK default."`
 	AddFilenameToDisposition bool   `json:"add_filename_to_disposition" help:"Add filename to Content-Disposition header."`
 	StorageClass             string `json:"storage_class" type:"select" options:",standard,standard_ia,onezone_ia,intelligent_tiering,glacier,glacier_ir,deep_archive,archive" help:"Storage class for new objects. AWS and Tencent COS support different subsets (COS uses ARCHIVE/DEEP_ARCHIVE)."`
+	UseBucketVersioning      bool   `json:"use_bucket_versioning" help:"Use the object versions of the bucket as the version history when versioning is enabled for the storage. The bucket must have versioning enabled."`
 }
 
 func init() {
@@ -3,10 +3,14 @@ package s3
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -286,3 +290,73 @@ func (d *S3) removeFile(src string) error {
 	_, err := d.client.DeleteObject(input)
 	return err
 }
+
+// maxSizeEstimateObjects bounds the listing of usedSize, the size of the
+// objects beyond it is not counted
+const maxSizeEstimateObjects = 100000
+
+// usedSize sums the size of the objects under the root path, s3 has no api for the size of a bucket.
+// truncated is true if the listing stopped at maxSizeEstimateObjects before the last object.
+func (d *S3) usedSize(ctx context.Context) (size int64, truncated bool, err error) {
+	prefix := getKey(d.GetRootPath(), true)
+	var count int64
+	err = d.client.ListObjectsPagesWithContext(ctx, &s3.ListObjectsInput{
+		Bucket: &d.Bucket,
+		Prefix: &prefix,
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, object := range page.Contents {
+			size += aws.Int64Value(object.Size)
+		}
+		count += int64(len(page.Contents))
+		truncated = !lastPage && count >= maxSizeEstimateObjects
+		return !truncated
+	})
+	return size, truncated, err
+}
+
+// changeMargin is how far back the listing time is taken as the next cursor, the last modified time
+// of an object is when its upload started, which may be long before it's listed
+const changeMargin = time.Hour
+
+// ChangeFeedEnabled reports whether the bucket has versioning, the deleted objects are only seen as its delete markers
+func (d *S3) ChangeFeedEnabled() bool {
+	return d.UseBucketVersioning
+}
+
+// Changes reports the folders holding the objects modified since the cursor, which is the time of the
+// previous listing. The bucket is listed flat page by page with the markers rather than folder by folder.
+func (d *S3) Changes(ctx context.Context, cursor string) ([]string, string, error) {
+	next := time.Now().Add(-changeMargin).Format(time.RFC3339Nano)
+	if cursor == "" {
+		return nil, next, nil
+	}
+	since, err := time.Parse(time.RFC3339Nano, cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid cursor: %w", err)
+	}
+	prefix := getKey(d.GetRootPath(), true)
+	dirs := make(map[string]struct{})
+	add := func(key *string, modified *time.Time) {
+		if aws.TimeValue(modified).After(since) {
+			// the key of a folder ends with a slash
+			dir := path.Dir(path.Join("/", strings.TrimPrefix(aws.StringValue(key), prefix)))
+			dirs[dir] = struct{}{}
+		}
+	}
+	err = d.client.ListObjectVersionsPagesWithContext(ctx, &s3.ListObjectVersionsInput{
+		Bucket: &d.Bucket,
+		Prefix: &prefix,
+	}, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, v := range page.Versions {
+			add(v.Key, v.LastModified)
+		}
+		for _, m := range page.DeleteMarkers {
+			add(m.Key, m.LastModified)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return slices.Collect(maps.Keys(dirs)), next, nil
+}
@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+	"sort"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func (d *S3) NativeVersioning() bool {
+	return d.UseBucketVersioning
+}
+
+func (d *S3) ListVersions(ctx context.Context, file model.Obj) ([]model.FileVersion, error) {
+	key := getKey(file.GetPath(), false)
+	input := &s3.ListObjectVersionsInput{
+		Bucket: &d.Bucket,
+		Prefix: &key,
+	}
+	versions := make([]model.FileVersion, 0)
+	err := d.client.ListObjectVersionsPagesWithContext(ctx, input, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, v := range page.Versions {
+			// the prefix matches the keys starting with the key as well
+			if aws.StringValue(v.Key) != key || aws.BoolValue(v.IsLatest) {
+				continue
+			}
+			versions = append(versions, model.FileVersion{
+				ID:       aws.StringValue(v.VersionId),
+				Size:     aws.Int64Value(v.Size),
+				Modified: aws.TimeValue(v.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Modified.After(versions[j].Modified)
+	})
+	return versions, nil
+}
+
+func (d *S3) LinkVersion(ctx context.Context, file model.Obj, versionID string, args model.LinkArgs) (*model.Link, error) {
+	return d.link(file, versionID)
+}
+
+// RestoreVersion copies the version over the file, so that the current content becomes a version in turn
+func (d *S3) RestoreVersion(ctx context.Context, file model.Obj, versionID string) error {
+	key := getKey(file.GetPath(), false)
+	input := &s3.CopyObjectInput{
+		Bucket:     &d.Bucket,
+		CopySource: aws.String(url.PathEscape(d.Bucket+"/"+key) + "?versionId=" + url.QueryEscape(versionID)),
+		Key:        &key,
+	}
+	if storageClass := d.resolveStorageClass(); storageClass != nil {
+		input.StorageClass = storageClass
+	}
+	_, err := d.client.CopyObjectWithContext(ctx, input)
+	return err
+}
+
+var _ driver.Versioner = (*S3)(nil)
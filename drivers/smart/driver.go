@@ -0,0 +1,177 @@
+package smart
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+type Smart struct {
+	model.Storage
+	Addition
+	searches []savedSearch
+	// targets maps the names of the last results of the saved searches to the paths of the objs
+	targets cache.ICache[map[string]string]
+}
+
+func (d *Smart) Config() driver.Config {
+	return config
+}
+
+func (d *Smart) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Smart) Init(ctx context.Context) error {
+	if d.MaxResults < 1 {
+		return errors.New("max_results must be at least 1")
+	}
+	d.searches = nil
+	d.targets = cache.NewMemCache(cache.WithShards[map[string]string](16))
+	for _, line := range strings.Split(d.Searches, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, query, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.Contains(name, "/") {
+			return errors.Errorf("invalid saved search %s, it should be name:query", line)
+		}
+		if d.savedSearch(name) != nil {
+			return errors.Errorf("duplicate saved search %s", name)
+		}
+		req := model.SearchReq{Parent: "/", Keywords: query, PageReq: model.PageReq{Page: 1, PerPage: d.MaxResults}}
+		if err := search.ParseFilters(&req); err != nil {
+			return errors.WithMessagef(err, "invalid saved search %s", name)
+		}
+		req.Parent = utils.FixAndCleanPath(req.Parent)
+		if err := req.Validate(); err != nil {
+			return errors.WithMessagef(err, "invalid saved search %s", name)
+		}
+		d.searches = append(d.searches, savedSearch{name: name, req: req})
+	}
+	if len(d.searches) == 0 {
+		return errors.New("searches is empty")
+	}
+	return nil
+}
+
+func (d *Smart) Drop(ctx context.Context) error {
+	d.searches = nil
+	if d.targets != nil {
+		d.targets.Clear()
+	}
+	return nil
+}
+
+func (d *Smart) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &model.Object{
+		Path:     "/",
+		Name:     op.RootName,
+		Modified: d.Modified,
+		IsFolder: true,
+	}, nil
+}
+
+// Get returns the folder of a saved search, or the obj found by it or under it
+func (d *Smart) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return d.GetRoot(ctx)
+	}
+	s, entry, sub := d.split(path)
+	if s == nil {
+		return nil, errs.ObjectNotFound
+	}
+	if entry == "" {
+		return d.folder(s), nil
+	}
+	target, err := d.resolve(ctx, s, entry, sub)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := fs.Get(ctx, target, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	return toObject(utils.FixAndCleanPath(path), target, obj), nil
+}
+
+// List lists the saved searches in the root, the results of the search in its folder,
+// and the objs of the storage they are found in under a folder found by the search
+func (d *Smart) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	path := dir.GetPath()
+	if utils.PathEqual(path, "/") {
+		return utils.SliceConvert(d.searches, func(s savedSearch) (model.Obj, error) {
+			return d.folder(&s), nil
+		})
+	}
+	s, entry, _ := d.split(path)
+	if s == nil {
+		return nil, errs.ObjectNotFound
+	}
+	if entry == "" {
+		objs, err := d.results(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		return utils.SliceConvert(objs, func(obj *Object) (model.Obj, error) {
+			return obj, nil
+		})
+	}
+	target, err := d.targetOf(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := fs.List(ctx, target, &fs.ListArgs{NoLog: true, Refresh: args.Refresh, NoUpdateIndex: true})
+	if err != nil {
+		return nil, err
+	}
+	user, _ := ctx.Value("user").(*model.User)
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if t := stdpath.Join(target, obj.GetName()); d.visible(user, t) {
+			res = append(res, toObject(stdpath.Join(path, obj.GetName()), t, obj))
+		}
+	}
+	return res, nil
+}
+
+// Link resolves the link of the file in the storage it's found in
+func (d *Smart) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	target, err := d.targetOf(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(target)
+	if err != nil {
+		return nil, err
+	}
+	if args.Redirect && common.ShouldProxy(storage, stdpath.Base(target)) {
+		return &model.Link{
+			URL: fmt.Sprintf("%s/p%s?sign=%s",
+				common.GetApiUrl(args.HttpReq),
+				utils.EncodePath(target, true),
+				sign.Sign(target)),
+		}, nil
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, args)
+	return link, err
+}
+
+var _ driver.Driver = (*Smart)(nil)
+var _ driver.GetRooter = (*Smart)(nil)
+var _ driver.Getter = (*Smart)(nil)
@@ -0,0 +1,134 @@
+package smart
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/search"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestSmart(t *testing.T) {
+	role := &model.Role{Name: "all", PermissionScopes: []model.PermissionEntry{{Path: "/"}}}
+	if err := op.CreateRole(role); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 1, Username: "user", BasePath: "/", Role: model.Roles{int(role.ID)}}
+	ctx := context.WithValue(context.Background(), "user", user)
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"docs/a.pdf":            "a",
+		"docs/b.txt":            "b",
+		"docs/reports/2024.pdf": "2024",
+		"old/a.pdf":             "old a",
+	} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	if err = search.Init("database"); err != nil {
+		t.Fatal(err)
+	}
+	if err = search.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = op.CreateStorage(ctx, model.Storage{
+		Driver:    "Smart",
+		MountPath: "/smart",
+		Addition:  `{"searches":"PDFs:ext:pdf sort:name\nDocs: path:/local/docs","max_results":10}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, _ := op.GetStorageByMountPath("/smart")
+	list := func(path string) []string {
+		t.Helper()
+		objs, err := op.List(ctx, storage, path, model.ListArgs{})
+		if err != nil {
+			t.Fatalf("failed list %s: %+v", path, err)
+		}
+		var names []string
+		for _, obj := range objs {
+			names = append(names, obj.GetName())
+		}
+		slices.Sort(names)
+		return names
+	}
+	if got := list("/"); !slices.Equal(got, []string{"Docs", "PDFs"}) {
+		t.Errorf("unexpected saved searches %v", got)
+	}
+	if got := list("/PDFs"); !slices.Equal(got, []string{"2024.pdf", "a (1).pdf", "a.pdf"}) {
+		t.Errorf("unexpected results %v", got)
+	}
+	if got := list("/Docs/reports"); !slices.Equal(got, []string{"2024.pdf"}) {
+		t.Errorf("unexpected objs under a result %v", got)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		link, _, err := op.Link(ctx, storage, path, model.LinkArgs{})
+		if err != nil {
+			t.Fatalf("failed link %s: %+v", path, err)
+		}
+		defer link.MFile.Close()
+		data, err := io.ReadAll(link.MFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if got := read("/Docs/reports/2024.pdf"); got != "2024" {
+		t.Errorf("unexpected content %q", got)
+	}
+	a, a1 := read("/PDFs/a.pdf"), read("/PDFs/a (1).pdf")
+	if a == a1 || !slices.Contains([]string{"a", "old a"}, a) || !slices.Contains([]string{"a", "old a"}, a1) {
+		t.Errorf("unexpected contents %q and %q of the results of the same name", a, a1)
+	}
+
+	// the objs under a result are checked too and nothing is visible without a user
+	if err = op.CreateMeta(&model.Meta{Path: "/local/docs/reports", Password: "secret", PSub: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := list("/Docs/reports"); len(got) != 0 {
+		t.Errorf("unexpected objs under a protected folder %v", got)
+	}
+	if _, _, err = op.Link(ctx, storage, "/Docs/reports/2024.pdf", model.LinkArgs{}); err == nil {
+		t.Errorf("expect the protected file denied")
+	}
+	objs, _ := op.List(context.Background(), storage, "/PDFs", model.ListArgs{})
+	if len(objs) != 0 {
+		t.Errorf("unexpected results without a user %v", objs)
+	}
+}
@@ -0,0 +1,26 @@
+package smart
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	Searches   string `json:"searches" type:"text" required:"true" help:"The saved searches shown as folders, one per line as name:query, the query takes the keywords and filters of the search, e.g. Recent PDFs:ext:pdf modified>30d path:/docs"`
+	MaxResults int    `json:"max_results" type:"number" default:"1000" help:"The most results listed in a folder"`
+}
+
+var config = driver.Config{
+	Name:        "Smart",
+	LocalSort:   true,
+	NoCache:     true,
+	NoUpload:    true,
+	DefaultRoot: "/",
+	Alert:       "info|The folders are filled by the search index, which must be enabled and built.",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Smart{}
+	})
+}
@@ -0,0 +1,16 @@
+package smart
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type savedSearch struct {
+	name string
+	req  model.SearchReq
+}
+
+type Object struct {
+	model.Object
+	// the path of the obj in the storage it's found in
+	target string
+}
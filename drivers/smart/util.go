@@ -0,0 +1,196 @@
+package smart
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// targetsExpiration is how long the names of the last results of a saved search are kept,
+// so that opening a result doesn't run the search again
+const targetsExpiration = 5 * time.Minute
+
+func (d *Smart) savedSearch(name string) *savedSearch {
+	for i := range d.searches {
+		if d.searches[i].name == name {
+			return &d.searches[i]
+		}
+	}
+	return nil
+}
+
+func (d *Smart) folder(s *savedSearch) model.Obj {
+	return &model.Object{
+		Path:     "/" + s.name,
+		Name:     s.name,
+		Modified: d.Modified,
+		IsFolder: true,
+	}
+}
+
+// split splits the path into the saved search, the name of the result and the path under the result
+func (d *Smart) split(path string) (s *savedSearch, entry, sub string) {
+	parts := strings.SplitN(strings.TrimPrefix(utils.FixAndCleanPath(path), "/"), "/", 3)
+	if s = d.savedSearch(parts[0]); s == nil {
+		return nil, "", ""
+	}
+	if len(parts) > 1 {
+		entry = parts[1]
+	}
+	if len(parts) > 2 {
+		sub = parts[2]
+	}
+	return s, entry, sub
+}
+
+// targetsKey returns the key of the names of the results in the targets cache,
+// the results depend on the user as they are filtered by permission
+func targetsKey(user *model.User, s *savedSearch) string {
+	if user == nil {
+		return s.name
+	}
+	return fmt.Sprintf("%s/%d", s.name, user.ID)
+}
+
+// results runs the saved search, the results are named after the objs found
+// unless several ones have the same name, the other ones are named like "name (1).ext"
+func (d *Smart) results(ctx context.Context, s *savedSearch) ([]*Object, error) {
+	user, _ := ctx.Value("user").(*model.User)
+	req := s.req
+	var objs []*Object
+	names := make(map[string]struct{})
+	for len(objs) < d.MaxResults {
+		nodes, _, err := search.Search(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			target := stdpath.Join(node.Parent, node.Name)
+			if !d.visible(user, target) {
+				continue
+			}
+			name := freeName(names, node.Name)
+			objs = append(objs, &Object{
+				Object: model.Object{
+					Path:     stdpath.Join("/", s.name, name),
+					Name:     name,
+					Size:     node.Size,
+					Modified: node.Modified,
+					IsFolder: node.IsDir,
+				},
+				target: target,
+			})
+			if len(objs) >= d.MaxResults {
+				break
+			}
+		}
+		if len(nodes) < req.PerPage {
+			break
+		}
+		req.Page++
+	}
+	targets := make(map[string]string, len(objs))
+	for _, obj := range objs {
+		targets[obj.Name] = obj.target
+	}
+	d.targets.Set(targetsKey(user, s), targets, cache.WithEx[map[string]string](targetsExpiration))
+	return objs, nil
+}
+
+// visible reports whether the user can access the obj at path, like in the search api, nothing is visible
+// without a user. The objs of this storage aren't shown so that a search can't find itself
+func (d *Smart) visible(user *model.User, path string) bool {
+	if user == nil || utils.IsSubPath(d.MountPath, path) {
+		return false
+	}
+	if !strings.HasPrefix(path, user.BasePath) {
+		return false
+	}
+	meta, err := op.GetNearestMeta(stdpath.Dir(path))
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	return common.CanAccessWithRoles(user, meta, path, "")
+}
+
+// resolve returns the path of the obj at the sub path of the result of the saved search if the user
+// can access it, the search only runs again if the names of its last results are no longer cached
+func (d *Smart) resolve(ctx context.Context, s *savedSearch, entry, sub string) (string, error) {
+	user, _ := ctx.Value("user").(*model.User)
+	targets, ok := d.targets.Get(targetsKey(user, s))
+	if !ok {
+		if _, err := d.results(ctx, s); err != nil {
+			return "", err
+		}
+		targets, _ = d.targets.Get(targetsKey(user, s))
+	}
+	target, ok := targets[entry]
+	if !ok {
+		return "", errs.ObjectNotFound
+	}
+	target = stdpath.Join(target, sub)
+	if !d.visible(user, target) {
+		return "", errs.PermissionDenied
+	}
+	return target, nil
+}
+
+// targetOf returns the path of the obj in the storage it's found in if the user can access it
+func (d *Smart) targetOf(ctx context.Context, obj model.Obj) (string, error) {
+	if o, ok := model.UnwrapObj(obj).(*Object); ok && o.target != "" {
+		if user, _ := ctx.Value("user").(*model.User); !d.visible(user, o.target) {
+			return "", errs.PermissionDenied
+		}
+		return o.target, nil
+	}
+	s, entry, sub := d.split(obj.GetPath())
+	if s == nil || entry == "" {
+		return "", errs.NotFile
+	}
+	return d.resolve(ctx, s, entry, sub)
+}
+
+func toObject(path, target string, obj model.Obj) *Object {
+	return &Object{
+		Object: model.Object{
+			Path:     path,
+			Name:     obj.GetName(),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+			Ctime:    obj.CreateTime(),
+			IsFolder: obj.IsDir(),
+			HashInfo: obj.GetHash(),
+		},
+		target: target,
+	}
+}
+
+// freeName returns name if it isn't used yet, otherwise the first free name like "name (1).ext"
+func freeName(used map[string]struct{}, name string) string {
+	ext := stdpath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// dot files like ".env" have no extension
+		base, ext = name, ""
+	}
+	n := name
+	for i := 1; ; i++ {
+		if _, ok := used[n]; !ok {
+			break
+		}
+		n = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	used[n] = struct{}{}
+	return n
+}
@@ -0,0 +1,338 @@
+package union
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Union struct {
+	model.Storage
+	Addition
+	remotes []*remote
+}
+
+func (d *Union) Config() driver.Config {
+	return config
+}
+
+func (d *Union) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Union) Init(ctx context.Context) error {
+	d.remotes = nil
+	for _, p := range strings.Split(d.RemotePaths, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = utils.FixAndCleanPath(p)
+		if _, _, err := op.GetStorageAndActualPath(p); err != nil {
+			return errors.WithMessagef(err, "can't find remote storage of %s", p)
+		}
+		d.remotes = append(d.remotes, &remote{path: p})
+	}
+	if len(d.remotes) == 0 {
+		return errors.New("remote_paths is empty")
+	}
+	switch d.Mode {
+	case modeMirror, "":
+		d.Mode = modeMirror
+		if d.Copies < 1 || d.Copies > len(d.remotes) {
+			return errors.Errorf("copies must be between 1 and the number of remotes (%d)", len(d.remotes))
+		}
+	case modeErasure:
+		if d.DataShards < 1 || d.ParityShards < 1 {
+			return errors.New("data_shards and parity_shards must be at least 1")
+		}
+		if d.DataShards+d.ParityShards > len(d.remotes) {
+			return errors.Errorf("every shard must be stored on a different remote, data_shards + parity_shards can't be more than %d", len(d.remotes))
+		}
+	default:
+		return errors.Errorf("unknown mode %s", d.Mode)
+	}
+	return nil
+}
+
+func (d *Union) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Union) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &Object{
+		Object: model.Object{
+			Path:     "/",
+			Name:     op.RootName,
+			Modified: d.Modified,
+			IsFolder: true,
+		},
+	}, nil
+}
+
+// List merges the entries of the folder on all the remotes, the shards of a file are shown as the file
+func (d *Union) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var (
+		objs    []*Object
+		byName  = make(map[string]*Object)
+		listed  int
+		lastErr error
+	)
+	for i := range d.remotes {
+		storage, actualPath, err := d.storageOf(i, dir.GetPath())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		start := time.Now()
+		entries, err := op.List(ctx, storage, actualPath, model.ListArgs{Refresh: args.Refresh, NoUpdateIndex: true})
+		d.remotes[i].record(start, err)
+		if err != nil {
+			// the folder may not have been created on every remote
+			if !errs.IsObjectNotFound(err) {
+				lastErr = err
+			}
+			continue
+		}
+		listed++
+		for _, entry := range entries {
+			if !entry.IsDir() && isTmpName(entry.GetName()) {
+				continue
+			}
+			rep := replica{remote: i, path: stdpath.Join(dir.GetPath(), entry.GetName()), size: entry.GetSize(), hash: entry.GetHash()}
+			name := entry.GetName()
+			var l *layout
+			if !entry.IsDir() {
+				if shardOf, shardLayout, shard, ok := parseShardName(name); ok {
+					name, l, rep.shard = shardOf, &shardLayout, shard
+				}
+			}
+			obj, ok := byName[name]
+			if !ok {
+				obj = &Object{
+					Object: model.Object{
+						Path:     stdpath.Join(dir.GetPath(), name),
+						Name:     name,
+						Size:     entry.GetSize(),
+						Modified: entry.ModTime(),
+						Ctime:    entry.CreateTime(),
+						IsFolder: entry.IsDir(),
+					},
+					layout: l,
+				}
+				if l != nil {
+					obj.Size = l.size
+				}
+				byName[name] = obj
+				objs = append(objs, obj)
+			}
+			// a file and a folder of the same name, or the shards of different versions of the file
+			if obj.IsDir() != entry.IsDir() || (obj.layout == nil) != (l == nil) || (l != nil && *obj.layout != *l) {
+				continue
+			}
+			if entry.ModTime().After(obj.Modified) {
+				obj.Modified = entry.ModTime()
+			}
+			obj.replicas = append(obj.replicas, rep)
+		}
+	}
+	if listed == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	for _, obj := range objs {
+		if !obj.IsDir() && obj.layout == nil {
+			obj.Size = commonSize(obj.replicas)
+			obj.HashInfo = utils.NewHashInfoByMap(commonHashes(obj.replicas, obj.Size))
+		}
+	}
+	return utils.SliceConvert(objs, func(obj *Object) (model.Obj, error) {
+		return obj, nil
+	})
+}
+
+func (d *Union) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	obj, err := toObject(file)
+	if err != nil {
+		return nil, err
+	}
+	size := obj.GetSize()
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{
+			RangeReader: func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+				if httpRange.Start < 0 || httpRange.Start > size {
+					return nil, errors.New("range start out of bound")
+				}
+				if httpRange.Length < 0 || httpRange.Start+httpRange.Length > size {
+					httpRange.Length = size - httpRange.Start
+				}
+				if obj.layout == nil {
+					return d.openCopy(ctx, obj, httpRange)
+				}
+				sr, err := d.newStripeReader(ctx, obj)
+				if err != nil {
+					return nil, err
+				}
+				return &erasureReader{
+					stripeReader: sr,
+					stripe:       httpRange.Start / obj.layout.stripeSize(),
+					skip:         httpRange.Start % obj.layout.stripeSize(),
+					remaining:    httpRange.Length,
+				}, nil
+			},
+		},
+	}, nil
+}
+
+// MakeDir makes the folder on all the remotes, it's enough that it's made on one of them
+func (d *Union) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	var lastErr error
+	made := false
+	for i := range d.remotes {
+		storage, actualPath, err := d.storageOf(i, stdpath.Join(parentDir.GetPath(), dirName))
+		if err == nil {
+			err = op.MakeDir(ctx, storage, actualPath)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		made = true
+	}
+	if !made {
+		return lastErr
+	}
+	return nil
+}
+
+func (d *Union) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	obj, err := toObject(srcObj)
+	if err != nil {
+		return err
+	}
+	return d.each(obj.replicas, func(rep replica, storage driver.Driver, actualPath string) error {
+		_, dstActualPath, err := d.storageOf(rep.remote, dstDir.GetPath())
+		if err != nil {
+			return err
+		}
+		if err = op.MakeDir(ctx, storage, dstActualPath); err != nil {
+			return err
+		}
+		return op.Move(ctx, storage, actualPath, dstActualPath)
+	})
+}
+
+func (d *Union) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	obj, err := toObject(srcObj)
+	if err != nil {
+		return err
+	}
+	return d.each(obj.replicas, func(rep replica, storage driver.Driver, actualPath string) error {
+		name := newName
+		if obj.layout != nil {
+			name = shardName(newName, *obj.layout, rep.shard)
+		}
+		return op.Rename(ctx, storage, actualPath, name)
+	})
+}
+
+func (d *Union) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	obj, err := toObject(srcObj)
+	if err != nil {
+		return err
+	}
+	return d.each(obj.replicas, func(rep replica, storage driver.Driver, actualPath string) error {
+		_, dstActualPath, err := d.storageOf(rep.remote, dstDir.GetPath())
+		if err != nil {
+			return err
+		}
+		if err = op.MakeDir(ctx, storage, dstActualPath); err != nil {
+			return err
+		}
+		return op.Copy(ctx, storage, actualPath, dstActualPath)
+	})
+}
+
+func (d *Union) Remove(ctx context.Context, obj model.Obj) error {
+	o, err := toObject(obj)
+	if err != nil {
+		return err
+	}
+	return d.each(o.replicas, func(rep replica, storage driver.Driver, actualPath string) error {
+		return op.Remove(ctx, storage, actualPath)
+	})
+}
+
+// Put writes the file to Copies remotes in mirror mode, or splits it into shards
+// each one written to a different remote in erasure mode
+func (d *Union) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) error {
+	size := file.GetSize()
+	if size < 0 {
+		return errors.New("the size of the file must be known")
+	}
+	name := file.GetName()
+	path := stdpath.Join(dstDir.GetPath(), name)
+	modified := file.ModTime()
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	r := io.TeeReader(file, driver.NewProgress(size, up))
+	var (
+		targets []target
+		write   func(ws []io.Writer) error
+	)
+	if d.Mode == modeErasure {
+		l := layout{data: d.DataShards, parity: d.ParityShards, size: size}
+		for i, rmt := range d.placement(path, l.shards(), nil) {
+			targets = append(targets, target{remote: rmt, name: shardName(name, l, i), size: l.shardSize()})
+		}
+		write = func(ws []io.Writer) error {
+			return encode(l, r, ws)
+		}
+	} else {
+		for _, rmt := range d.placement(path, d.Copies, nil) {
+			targets = append(targets, target{remote: rmt, name: name, size: size})
+		}
+		write = func(ws []io.Writer) error {
+			_, err := utils.CopyWithBuffer(io.MultiWriter(ws...), r)
+			return err
+		}
+	}
+	if err := d.upload(ctx, dstDir.GetPath(), targets, modified, write); err != nil {
+		return err
+	}
+	// the copies or shards of the previous version which haven't been overwritten
+	if exist, ok := file.GetExist().(*Object); ok {
+		return d.removeStale(ctx, exist.replicas, dstDir.GetPath(), targets)
+	}
+	return nil
+}
+
+func (d *Union) Scrub(ctx context.Context, repair bool, up driver.UpdateProgress) (*model.ScrubResult, error) {
+	root, _ := d.GetRoot(ctx)
+	objs, err := d.List(ctx, root, model.ListArgs{Refresh: true})
+	if err != nil {
+		return nil, err
+	}
+	res := &model.ScrubResult{Lost: []string{}}
+	for i, obj := range objs {
+		if err := d.scrub(ctx, obj.(*Object), repair, res); err != nil {
+			return res, err
+		}
+		up(float64(i+1) / float64(len(objs)) * 100)
+	}
+	return res, nil
+}
+
+var _ driver.Driver = (*Union)(nil)
+var _ driver.GetRooter = (*Union)(nil)
+var _ driver.Scrubber = (*Union)(nil)
@@ -0,0 +1,183 @@
+package union
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestLayout(t *testing.T) {
+	for _, size := range []int64{0, 1, blockSize, 2*blockSize + 1, 5*blockSize + 123} {
+		l := layout{data: 2, parity: 1, size: size}
+		var total int64
+		for s := int64(0); s < l.stripes(); s++ {
+			if l.blockLen(s)*int64(l.data) < l.dataLen(s) {
+				t.Errorf("size %d: the blocks of stripe %d are too short", size, s)
+			}
+			total += l.dataLen(s)
+		}
+		if total != size {
+			t.Errorf("size %d: the stripes hold %d bytes", size, total)
+		}
+		name, parsed, shard, ok := parseShardName(shardName("a.rs1-1-1-1", l, 2))
+		if !ok || name != "a.rs1-1-1-1" || parsed != l || shard != 2 {
+			t.Errorf("size %d: the shard name is parsed as %q %+v %d", size, name, parsed, shard)
+		}
+	}
+}
+
+func TestCopies(t *testing.T) {
+	rep := func(remote int, md5 string) replica {
+		return replica{remote: remote, size: 3, hash: utils.NewHashInfo(utils.MD5, md5)}
+	}
+	obj := &Object{Object: model.Object{Size: 3}, replicas: []replica{rep(0, "a"), rep(1, "b"), rep(2, "a"), {remote: 3, size: 2}}}
+	obj.HashInfo = utils.NewHashInfoByMap(commonHashes(obj.replicas, obj.Size))
+	copies := obj.copies()
+	if len(copies) != 2 || copies[0].remote != 0 || copies[1].remote != 2 {
+		t.Errorf("the corrupted or stale copies are kept: %+v", copies)
+	}
+	if hashConflict(copies) != nil {
+		t.Errorf("expect no conflict between the intact copies")
+	}
+	obj.replicas = obj.replicas[:2]
+	obj.HashInfo = utils.NewHashInfoByMap(commonHashes(obj.replicas, obj.Size))
+	if hashConflict(obj.copies()) != utils.MD5 {
+		t.Errorf("expect a conflict without a hash most of the copies have")
+	}
+}
+
+func TestUnion(t *testing.T) {
+	ctx := context.Background()
+	var remotes []driver.Driver
+	for i := 0; i < 3; i++ {
+		mountPath := fmt.Sprintf("/r%d", i)
+		_, err := op.CreateStorage(ctx, model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, t.TempDir()),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+		remote, _ := op.GetStorageByMountPath(mountPath)
+		remotes = append(remotes, remote)
+	}
+	content := make([]byte, 5*blockSize+123)
+	rand.New(rand.NewSource(3)).Read(content)
+
+	for _, mode := range []string{modeErasure, modeMirror} {
+		_, err := op.CreateStorage(ctx, model.Storage{
+			Driver:    "Union",
+			MountPath: "/" + mode,
+			Addition: fmt.Sprintf(`{"remote_paths":"/r0/%[1]s\n/r1/%[1]s\n/r2/%[1]s","mode":%[1]q,"copies":2,"data_shards":2,"parity_shards":1}`,
+				mode),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+		storage, _ := op.GetStorageByMountPath("/" + mode)
+		err = op.Put(ctx, storage, "/dir", &stream.FileStream{
+			Obj: &model.Object{
+				Name:     "a.img",
+				Size:     int64(len(content)),
+				Modified: time.Now(),
+			},
+			Reader: bytes.NewReader(content),
+		}, nil)
+		if err != nil {
+			t.Fatalf("%s: failed put: %+v", mode, err)
+		}
+		read := func(path string, start, length int64) []byte {
+			t.Helper()
+			link, _, err := op.Link(ctx, storage, path, model.LinkArgs{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc, err := link.RangeReadCloser.RangeRead(ctx, http_range.Range{Start: start, Length: length})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("%s: failed read: %+v", mode, err)
+			}
+			return data
+		}
+		// the remotes holding a copy or a shard of the file
+		stored := func() []int {
+			t.Helper()
+			var res []int
+			for i, remote := range remotes {
+				objs, err := op.List(ctx, remote, "/"+mode+"/dir", model.ListArgs{Refresh: true})
+				if err == nil && len(objs) == 1 {
+					res = append(res, i)
+				}
+			}
+			return res
+		}
+		if want := map[string]int{modeErasure: 3, modeMirror: 2}[mode]; len(stored()) != want {
+			t.Fatalf("%s: the file is stored on %v", mode, stored())
+		}
+		if !bytes.Equal(read("/dir/a.img", 0, -1), content) {
+			t.Errorf("%s: the content read is different", mode)
+		}
+		if !bytes.Equal(read("/dir/a.img", 2*blockSize-10, blockSize), content[2*blockSize-10:3*blockSize-10]) {
+			t.Errorf("%s: the range read is different", mode)
+		}
+
+		// a remote loses the file, it's still readable and the scrub re-creates it
+		lost := stored()[0]
+		objs, _ := op.List(ctx, remotes[lost], "/"+mode+"/dir", model.ListArgs{})
+		if err = op.Remove(ctx, remotes[lost], "/"+mode+"/dir/"+objs[0].GetName()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(read("/dir/a.img", 0, -1), content) {
+			t.Errorf("%s: the content read without a remote is different", mode)
+		}
+		scrubber := storage.(driver.Scrubber)
+		res, err := scrubber.Scrub(ctx, false, func(float64) {})
+		if err != nil || res.Files != 1 || res.Degraded != 1 || res.Repaired != 0 {
+			t.Fatalf("%s: unexpected scrub result %+v: %+v", mode, res, err)
+		}
+		res, err = scrubber.Scrub(ctx, true, func(float64) {})
+		if err != nil || res.Repaired != 1 || len(res.Lost) != 0 {
+			t.Fatalf("%s: unexpected repair result %+v: %+v", mode, res, err)
+		}
+		if res, _ = scrubber.Scrub(ctx, false, func(float64) {}); res.Degraded != 0 {
+			t.Errorf("%s: the file is still degraded after the repair", mode)
+		}
+
+		if err = op.Rename(ctx, storage, "/dir/a.img", "b.img"); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(read("/dir/b.img", 0, -1), content) {
+			t.Errorf("%s: the content read after renaming is different", mode)
+		}
+	}
+}
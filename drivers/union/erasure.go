@@ -0,0 +1,210 @@
+package union
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/klauspost/reedsolomon"
+	"github.com/pkg/errors"
+)
+
+// encode splits the content read from r into the shards of l, and writes them through ws stripe by stripe
+func encode(l layout, r io.Reader, ws []io.Writer) error {
+	enc, err := reedsolomon.New(l.data, l.parity)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	buf := make([]byte, int64(l.shards())*blockSize)
+	shards := make([][]byte, l.shards())
+	for s := int64(0); s < l.stripes(); s++ {
+		n, bl := l.dataLen(s), l.blockLen(s)
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return errors.Wrap(err, "failed read the file")
+		}
+		// the last stripe is padded with zeros
+		clear(buf[n : int64(l.data)*bl])
+		for i := range shards {
+			shards[i] = buf[int64(i)*bl : int64(i+1)*bl]
+		}
+		if err := enc.Encode(shards); err != nil {
+			return errors.WithStack(err)
+		}
+		for i, w := range ws {
+			if _, err := w.Write(shards[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shardStream reads the blocks of a shard in sequence
+type shardStream struct {
+	// the copies of the shard which haven't failed, usually only one
+	candidates []replica
+	rc         io.ReadCloser
+	pos        int64
+}
+
+func (st *shardStream) read(ctx context.Context, d *Union, off, n int64) ([]byte, error) {
+	for len(st.candidates) > 0 {
+		rep := st.candidates[0]
+		if st.rc == nil || st.pos != off {
+			st.close()
+			rc, err := d.openReplica(ctx, rep, http_range.Range{Start: off, Length: -1})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				st.candidates = st.candidates[1:]
+				continue
+			}
+			st.rc, st.pos = rc, off
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(st.rc, buf); err != nil {
+			st.close()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			d.remotes[rep.remote].record(time.Now(), err)
+			st.candidates = st.candidates[1:]
+			continue
+		}
+		st.pos += n
+		return buf, nil
+	}
+	return nil, errors.New("no readable copy of the shard")
+}
+
+func (st *shardStream) close() {
+	if st.rc != nil {
+		_ = st.rc.Close()
+		st.rc = nil
+	}
+}
+
+// stripeReader reads the stripes of an erasure coded file, the shards which are missing
+// or fail to be read are reconstructed from the other ones
+type stripeReader struct {
+	ctx    context.Context
+	d      *Union
+	l      layout
+	enc    reedsolomon.Encoder
+	shards []*shardStream
+	// the indexes of the shards in the order they should be read
+	order []int
+}
+
+func (d *Union) newStripeReader(ctx context.Context, obj *Object) (*stripeReader, error) {
+	l := *obj.layout
+	enc, err := reedsolomon.New(l.data, l.parity)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r := &stripeReader{ctx: ctx, d: d, l: l, enc: enc, shards: make([]*shardStream, l.shards())}
+	for _, rep := range d.sortReplicas(obj.replicas) {
+		if rep.size != l.shardSize() {
+			continue
+		}
+		if r.shards[rep.shard] == nil {
+			r.shards[rep.shard] = &shardStream{}
+			r.order = append(r.order, rep.shard)
+		}
+		r.shards[rep.shard].candidates = append(r.shards[rep.shard].candidates, rep)
+	}
+	healthy := make(map[int]bool, len(r.order))
+	for _, i := range r.order {
+		healthy[i] = d.remotes[r.shards[i].candidates[0].remote].healthy()
+	}
+	// the data shards need no decoding, so the healthy data shards are read first,
+	// then the healthy parity shards from the fastest one
+	sort.SliceStable(r.order, func(a, b int) bool {
+		ia, ib := r.order[a], r.order[b]
+		if healthy[ia] != healthy[ib] {
+			return healthy[ia]
+		}
+		return ia < l.data && ib >= l.data
+	})
+	if len(r.order) < l.data {
+		return nil, errors.Errorf("only %d of the %d shards needed are available", len(r.order), l.data)
+	}
+	return r, nil
+}
+
+// readStripe returns the blocks of the stripe s, only the data blocks are complete unless all is true
+func (r *stripeReader) readStripe(s int64, all bool) ([][]byte, error) {
+	bl := r.l.blockLen(s)
+	blocks := make([][]byte, r.l.shards())
+	got := 0
+	for _, i := range r.order {
+		if got == r.l.data {
+			break
+		}
+		buf, err := r.shards[i].read(r.ctx, r.d, s*blockSize, bl)
+		if err != nil {
+			if r.ctx.Err() != nil {
+				return nil, r.ctx.Err()
+			}
+			continue
+		}
+		blocks[i] = buf
+		got++
+	}
+	if got < r.l.data {
+		return nil, errors.Errorf("only %d of the %d shards needed are readable", got, r.l.data)
+	}
+	var err error
+	if all {
+		err = r.enc.Reconstruct(blocks)
+	} else {
+		err = r.enc.ReconstructData(blocks)
+	}
+	return blocks, errors.WithStack(err)
+}
+
+func (r *stripeReader) Close() error {
+	for _, st := range r.shards {
+		if st != nil {
+			st.close()
+		}
+	}
+	return nil
+}
+
+// erasureReader reads a range of an erasure coded file
+type erasureReader struct {
+	*stripeReader
+	stripe    int64
+	skip      int64
+	remaining int64
+	buf       []byte
+}
+
+func (r *erasureReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.remaining <= 0 {
+			return 0, io.EOF
+		}
+		blocks, err := r.readStripe(r.stripe, false)
+		if err != nil {
+			return 0, err
+		}
+		data := make([]byte, 0, r.l.dataLen(r.stripe))
+		for _, block := range blocks[:r.l.data] {
+			data = append(data, block[:min(int64(len(block)), int64(cap(data)-len(data)))]...)
+		}
+		r.buf = data[r.skip:]
+		r.stripe, r.skip = r.stripe+1, 0
+	}
+	if int64(len(r.buf)) > r.remaining {
+		r.buf = r.buf[:r.remaining]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.remaining -= int64(n)
+	return n, nil
+}
@@ -0,0 +1,39 @@
+package union
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+const (
+	modeMirror  = "mirror"
+	modeErasure = "erasure"
+)
+
+type Addition struct {
+	RemotePaths  string `json:"remote_paths" type:"text" required:"true" help:"AList mounted folder paths backing the storage, one per line, each one should be on a different storage"`
+	Mode         string `json:"mode" type:"select" options:"mirror,erasure" default:"mirror" help:"mirror: every file is copied to some of the remotes; erasure: every file is split into data and parity shards, each one stored on a different remote"`
+	Copies       int    `json:"copies" type:"number" default:"2" help:"The number of remotes a file is copied to in mirror mode"`
+	DataShards   int    `json:"data_shards" type:"number" default:"2" help:"The number of data shards of a file in erasure mode"`
+	ParityShards int    `json:"parity_shards" type:"number" default:"1" help:"The number of parity shards of a file in erasure mode, that is the number of shards which can be lost"`
+}
+
+var config = driver.Config{
+	Name:              "Union",
+	LocalSort:         true,
+	OnlyLocal:         false,
+	OnlyProxy:         true,
+	NoCache:           true,
+	NoUpload:          false,
+	NeedMs:            false,
+	DefaultRoot:       "/",
+	CheckStatus:       false,
+	Alert:             "info|The remotes should only be changed by this storage. The missing copies and shards are re-created by the scrub task.",
+	NoOverwriteUpload: false,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Union{}
+	})
+}
@@ -0,0 +1,164 @@
+package union
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"slices"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// scrub checks the obj and the objs under it, a file which can't be repaired doesn't stop the scrub
+func (d *Union) scrub(ctx context.Context, obj *Object, repair bool, res *model.ScrubResult) error {
+	if utils.IsCanceled(ctx) {
+		return ctx.Err()
+	}
+	if obj.IsDir() {
+		objs, err := d.List(ctx, obj, model.ListArgs{Refresh: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", obj.GetPath())
+		}
+		for _, child := range objs {
+			if err := d.scrub(ctx, child.(*Object), repair, res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	res.Files++
+	var (
+		degraded bool
+		err      error
+	)
+	if obj.layout == nil {
+		degraded, err = d.scrubCopies(ctx, obj, repair)
+	} else {
+		degraded, err = d.scrubShards(ctx, obj, repair, res)
+	}
+	if degraded {
+		res.Degraded++
+	}
+	if err != nil {
+		log.Warnf("failed repair [%s]: %+v", obj.GetPath(), err)
+	} else if degraded && repair {
+		res.Repaired++
+	}
+	return nil
+}
+
+// scrubCopies checks that the mirrored file has Copies copies, the stale copies and the ones
+// with another hash than most of the copies are replaced
+func (d *Union) scrubCopies(ctx context.Context, obj *Object, repair bool) (bool, error) {
+	copies := obj.copies()
+	conflict := hashConflict(copies)
+	missing := d.Copies - len(copies)
+	if missing <= 0 && len(copies) == len(obj.replicas) && conflict == nil {
+		return false, nil
+	}
+	if !repair {
+		return true, nil
+	}
+	if conflict != nil {
+		return true, errors.Errorf("the copies have different %s hashes, which one is intact is unknown", conflict.Name)
+	}
+	dir := stdpath.Dir(obj.GetPath())
+	var targets []target
+	if missing > 0 {
+		held := make(map[int]bool, len(copies))
+		for _, rep := range copies {
+			held[rep.remote] = true
+		}
+		for _, rmt := range d.placement(obj.GetPath(), missing, held) {
+			targets = append(targets, target{remote: rmt, name: obj.GetName(), size: obj.GetSize()})
+		}
+		err := d.upload(ctx, dir, targets, obj.ModTime(), func(ws []io.Writer) error {
+			rc, err := d.openCopy(ctx, obj, http_range.Range{Length: -1})
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			_, err = utils.CopyWithBuffer(io.MultiWriter(ws...), rc)
+			return err
+		})
+		if err != nil {
+			return true, err
+		}
+	}
+	stale := utils.SliceFilter(obj.replicas, func(rep replica) bool {
+		return !slices.ContainsFunc(copies, func(c replica) bool {
+			return c.remote == rep.remote && c.path == rep.path
+		})
+	})
+	return true, d.removeStale(ctx, stale, dir, targets)
+}
+
+// scrubShards checks that the erasure coded file has all its shards and re-creates the missing ones
+// from the other ones, the file is lost if less than DataShards shards are left
+func (d *Union) scrubShards(ctx context.Context, obj *Object, repair bool, res *model.ScrubResult) (bool, error) {
+	l := *obj.layout
+	present := make(map[int]bool, l.shards())
+	held := make(map[int]bool, l.shards())
+	var stale []replica
+	for _, rep := range obj.replicas {
+		if rep.size != l.shardSize() {
+			stale = append(stale, rep)
+			continue
+		}
+		present[rep.shard], held[rep.remote] = true, true
+	}
+	var missing []int
+	for i := 0; i < l.shards(); i++ {
+		if !present[i] {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 && len(stale) == 0 {
+		return false, nil
+	}
+	if len(present) < l.data {
+		res.Lost = append(res.Lost, obj.GetPath())
+		return true, errors.Errorf("only %d of the %d shards needed are left", len(present), l.data)
+	}
+	if !repair {
+		return true, nil
+	}
+	dir := stdpath.Dir(obj.GetPath())
+	var targets []target
+	if len(missing) > 0 {
+		remotes := d.placement(obj.GetPath(), len(missing), held)
+		if len(remotes) < len(missing) {
+			return true, errors.Errorf("no remote left to store %d shards on", len(missing)-len(remotes))
+		}
+		for j, i := range missing {
+			targets = append(targets, target{remote: remotes[j], name: shardName(obj.GetName(), l, i), size: l.shardSize()})
+		}
+		sr, err := d.newStripeReader(ctx, obj)
+		if err != nil {
+			return true, err
+		}
+		defer sr.Close()
+		err = d.upload(ctx, dir, targets, obj.ModTime(), func(ws []io.Writer) error {
+			for s := int64(0); s < l.stripes(); s++ {
+				blocks, err := sr.readStripe(s, true)
+				if err != nil {
+					return err
+				}
+				for j, i := range missing {
+					if _, err := ws[j].Write(blocks[i]); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return true, err
+		}
+	}
+	return true, d.removeStale(ctx, stale, dir, targets)
+}
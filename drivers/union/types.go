@@ -0,0 +1,108 @@
+package union
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+)
+
+// Object is a file or a folder of the union, made of its copies or shards stored on the remotes
+type Object struct {
+	model.Object
+	replicas []replica
+	// nil for the folders and the mirrored files
+	layout *layout
+}
+
+// replica is a copy of a mirrored file, a shard of an erasure coded file or a folder on one of the remotes
+type replica struct {
+	remote int
+	// the path relative to the remote
+	path  string
+	size  int64
+	shard int
+	// the hashes given by the remote, if any
+	hash utils.HashInfo
+}
+
+// the size of the blocks the shards are made of, a stripe of the file is split into DataShards blocks
+// and each shard holds one block of every stripe, so that a range can be read without reading the whole shards
+const blockSize int64 = 1 << 20
+
+// layout is the geometry of the shards of an erasure coded file,
+// it's kept in the names of the shards so that no manifest is needed
+type layout struct {
+	data   int
+	parity int
+	size   int64
+}
+
+// the copies and shards are uploaded under a temp name and renamed once all of them are uploaded,
+// so that a failed upload doesn't replace the previous version. The temp names aren't listed
+const tmpSuffix = ".union-uploading"
+
+func tmpName(name string) string {
+	return fmt.Sprintf("%s.%s%s", name, random.String(8), tmpSuffix)
+}
+
+func isTmpName(name string) bool {
+	return strings.HasSuffix(name, tmpSuffix)
+}
+
+var shardNameRe = regexp.MustCompile(`^(.+)\.rs(\d+)-(\d+)-(\d+)-(\d+)$`)
+
+func shardName(name string, l layout, shard int) string {
+	return fmt.Sprintf("%s.rs%d-%d-%d-%d", name, l.data, l.parity, shard, l.size)
+}
+
+// parseShardName returns the name of the file, its layout and the index of the shard
+func parseShardName(raw string) (string, layout, int, bool) {
+	m := shardNameRe.FindStringSubmatch(raw)
+	if m == nil {
+		return "", layout{}, 0, false
+	}
+	data, _ := strconv.Atoi(m[2])
+	parity, _ := strconv.Atoi(m[3])
+	shard, _ := strconv.Atoi(m[4])
+	size, err := strconv.ParseInt(m[5], 10, 64)
+	if err != nil || data < 1 || parity < 0 || data+parity > 256 || shard >= data+parity {
+		return "", layout{}, 0, false
+	}
+	return m[1], layout{data: data, parity: parity, size: size}, shard, true
+}
+
+func (l layout) shards() int {
+	return l.data + l.parity
+}
+
+func (l layout) stripeSize() int64 {
+	return int64(l.data) * blockSize
+}
+
+func (l layout) stripes() int64 {
+	return (l.size + l.stripeSize() - 1) / l.stripeSize()
+}
+
+// blockLen returns the length of the blocks of the stripe s, the blocks of the last stripe are shorter
+func (l layout) blockLen(s int64) int64 {
+	if s < l.size/l.stripeSize() {
+		return blockSize
+	}
+	rest := l.size % l.stripeSize()
+	return (rest + int64(l.data) - 1) / int64(l.data)
+}
+
+// dataLen returns the length of the data of the file in the stripe s, without the padding of the last one
+func (l layout) dataLen(s int64) int64 {
+	return min(l.stripeSize(), l.size-s*l.stripeSize())
+}
+
+func (l layout) shardSize() int64 {
+	full := l.size / l.stripeSize()
+	return full*blockSize + l.blockLen(full)
+}
@@ -0,0 +1,430 @@
+package union
+
+import (
+	"cmp"
+	"context"
+	"hash/fnv"
+	"io"
+	stdpath "path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// a remote which failed is only used when there is no other choice until this delay has passed
+const failureCooldown = time.Minute
+
+// remote is a backing folder, with how fast and healthy it has been lately
+type remote struct {
+	path    string
+	mu      sync.Mutex
+	latency time.Duration
+	failed  time.Time
+}
+
+func (r *remote) healthy() bool {
+	r.mu.Lock()
+	failed := r.failed
+	r.mu.Unlock()
+	if time.Since(failed) < failureCooldown {
+		return false
+	}
+	storage, _, err := op.GetStorageAndActualPath(r.path)
+	return err == nil && storage.GetStorage().Status == op.WORK
+}
+
+func (r *remote) getLatency() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latency
+}
+
+// record updates the state of the remote with a request started at start
+func (r *remote) record(start time.Time, err error) {
+	if errors.Is(err, context.Canceled) || errs.IsObjectNotFound(err) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failed = time.Now()
+		return
+	}
+	if d := time.Since(start); r.latency == 0 {
+		r.latency = d
+	} else {
+		r.latency = (r.latency*3 + d) / 4
+	}
+}
+
+func (d *Union) storageOf(i int, path string) (driver.Driver, string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(stdpath.Join(d.remotes[i].path, path))
+	if err != nil {
+		return nil, "", errors.WithMessagef(err, "failed get remote storage of %s", d.remotes[i].path)
+	}
+	return storage, actualPath, nil
+}
+
+// byPreference sorts the remotes so that the healthy ones come first, the fastest first
+func (d *Union) byPreference(remotes []int) {
+	healthy := make(map[int]bool, len(remotes))
+	latency := make(map[int]time.Duration, len(remotes))
+	for _, i := range remotes {
+		healthy[i], latency[i] = d.remotes[i].healthy(), d.remotes[i].getLatency()
+	}
+	sort.SliceStable(remotes, func(a, b int) bool {
+		ra, rb := remotes[a], remotes[b]
+		if healthy[ra] != healthy[rb] {
+			return healthy[ra]
+		}
+		return latency[ra] < latency[rb]
+	})
+}
+
+// sortReplicas sorts the replicas in the order they should be read
+func (d *Union) sortReplicas(replicas []replica) []replica {
+	var remotes []int
+	index := make(map[int][]int)
+	for i, rep := range replicas {
+		if _, ok := index[rep.remote]; !ok {
+			remotes = append(remotes, rep.remote)
+		}
+		index[rep.remote] = append(index[rep.remote], i)
+	}
+	d.byPreference(remotes)
+	res := make([]replica, 0, len(replicas))
+	for _, r := range remotes {
+		for _, i := range index[r] {
+			res = append(res, replicas[i])
+		}
+	}
+	return res
+}
+
+// placement returns up to n remotes to store a new file at path on, which aren't in exclude.
+// The files are spread by their paths, the healthy remotes are preferred.
+func (d *Union) placement(path string, n int, exclude map[int]bool) []int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	start := int(h.Sum32() % uint32(len(d.remotes)))
+	var candidates []int
+	for i := range d.remotes {
+		r := (start + i) % len(d.remotes)
+		if !exclude[r] {
+			candidates = append(candidates, r)
+		}
+	}
+	healthy := make(map[int]bool, len(candidates))
+	for _, r := range candidates {
+		healthy[r] = d.remotes[r].healthy()
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return healthy[candidates[a]] && !healthy[candidates[b]]
+	})
+	return candidates[:min(n, len(candidates))]
+}
+
+// openReplica reads the range of a copy or a shard
+func (d *Union) openReplica(ctx context.Context, rep replica, httpRange http_range.Range) (io.ReadCloser, error) {
+	start := time.Now()
+	storage, actualPath, err := d.storageOf(rep.remote, rep.path)
+	if err != nil {
+		return nil, err
+	}
+	link, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	var rc io.ReadCloser
+	if err == nil {
+		rc, err = openLink(ctx, link, obj.GetSize(), httpRange)
+	}
+	d.remotes[rep.remote].record(start, err)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed read %s of %s", rep.path, d.remotes[rep.remote].path)
+	}
+	return rc, nil
+}
+
+// openLink reads a range of the link, closing the reader releases the link
+func openLink(ctx context.Context, link *model.Link, size int64, httpRange http_range.Range) (io.ReadCloser, error) {
+	if httpRange.Length < 0 || httpRange.Start+httpRange.Length > size {
+		httpRange.Length = size - httpRange.Start
+	}
+	var (
+		rc  io.ReadCloser
+		err error
+	)
+	switch {
+	case link.MFile != nil:
+		rc = io.NopCloser(io.NewSectionReader(link.MFile, httpRange.Start, httpRange.Length))
+	case link.RangeReadCloser != nil:
+		rc, err = link.RangeReadCloser.RangeRead(ctx, httpRange)
+	case link.URL != "":
+		var rrc model.RangeReadCloserIF
+		rrc, err = stream.GetRangeReadCloserFromLink(size, link)
+		if err == nil {
+			rc, err = rrc.RangeRead(ctx, httpRange)
+		}
+	default:
+		err = errs.NotSupport
+	}
+	release := func() {
+		if link.MFile != nil {
+			_ = link.MFile.Close()
+		}
+		if link.RangeReadCloser != nil {
+			_ = link.RangeReadCloser.Close()
+		}
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return utils.NewReadCloser(rc, func() error {
+		err := rc.Close()
+		release()
+		return err
+	}), nil
+}
+
+// target is a copy or a shard to upload
+type target struct {
+	remote int
+	name   string
+	size   int64
+}
+
+var errUploadDone = errors.New("the upload stopped reading")
+
+// upload uploads the targets into the folder dir of their remotes at the same time,
+// write writes the content of all the targets through the writers in the same order.
+// They are uploaded under temp names and only replace the objs of the same names once
+// all of them are uploaded, if any upload fails the temp copies are removed.
+func (d *Union) upload(ctx context.Context, dir string, targets []target, modified time.Time, write func(ws []io.Writer) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tmpNames := make([]string, len(targets))
+	for i, t := range targets {
+		tmpNames[i] = tmpName(t.name)
+	}
+	pws := make([]*io.PipeWriter, len(targets))
+	ws := make([]io.Writer, len(targets))
+	putErrs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		pr, pw := io.Pipe()
+		pws[i], ws[i] = pw, pw
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			storage, dirActualPath, err := d.storageOf(t.remote, dir)
+			if err == nil {
+				err = op.Put(ctx, storage, dirActualPath, &stream.FileStream{
+					Ctx: ctx,
+					Obj: &model.Object{
+						Name:     tmpNames[i],
+						Size:     t.size,
+						Modified: modified,
+					},
+					Reader:   pr,
+					Mimetype: "application/octet-stream",
+				}, nil, false)
+				d.remotes[t.remote].record(start, err)
+			}
+			putErrs[i] = err
+			// unblocks the writes if the upload stopped before reading everything
+			_ = pr.CloseWithError(cmp.Or(err, errUploadDone))
+		}()
+	}
+	err := write(ws)
+	for _, pw := range pws {
+		_ = pw.CloseWithError(err)
+	}
+	if err != nil {
+		cancel()
+	}
+	wg.Wait()
+	for i := range targets {
+		if err == nil && putErrs[i] != nil {
+			err = errors.WithMessagef(putErrs[i], "failed upload to %s", d.remotes[targets[i].remote].path)
+		}
+	}
+	if err == nil {
+		err = d.commit(ctx, dir, targets, tmpNames)
+	}
+	if err != nil {
+		// the ones already renamed are kept as they replaced the previous version
+		for i, t := range targets {
+			_ = d.removeReplica(context.WithoutCancel(ctx), replica{remote: t.remote, path: stdpath.Join(dir, tmpNames[i])})
+		}
+	}
+	return err
+}
+
+// commit renames the uploaded targets from their temp names to their names, replacing the objs of the same names
+func (d *Union) commit(ctx context.Context, dir string, targets []target, tmpNames []string) error {
+	for i, t := range targets {
+		storage, dirActualPath, err := d.storageOf(t.remote, dir)
+		if err == nil {
+			err = op.Remove(ctx, storage, stdpath.Join(dirActualPath, t.name))
+		}
+		if err == nil {
+			err = op.Rename(ctx, storage, stdpath.Join(dirActualPath, tmpNames[i]), t.name)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed rename the upload on %s", d.remotes[t.remote].path)
+		}
+	}
+	return nil
+}
+
+func (d *Union) removeReplica(ctx context.Context, rep replica) error {
+	storage, actualPath, err := d.storageOf(rep.remote, rep.path)
+	if err != nil {
+		return err
+	}
+	return op.Remove(ctx, storage, actualPath)
+}
+
+func toObject(obj model.Obj) (*Object, error) {
+	o, ok := model.UnwrapObj(obj).(*Object)
+	if !ok {
+		return nil, errs.NotSupport
+	}
+	return o, nil
+}
+
+// each calls f for every replica, it goes on after a failure and returns the first error
+func (d *Union) each(replicas []replica, f func(rep replica, storage driver.Driver, actualPath string) error) error {
+	var firstErr error
+	for _, rep := range replicas {
+		storage, actualPath, err := d.storageOf(rep.remote, rep.path)
+		if err == nil {
+			err = f(rep, storage, actualPath)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = errors.WithMessagef(err, "failed on %s", d.remotes[rep.remote].path)
+		}
+	}
+	return firstErr
+}
+
+// commonSize returns the size most of the copies have, the other ones are stale
+func commonSize(replicas []replica) int64 {
+	count := make(map[int64]int)
+	var size int64
+	for _, rep := range replicas {
+		count[rep.size]++
+		if count[rep.size] > count[size] {
+			size = rep.size
+		}
+	}
+	return size
+}
+
+// commonHashes returns the hash more than half of the copies of the size have, for each type of hash
+// the remotes give. A copy with another hash is corrupted
+func commonHashes(replicas []replica, size int64) map[*utils.HashType]string {
+	count := make(map[*utils.HashType]map[string]int)
+	total := make(map[*utils.HashType]int)
+	for _, rep := range replicas {
+		if rep.size != size {
+			continue
+		}
+		for ht, h := range rep.hash.All() {
+			if h == "" {
+				continue
+			}
+			if count[ht] == nil {
+				count[ht] = make(map[string]int)
+			}
+			count[ht][h]++
+			total[ht]++
+		}
+	}
+	res := make(map[*utils.HashType]string)
+	for ht, hashes := range count {
+		for h, n := range hashes {
+			if n*2 > total[ht] {
+				res[ht] = h
+			}
+		}
+	}
+	return res
+}
+
+// hashConflict returns a type of hash the replicas have different hashes of, nil if there is none
+func hashConflict(replicas []replica) *utils.HashType {
+	seen := make(map[*utils.HashType]string)
+	for _, rep := range replicas {
+		for ht, h := range rep.hash.All() {
+			if h == "" {
+				continue
+			}
+			if prev, ok := seen[ht]; ok && prev != h {
+				return ht
+			}
+			seen[ht] = h
+		}
+	}
+	return nil
+}
+
+// copies returns the copies of a mirrored file which are neither stale nor corrupted
+func (o *Object) copies() []replica {
+	return utils.SliceFilter(o.replicas, func(rep replica) bool {
+		if rep.size != o.Size {
+			return false
+		}
+		for ht, h := range o.HashInfo.All() {
+			if v := rep.hash.GetHash(ht); v != "" && v != h {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// openCopy reads the range from the first copy which can be read, the fastest healthy one if any
+func (d *Union) openCopy(ctx context.Context, obj *Object, httpRange http_range.Range) (io.ReadCloser, error) {
+	err := errors.New("no copy of the file")
+	for _, rep := range d.sortReplicas(obj.copies()) {
+		var rc io.ReadCloser
+		if rc, err = d.openReplica(ctx, rep, httpRange); err == nil {
+			return rc, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
+
+// removeStale removes the replicas which haven't been overwritten by the uploaded targets
+func (d *Union) removeStale(ctx context.Context, replicas []replica, dir string, targets []target) error {
+	type key struct {
+		remote int
+		path   string
+	}
+	written := make(map[key]bool, len(targets))
+	for _, t := range targets {
+		written[key{remote: t.remote, path: stdpath.Join(dir, t.name)}] = true
+	}
+	var stale []replica
+	for _, rep := range replicas {
+		if !written[key{remote: rep.remote, path: rep.path}] {
+			stale = append(stale, rep)
+		}
+	}
+	return d.each(stale, func(rep replica, storage driver.Driver, actualPath string) error {
+		return op.Remove(ctx, storage, actualPath)
+	})
+}
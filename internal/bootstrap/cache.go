@@ -0,0 +1,39 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitCache() {
+	c := conf.Conf.Cache
+	var (
+		s   cache.Store
+		err error
+	)
+	switch strings.ToLower(c.Type) {
+	case "", "memory":
+		return
+	case "bolt":
+		s, err = cache.NewBoltStore(c.BoltFile)
+	case "redis":
+		s, err = cache.NewRedisStore(cache.RedisOptions{
+			Address:   c.Address,
+			Password:  c.Password,
+			DB:        c.DB,
+			KeyPrefix: c.Prefix,
+		})
+	default:
+		log.Errorf("unknown cache type: %s, fallback to memory", c.Type)
+		return
+	}
+	if err != nil {
+		log.Errorf("failed init %s cache, fallback to memory: %+v", c.Type, err)
+		return
+	}
+	cache.SetStore(s)
+	log.Infof("use %s cache", c.Type)
+}
@@ -173,13 +173,22 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.DeviceSessionTTL, Value: "86400", Type: conf.TypeNumber, Group: model.GLOBAL},
 		{Key: conf.MetaNotFoundCacheExpire, Value: "60", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Negative cache expiration for missing meta records, in seconds. Set 0 to disable."},
 		{Key: conf.MaxExtractSize, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Max total size of files decompressed by a single task, in GB. Set 0 for unlimited."},
+		{Key: conf.TrashEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Move removed objects to a hidden trash folder of their storage instead of deleting them."},
+		{Key: conf.TrashRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Days to keep removed objects in the trash before purging them. Set 0 to keep them forever."},
+		{Key: conf.CopyVerifyHash, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Verify the hash of files copied between storages, reading the copy back if the destination doesn't report a hash. A mismatch fails the copy, so that it's retried."},
+		{Key: conf.WebhookDeliveryRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Days to keep the finished webhook deliveries in the log. Set 0 to keep them forever."},
+		{Key: conf.StorageHealthCheckInterval, Value: "5", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Minutes between the health checks of the storages, the storages failing 3 checks in a row are reloaded automatically. Set 0 to disable the checks."},
+		{Key: conf.ShareAccessLogRetentionDays, Value: "180", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Days to keep the access logs of the shares, the daily statistics are kept forever. Set 0 to keep the logs forever."},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
-		{Key: conf.SearchIndex, Value: "none", Type: conf.TypeSelect, Options: "database,database_non_full_text,bleve,meilisearch,no_index,none", Group: model.INDEX},
+		{Key: conf.SearchIndex, Value: "none", Type: conf.TypeSelect, Options: "database,database_non_full_text,bleve,sqlite_fts,meilisearch,no_index,none", Group: model.INDEX},
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
 		{Key: conf.MaxIndexDepth, Value: "20", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max depth of index`},
+		{Key: conf.SearchContentExts, Value: "", Type: conf.TypeString, Group: model.INDEX, Flag: model.PRIVATE, Help: `extensions of the files whose content is indexed, separated by commas, e.g. txt,md,go,pdf,docx,xlsx,pptx. Only bleve and meilisearch search the content, leave empty to index the names only`},
+		{Key: conf.SearchContentMaxSize, Value: "10", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max size in MB of the files whose content is indexed`},
+		{Key: conf.IndexRefreshInterval, Value: "0", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `minutes between the incremental updates of the index, which only sync the folders changed. Set 0 to disable the updates`},
 		{Key: conf.IndexProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
 
 		// SSO settings
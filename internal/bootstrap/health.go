@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var healthCron *cron.Cron
+
+// InitStorageHealth checks the health of the storages at the interval of the setting,
+// which is read every minute so that changing it doesn't need a restart
+func InitStorageHealth() {
+	minutes := 0
+	healthCron = cron.NewCron(time.Minute)
+	healthCron.Do(func() {
+		interval := setting.GetInt(conf.StorageHealthCheckInterval, 5)
+		minutes++
+		if interval <= 0 || minutes < interval || !conf.StoragesLoaded {
+			return
+		}
+		minutes = 0
+		op.CheckStoragesHealth()
+	})
+}
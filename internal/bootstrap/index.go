@@ -1,7 +1,15 @@
 package bootstrap
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -16,3 +24,25 @@ func InitIndex() {
 		search.WriteProgress(progress)
 	}
 }
+
+var indexCron *cron.Cron
+
+// InitIndexRefresh updates the index incrementally at the interval of the setting,
+// which is read every minute so that changing it doesn't need a restart. Nothing is done
+// while the searcher can't be updated, the search mode may also be changed without a restart
+func InitIndexRefresh() {
+	minutes := 0
+	indexCron = cron.NewCron(time.Minute)
+	indexCron.Do(func() {
+		interval := setting.GetInt(conf.IndexRefreshInterval, 0)
+		minutes++
+		if interval <= 0 || minutes < interval || !conf.StoragesLoaded || search.Running() || !search.CanRefresh() {
+			return
+		}
+		minutes = 0
+		err := search.Refresh(context.Background())
+		if err != nil && !errors.Is(err, errs.SearchNotAvailable) && !errors.Is(err, context.Canceled) {
+			log.Errorf("refresh index error: %+v", err)
+		}
+	})
+}
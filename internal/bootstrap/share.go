@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/share"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var shareAccessCron *cron.Cron
+
+// InitShareAccessLogs rolls up the access logs of the shares into daily statistics hourly
+func InitShareAccessLogs() {
+	shareAccessCron = cron.NewCron(time.Hour)
+	shareAccessCron.Do(share.RollupAccessLogs)
+}
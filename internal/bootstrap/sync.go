@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var syncCron *cron.Cron
+
+// InitSyncJobs checks the schedules of the sync jobs every minute
+func InitSyncJobs() {
+	syncCron = cron.NewCron(time.Minute)
+	syncCron.Do(fs.RunScheduledSyncJobs)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/xhofe/tache"
 )
 
@@ -49,6 +50,10 @@ func InitTaskManager() {
 		),
 		tache.WithMaxRetry(conf.Conf.Tasks.S3Transition.MaxRetry),
 	)
+	fs.SyncTaskManager = tache.NewManager[*fs.SyncTask](tache.WithWorks(conf.Conf.Tasks.Sync.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("sync", conf.Conf.Tasks.Sync.TaskPersistant), db.UpdateTaskDataFunc("sync", conf.Conf.Tasks.Sync.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))
+	fs.ReencryptTaskManager = tache.NewManager[*fs.ReencryptTask](tache.WithWorks(conf.Conf.Tasks.Reencrypt.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("reencrypt", conf.Conf.Tasks.Reencrypt.TaskPersistant), db.UpdateTaskDataFunc("reencrypt", conf.Conf.Tasks.Reencrypt.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Reencrypt.MaxRetry))
+	fs.GCTaskManager = tache.NewManager[*fs.GCTask](tache.WithWorks(conf.Conf.Tasks.GC.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("gc", conf.Conf.Tasks.GC.TaskPersistant), db.UpdateTaskDataFunc("gc", conf.Conf.Tasks.GC.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.GC.MaxRetry))
+	fs.ScrubTaskManager = tache.NewManager[*fs.ScrubTask](tache.WithWorks(conf.Conf.Tasks.Scrub.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("scrub", conf.Conf.Tasks.Scrub.TaskPersistant), db.UpdateTaskDataFunc("scrub", conf.Conf.Tasks.Scrub.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Scrub.MaxRetry))
 	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveDownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)))
@@ -57,4 +62,16 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	// the types are named as the task routes
+	task.RegisterManager("upload", fs.UploadTaskManager)
+	task.RegisterManager("copy", fs.CopyTaskManager)
+	task.RegisterManager("offline_download", tool.DownloadTaskManager)
+	task.RegisterManager("offline_download_transfer", tool.TransferTaskManager)
+	task.RegisterManager("s3_transition", fs.S3TransitionTaskManager)
+	task.RegisterManager("sync", fs.SyncTaskManager)
+	task.RegisterManager("reencrypt", fs.ReencryptTaskManager)
+	task.RegisterManager("gc", fs.GCTaskManager)
+	task.RegisterManager("scrub", fs.ScrubTaskManager)
+	task.RegisterManager("decompress", fs.ArchiveDownloadTaskManager)
+	task.RegisterManager("decompress_upload", fs.ArchiveContentUploadTaskManager)
 }
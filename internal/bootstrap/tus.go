@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var tusCron *cron.Cron
+
+// InitTusUploads removes the abandoned resumable uploads periodically
+func InitTusUploads() {
+	tusCron = cron.NewCron(time.Hour)
+	tusCron.Do(fs.PurgeExpiredTusUploads)
+}
@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var versionCron *cron.Cron
+
+// InitVersions removes the file versions beyond the retention of their storage daily
+func InitVersions() {
+	versionCron = cron.NewCron(24 * time.Hour)
+	versionCron.Do(fs.PruneVersions)
+}
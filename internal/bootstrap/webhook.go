@@ -0,0 +1,23 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/webhook"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var (
+	webhookCron      *cron.Cron
+	webhookPurgeCron *cron.Cron
+)
+
+// InitWebhooks subscribes the webhooks to the events, retries the failed deliveries
+// and purges the old ones periodically
+func InitWebhooks() {
+	webhook.Init()
+	webhookCron = cron.NewCron(15 * time.Second)
+	webhookCron.Do(webhook.RetryDue)
+	webhookPurgeCron = cron.NewCron(24 * time.Hour)
+	webhookPurgeCron.Do(webhook.PurgeDeliveries)
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("cache")
+
+// BoltStore keeps the cache in a single bbolt file, every value is
+// prefixed by its expiration in unix nanoseconds (0 means never).
+type BoltStore struct {
+	db   *bolt.DB
+	done chan struct{}
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	s := &BoltStore{db: db, done: make(chan struct{})}
+	go s.clearExpired(time.Minute * 10)
+	return s, nil
+}
+
+func (s *BoltStore) Get(key string) ([]byte, time.Duration, bool) {
+	var (
+		value    []byte
+		expireAt int64
+	)
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get([]byte(key))
+		if len(v) < 8 {
+			return nil
+		}
+		expireAt = int64(binary.BigEndian.Uint64(v[:8]))
+		value = bytes.Clone(v[8:])
+		return nil
+	})
+	if value == nil {
+		return nil, 0, false
+	}
+	if expireAt == 0 {
+		return value, 0, true
+	}
+	ttl := time.Until(time.Unix(0, expireAt))
+	if ttl <= 0 {
+		return nil, 0, false
+	}
+	return value, ttl, true
+}
+
+func (s *BoltStore) Set(key string, value []byte, ttl time.Duration) error {
+	v := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(v[:8], uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(v[8:], value)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), v)
+	})
+}
+
+func (s *BoltStore) Del(keys ...string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		for _, k := range keys {
+			if err := b.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) DelPrefix(prefix string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		c := b.Cursor()
+		p := []byte(prefix)
+		var keys [][]byte
+		for k, _ := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = c.Next() {
+			keys = append(keys, bytes.Clone(k))
+		}
+		return deleteKeys(b, keys)
+	})
+}
+
+func (s *BoltStore) clearExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		now := uint64(time.Now().UnixNano())
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(boltBucket)
+			c := b.Cursor()
+			var keys [][]byte
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				if len(v) < 8 {
+					continue
+				}
+				if expireAt := binary.BigEndian.Uint64(v[:8]); expireAt != 0 && expireAt <= now {
+					keys = append(keys, bytes.Clone(k))
+				}
+			}
+			return deleteKeys(b, keys)
+		})
+		if err != nil {
+			log.Warnf("failed to clear expired cache: %+v", err)
+		}
+	}
+}
+
+// deleteKeys is used instead of deleting with the cursor, which skips
+// the following key when deleting during iteration.
+func deleteKeys(b *bolt.Bucket, keys [][]byte) error {
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) Close() error {
+	close(s.done)
+	return s.db.Close()
+}
+
+var _ Store = (*BoltStore)(nil)
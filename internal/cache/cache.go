@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotPersistable can be returned by Codec.Encode if the value
+// can only live in memory, e.g. a link holding an opened file.
+var ErrNotPersistable = errors.New("value is not persistable")
+
+type Codec[V any] interface {
+	Encode(v V) ([]byte, error)
+	Decode(data []byte) (V, error)
+}
+
+// Cache is a memory cache backed by the Store set with SetStore.
+// Values are always kept in memory, and written through to the store
+// so that another instance or the next start can load them back.
+// With a SharedStore, the memory copy is only served while it's the same as the stored value.
+type Cache[V any] struct {
+	name  string
+	mem   cache.ICache[V]
+	state cache.ICache[memState]
+	codec Codec[V]
+}
+
+// memState is how the memory copy of a value is stored
+type memState struct {
+	// the encoded value, as it was written to or read from the store
+	data []byte
+	// the value can't be stored and only lives in memory
+	local bool
+}
+
+func NewCache[V any](name string, codec Codec[V], shards int) *Cache[V] {
+	return &Cache[V]{
+		name:  name,
+		mem:   cache.NewMemCache(cache.WithShards[V](shards)),
+		state: cache.NewMemCache(cache.WithShards[memState](shards)),
+		codec: codec,
+	}
+}
+
+// WithEx is re-exported so that callers don't need to import both cache packages.
+func WithEx[V any](d time.Duration) cache.SetIOption[V] {
+	return cache.WithEx[V](d)
+}
+
+func (c *Cache[V]) storeKey(k string) string {
+	return c.name + ":" + k
+}
+
+func (c *Cache[V]) setState(k string, st memState, ttl time.Duration) {
+	if ttl > 0 {
+		c.state.Set(k, st, cache.WithEx[memState](ttl))
+	} else {
+		c.state.Set(k, st)
+	}
+}
+
+func (c *Cache[V]) Get(k string) (V, bool) {
+	v, inMem := c.mem.Get(k)
+	s := getStore()
+	st, _ := c.state.Get(k)
+	// another instance may have changed the value in a shared store
+	if inMem && (s == nil || st.local || !isShared(s)) {
+		return v, true
+	}
+	var zero V
+	if s == nil {
+		return zero, false
+	}
+	data, ttl, ok := s.Get(c.storeKey(k))
+	if !ok {
+		if inMem {
+			c.mem.Del(k)
+			c.state.Del(k)
+		}
+		return zero, false
+	}
+	if inMem && st.data != nil && bytes.Equal(data, st.data) {
+		return v, true
+	}
+	v, err := c.codec.Decode(data)
+	if err != nil {
+		log.Warnf("failed to decode cache %s: %+v", c.storeKey(k), err)
+		_ = s.Del(c.storeKey(k))
+		return zero, false
+	}
+	if ttl > 0 {
+		c.mem.Set(k, v, cache.WithEx[V](ttl))
+	} else {
+		c.mem.Set(k, v)
+	}
+	c.setState(k, memState{data: data}, ttl)
+	return v, true
+}
+
+func (c *Cache[V]) Set(k string, v V, opts ...cache.SetIOption[V]) bool {
+	ok := c.mem.Set(k, v, opts...)
+	s := getStore()
+	if s == nil {
+		c.state.Del(k)
+		return ok
+	}
+	it := &item{}
+	for _, opt := range opts {
+		opt(c.mem, k, it)
+	}
+	var ttl time.Duration
+	if !it.expireAt.IsZero() {
+		ttl = time.Until(it.expireAt)
+		if ttl <= 0 {
+			c.state.Del(k)
+			_ = s.Del(c.storeKey(k))
+			return ok
+		}
+	}
+	data, err := c.codec.Encode(v)
+	if err != nil {
+		if !errors.Is(err, ErrNotPersistable) {
+			log.Warnf("failed to encode cache %s: %+v", c.storeKey(k), err)
+		}
+		c.setState(k, memState{local: true}, ttl)
+		// don't leave a stale value in the store
+		_ = s.Del(c.storeKey(k))
+		return ok
+	}
+	c.setState(k, memState{data: data}, ttl)
+	if err = s.Set(c.storeKey(k), data, ttl); err != nil {
+		log.Warnf("failed to set cache %s: %+v", c.storeKey(k), err)
+	}
+	return ok
+}
+
+func (c *Cache[V]) Del(keys ...string) int {
+	n := c.mem.Del(keys...)
+	c.state.Del(keys...)
+	if s := getStore(); s != nil && len(keys) > 0 {
+		storeKeys := make([]string, len(keys))
+		for i, k := range keys {
+			storeKeys[i] = c.storeKey(k)
+		}
+		if err := s.Del(storeKeys...); err != nil {
+			log.Warnf("failed to del cache of %s: %+v", c.name, err)
+		}
+	}
+	return n
+}
+
+func (c *Cache[V]) Clear() {
+	c.mem.Clear()
+	c.state.Clear()
+	if s := getStore(); s != nil {
+		if err := s.DelPrefix(c.name + ":"); err != nil {
+			log.Warnf("failed to clear cache of %s: %+v", c.name, err)
+		}
+	}
+}
+
+// item only collects the expiration of the set options
+type item struct {
+	expireAt time.Time
+}
+
+func (i *item) Expired() bool {
+	return i.CanExpire() && !time.Now().Before(i.expireAt)
+}
+
+func (i *item) CanExpire() bool {
+	return !i.expireAt.IsZero()
+}
+
+func (i *item) SetExpireAt(t time.Time) {
+	i.expireAt = t
+}
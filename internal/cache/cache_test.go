@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type intCodec struct{}
+
+func (intCodec) Encode(v int) ([]byte, error) {
+	if v < 0 {
+		return nil, ErrNotPersistable
+	}
+	return []byte(strconv.Itoa(v)), nil
+}
+
+func (intCodec) Decode(data []byte) (int, error) {
+	return strconv.Atoi(string(data))
+}
+
+func TestCacheWithBoltStore(t *testing.T) {
+	s, err := NewBoltStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetStore(s)
+	defer Close()
+
+	c := NewCache[int]("test", intCodec{}, 1)
+	c.Set("a", 1, WithEx[int](time.Minute))
+	c.Set("b", 2)
+	c.Set("c", -1)
+
+	// a new cache with the same name simulates a restart
+	c2 := NewCache[int]("test", intCodec{}, 1)
+	if v, ok := c2.Get("a"); !ok || v != 1 {
+		t.Errorf("expect a=1, got %d, %v", v, ok)
+	}
+	if v, ok := c2.Get("b"); !ok || v != 2 {
+		t.Errorf("expect b=2, got %d, %v", v, ok)
+	}
+	if _, ok := c2.Get("c"); ok {
+		t.Errorf("expect c not persisted")
+	}
+
+	c.Del("a")
+	if _, ok := NewCache[int]("test", intCodec{}, 1).Get("a"); ok {
+		t.Errorf("expect a deleted from store")
+	}
+	c.Clear()
+	if _, ok := NewCache[int]("test", intCodec{}, 1).Get("b"); ok {
+		t.Errorf("expect b cleared from store")
+	}
+}
+
+func TestBoltStoreExpire(t *testing.T) {
+	s, err := NewBoltStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err = s.Set("k", []byte("v"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 5)
+	if _, _, ok := s.Get("k"); ok {
+		t.Errorf("expect k expired")
+	}
+	if err = s.Set("k", []byte("v"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	v, ttl, ok := s.Get("k")
+	if !ok || string(v) != "v" || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("unexpected get result: %s, %s, %v", v, ttl, ok)
+	}
+}
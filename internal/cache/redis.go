@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RedisStore talks the RESP protocol directly, so it works with redis and
+// any compatible server (valkey, dragonfly, keydb...) without extra dependencies.
+type RedisStore struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+	timeout  time.Duration
+	pool     chan *redisConn
+}
+
+type RedisOptions struct {
+	Address  string
+	Password string
+	DB       int
+	// KeyPrefix allows several deployments to share one redis database
+	KeyPrefix string
+	PoolSize  int
+}
+
+func NewRedisStore(opts RedisOptions) (*RedisStore, error) {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 8
+	}
+	s := &RedisStore{
+		addr:     opts.Address,
+		password: opts.Password,
+		db:       opts.DB,
+		prefix:   opts.KeyPrefix,
+		timeout:  time.Second * 5,
+		pool:     make(chan *redisConn, opts.PoolSize),
+	}
+	// check the connection
+	if _, err := s.do("PING"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func (s *RedisStore) dial() (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if s.password != "" {
+		if _, err = c.do(s.timeout, "AUTH", s.password); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if s.db != 0 {
+		if _, err = c.do(s.timeout, "SELECT", strconv.Itoa(s.db)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *RedisStore) do(args ...string) (any, error) {
+	var (
+		c   *redisConn
+		err error
+	)
+	select {
+	case c = <-s.pool:
+	default:
+		c, err = s.dial()
+		if err != nil {
+			return nil, err
+		}
+	}
+	res, err := c.do(s.timeout, args...)
+	var rErr redisError
+	if err != nil && !errors.As(err, &rErr) {
+		// broken connection, drop it
+		_ = c.conn.Close()
+		return nil, err
+	}
+	select {
+	case s.pool <- c:
+	default:
+		_ = c.conn.Close()
+	}
+	return res, err
+}
+
+func (c *redisConn) do(timeout time.Duration, args ...string) (any, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(c.w, "*%d\r\n", len(args)); err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read parses one RESP2 reply, bulk strings are returned as []byte,
+// nil bulk strings as nil, integers as int64 and arrays as []any.
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.Errorf("invalid redis reply: %q", line)
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if arr[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, errors.Errorf("unknown redis reply: %q", line)
+}
+
+// Shared reports that the database may be used by several instances
+func (s *RedisStore) Shared() bool {
+	return true
+}
+
+func (s *RedisStore) Get(key string) ([]byte, time.Duration, bool) {
+	key = s.prefix + key
+	res, err := s.do("GET", key)
+	value, ok := res.([]byte)
+	if err != nil || !ok {
+		return nil, 0, false
+	}
+	res, err = s.do("PTTL", key)
+	if err != nil {
+		return nil, 0, false
+	}
+	ms, _ := res.(int64)
+	switch {
+	case ms == -1:
+		return value, 0, true
+	case ms <= 0:
+		// expired between GET and PTTL
+		return nil, 0, false
+	}
+	return value, time.Duration(ms) * time.Millisecond, true
+}
+
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	key = s.prefix + key
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms <= 0 {
+			ms = 1
+		}
+		_, err := s.do("SET", key, string(value), "PX", strconv.FormatInt(ms, 10))
+		return err
+	}
+	_, err := s.do("SET", key, string(value))
+	return err
+}
+
+func (s *RedisStore) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, k := range keys {
+		args = append(args, s.prefix+k)
+	}
+	_, err := s.do(args...)
+	return err
+}
+
+func (s *RedisStore) DelPrefix(prefix string) error {
+	cursor := "0"
+	for {
+		res, err := s.do("SCAN", cursor, "MATCH", escapeGlob(s.prefix+prefix)+"*", "COUNT", "500")
+		if err != nil {
+			return err
+		}
+		arr, ok := res.([]any)
+		if !ok || len(arr) != 2 {
+			return errors.Errorf("invalid SCAN reply: %v", res)
+		}
+		next, _ := arr[0].([]byte)
+		keys, _ := arr[1].([]any)
+		if len(keys) > 0 {
+			args := make([]string, 0, len(keys)+1)
+			args = append(args, "DEL")
+			for _, k := range keys {
+				if b, ok := k.([]byte); ok {
+					args = append(args, string(b))
+				}
+			}
+			if _, err = s.do(args...); err != nil {
+				return err
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+func escapeGlob(s string) string {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
+
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case c := <-s.pool:
+			_ = c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+var _ Store = (*RedisStore)(nil)
@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the commands used by RedisStore from a map
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	dbs      []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, password: password, values: map[string]string{}, expires: map[string]time.Time{}}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case cmd == "AUTH":
+			authed = args[1] == f.password
+			if !authed {
+				fmt.Fprint(w, "-WRONGPASS invalid password\r\n")
+				break
+			}
+			fmt.Fprint(w, "+OK\r\n")
+		case !authed:
+			fmt.Fprint(w, "-NOAUTH Authentication required.\r\n")
+		default:
+			f.exec(w, cmd, args[1:])
+		}
+		if err = w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeBulk(w io.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+// globMatch matches like redis, * also matches '/' unlike path.Match
+func globMatch(pattern, s string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String()).MatchString(s)
+}
+
+func (f *fakeRedis) exec(w io.Writer, cmd string, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, exp := range f.expires {
+		if time.Now().After(exp) {
+			delete(f.values, k)
+			delete(f.expires, k)
+		}
+	}
+	switch cmd {
+	case "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "SELECT":
+		f.dbs = append(f.dbs, args[0])
+		fmt.Fprint(w, "+OK\r\n")
+	case "GET":
+		if v, ok := f.values[args[0]]; ok {
+			writeBulk(w, v)
+		} else {
+			fmt.Fprint(w, "$-1\r\n")
+		}
+	case "PTTL":
+		_, ok := f.values[args[0]]
+		exp, expires := f.expires[args[0]]
+		switch {
+		case !ok:
+			fmt.Fprint(w, ":-2\r\n")
+		case !expires:
+			fmt.Fprint(w, ":-1\r\n")
+		default:
+			fmt.Fprintf(w, ":%d\r\n", time.Until(exp).Milliseconds())
+		}
+	case "SET":
+		f.values[args[0]] = args[1]
+		delete(f.expires, args[0])
+		if len(args) == 4 && args[2] == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			f.expires[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		fmt.Fprint(w, "+OK\r\n")
+	case "DEL":
+		n := 0
+		for _, k := range args {
+			if _, ok := f.values[k]; ok {
+				delete(f.values, k)
+				delete(f.expires, k)
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case "SCAN":
+		// returns one matching key per call so that the cursor is followed
+		var keys []string
+		for k := range f.values {
+			if globMatch(args[2], k) {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		cursor := "0"
+		if len(keys) > 1 {
+			keys, cursor = keys[:1], "1"
+		}
+		fmt.Fprint(w, "*2\r\n")
+		writeBulk(w, cursor)
+		fmt.Fprintf(w, "*%d\r\n", len(keys))
+		for _, k := range keys {
+			writeBulk(w, k)
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", cmd)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	f := newFakeRedis(t, "secret")
+	if _, err := NewRedisStore(RedisOptions{Address: f.ln.Addr().String(), Password: "wrong"}); err == nil {
+		t.Fatal("expect an error with a wrong password")
+	}
+	s, err := NewRedisStore(RedisOptions{Address: f.ln.Addr().String(), Password: "secret", DB: 2, KeyPrefix: "alist:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	f.mu.Lock()
+	if !slices.Contains(f.dbs, "2") {
+		t.Errorf("expect db 2 selected, got %v", f.dbs)
+	}
+	f.mu.Unlock()
+
+	value := "line1\r\nline2 with spaces and *[?]"
+	if err = s.Set("a", []byte(value), 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, ttl, ok := s.Get("a"); !ok || string(v) != value || ttl != 0 {
+		t.Errorf("unexpected get result: %q, %s, %v", v, ttl, ok)
+	}
+	f.mu.Lock()
+	if _, ok := f.values["alist:a"]; !ok {
+		t.Errorf("expect the key prefixed, got %v", f.values)
+	}
+	f.mu.Unlock()
+	if err = s.Set("b", []byte(""), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if v, ttl, ok := s.Get("b"); !ok || len(v) != 0 || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("unexpected get result: %q, %s, %v", v, ttl, ok)
+	}
+	if err = s.Set("c", []byte("v"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 5)
+	if _, _, ok := s.Get("c"); ok {
+		t.Errorf("expect c expired")
+	}
+	if _, _, ok := s.Get("missing"); ok {
+		t.Errorf("expect missing not found")
+	}
+
+	if err = s.Del("a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := s.Get("a"); ok {
+		t.Errorf("expect a deleted")
+	}
+
+	for _, k := range []string{"dir/x", "dir/y", "dir/z", "dir*/x", "other"} {
+		if err = s.Set(k, []byte("v"), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.DelPrefix("dir*"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := s.Get("dir*/x"); ok {
+		t.Errorf("expect dir*/x deleted")
+	}
+	if _, _, ok := s.Get("dir/x"); !ok {
+		t.Errorf("expect the glob characters of the prefix escaped")
+	}
+	if err = s.DelPrefix("dir/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"dir/x", "dir/y", "dir/z"} {
+		if _, _, ok := s.Get(k); ok {
+			t.Errorf("expect %s deleted", k)
+		}
+	}
+	if _, _, ok := s.Get("other"); !ok {
+		t.Errorf("expect other kept")
+	}
+
+	// an error reply keeps the connection usable
+	if _, err = s.do("UNKNOWN"); err == nil {
+		t.Errorf("expect an error reply")
+	}
+	if _, _, ok := s.Get("other"); !ok {
+		t.Errorf("expect other after an error reply")
+	}
+}
+
+func TestCacheWithRedisStore(t *testing.T) {
+	f := newFakeRedis(t, "")
+	s, err := NewRedisStore(RedisOptions{Address: f.ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetStore(s)
+	defer Close()
+
+	// two caches of the same name stand for two instances sharing the database
+	c1 := NewCache[int]("shared", intCodec{}, 1)
+	c2 := NewCache[int]("shared", intCodec{}, 1)
+	c1.Set("a", 1)
+	if v, ok := c2.Get("a"); !ok || v != 1 {
+		t.Errorf("expect a=1, got %d, %v", v, ok)
+	}
+	c1.Set("a", 2)
+	if v, ok := c2.Get("a"); !ok || v != 2 {
+		t.Errorf("expect the value changed by the other instance, got %d, %v", v, ok)
+	}
+	c1.Del("a")
+	if _, ok := c2.Get("a"); ok {
+		t.Errorf("expect a deleted by the other instance")
+	}
+	c2.Set("b", -1)
+	if v, ok := c2.Get("b"); !ok || v != -1 {
+		t.Errorf("expect the value which can't be stored served from memory, got %d, %v", v, ok)
+	}
+}
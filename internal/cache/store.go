@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Store is a byte oriented key-value backend shared by the typed caches,
+// so that cached values survive restarts or can be shared by several instances.
+type Store interface {
+	// Get returns the value and its remaining time to live, a ttl of 0 means no expiration
+	Get(key string) ([]byte, time.Duration, bool)
+	// Set stores the value, a ttl of 0 means no expiration
+	Set(key string, value []byte, ttl time.Duration) error
+	Del(keys ...string) error
+	// DelPrefix removes all keys starting with prefix
+	DelPrefix(prefix string) error
+	Close() error
+}
+
+// SharedStore is implemented by the stores several instances may use at the same time,
+// the typed caches then check their memory copies against the store on every read
+// as another instance may have changed or removed the values.
+type SharedStore interface {
+	Shared() bool
+}
+
+func isShared(s Store) bool {
+	ss, ok := s.(SharedStore)
+	return ok && ss.Shared()
+}
+
+type storeHolder struct {
+	Store
+}
+
+var store atomic.Pointer[storeHolder]
+
+// SetStore changes the backend of all typed caches, nil means memory only.
+func SetStore(s Store) {
+	if s == nil {
+		store.Store(nil)
+		return
+	}
+	store.Store(&storeHolder{Store: s})
+}
+
+func getStore() Store {
+	h := store.Load()
+	if h == nil {
+		return nil
+	}
+	return h.Store
+}
+
+// Close closes the current backend and falls back to memory only.
+func Close() error {
+	h := store.Swap(nil)
+	if h == nil {
+		return nil
+	}
+	return h.Close()
+}
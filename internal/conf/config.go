@@ -26,6 +26,16 @@ type Meilisearch struct {
 	IndexPrefix string `json:"index_prefix" env:"INDEX_PREFIX"`
 }
 
+type Cache struct {
+	// Type is one of memory, bolt or redis
+	Type     string `json:"type" env:"TYPE"`
+	BoltFile string `json:"bolt_file" env:"BOLT_FILE"`
+	Address  string `json:"address" env:"ADDRESS"`
+	Password string `json:"password" env:"PASSWORD"`
+	DB       int    `json:"db" env:"DB"`
+	Prefix   string `json:"prefix" env:"PREFIX"`
+}
+
 type Scheme struct {
 	Address      string `json:"address" env:"ADDR"`
 	HttpPort     int    `json:"http_port" env:"HTTP_PORT"`
@@ -61,6 +71,10 @@ type TasksConfig struct {
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
 	S3Transition       TaskConfig `json:"s3_transition" envPrefix:"S3_TRANSITION_"`
+	Sync               TaskConfig `json:"sync" envPrefix:"SYNC_"`
+	Reencrypt          TaskConfig `json:"reencrypt" envPrefix:"REENCRYPT_"`
+	GC                 TaskConfig `json:"gc" envPrefix:"GC_"`
+	Scrub              TaskConfig `json:"scrub" envPrefix:"SCRUB_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -107,9 +121,11 @@ type Config struct {
 	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
 	Database              Database    `json:"database" envPrefix:"DB_"`
 	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
+	Cache                 Cache       `json:"cache" envPrefix:"CACHE_"`
 	Scheme                Scheme      `json:"scheme"`
 	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
 	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
+	SQLiteFTSFile         string      `json:"sqlite_fts_file" env:"SQLITE_FTS_FILE"`
 	DistDir               string      `json:"dist_dir"`
 	Log                   LogConfig   `json:"log"`
 	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
@@ -128,8 +144,10 @@ type Config struct {
 func DefaultConfig() *Config {
 	tempDir := filepath.Join(flags.DataDir, "temp")
 	indexDir := filepath.Join(flags.DataDir, "bleve")
+	ftsPath := filepath.Join(flags.DataDir, "fts.db")
 	logPath := filepath.Join(flags.DataDir, "log/log.log")
 	dbPath := filepath.Join(flags.DataDir, "data.db")
+	cachePath := filepath.Join(flags.DataDir, "cache.db")
 	return &Config{
 		Scheme: Scheme{
 			Address:    "0.0.0.0",
@@ -152,7 +170,14 @@ func DefaultConfig() *Config {
 		Meilisearch: Meilisearch{
 			Host: "http://localhost:7700",
 		},
-		BleveDir: indexDir,
+		Cache: Cache{
+			Type:     "memory",
+			BoltFile: cachePath,
+			Address:  "localhost:6379",
+			Prefix:   "alist:",
+		},
+		BleveDir:      indexDir,
+		SQLiteFTSFile: ftsPath,
 		Log: LogConfig{
 			Enable:     true,
 			Name:       logPath,
@@ -196,6 +221,26 @@ func DefaultConfig() *Config {
 				MaxRetry: 2,
 				// TaskPersistant: true,
 			},
+			Sync: TaskConfig{
+				Workers:  2,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
+			Reencrypt: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
+			GC: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
+			Scrub: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+				// TaskPersistant: true,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
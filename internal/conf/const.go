@@ -39,28 +39,37 @@ const (
 	ReadMeAutoRender         = "readme_autorender"
 	FilterReadMeScripts      = "filter_readme_scripts"
 	// global
-	HideFiles               = "hide_files"
-	CustomizeHead           = "customize_head"
-	CustomizeBody           = "customize_body"
-	LinkExpiration          = "link_expiration"
-	SignAll                 = "sign_all"
-	PrivacyRegs             = "privacy_regs"
-	OcrApi                  = "ocr_api"
-	FilenameCharMapping     = "filename_char_mapping"
-	ForwardDirectLinkParams = "forward_direct_link_params"
-	IgnoreDirectLinkParams  = "ignore_direct_link_params"
-	WebauthnLoginEnabled    = "webauthn_login_enabled"
-	MaxDevices              = "max_devices"
-	DeviceEvictPolicy       = "device_evict_policy"
-	DeviceSessionTTL        = "device_session_ttl"
-	MetaNotFoundCacheExpire = "meta_not_found_cache_expire"
-	MaxExtractSize          = "max_extract_size"
+	HideFiles                    = "hide_files"
+	CustomizeHead                = "customize_head"
+	CustomizeBody                = "customize_body"
+	LinkExpiration               = "link_expiration"
+	SignAll                      = "sign_all"
+	PrivacyRegs                  = "privacy_regs"
+	OcrApi                       = "ocr_api"
+	FilenameCharMapping          = "filename_char_mapping"
+	ForwardDirectLinkParams      = "forward_direct_link_params"
+	IgnoreDirectLinkParams       = "ignore_direct_link_params"
+	WebauthnLoginEnabled         = "webauthn_login_enabled"
+	MaxDevices                   = "max_devices"
+	DeviceEvictPolicy            = "device_evict_policy"
+	DeviceSessionTTL             = "device_session_ttl"
+	MetaNotFoundCacheExpire      = "meta_not_found_cache_expire"
+	MaxExtractSize               = "max_extract_size"
+	TrashEnabled                 = "trash_enabled"
+	TrashRetentionDays           = "trash_retention_days"
+	CopyVerifyHash               = "copy_verify_hash"
+	WebhookDeliveryRetentionDays = "webhook_delivery_retention_days"
+	StorageHealthCheckInterval   = "storage_health_check_interval"
+	ShareAccessLogRetentionDays  = "share_access_log_retention_days"
 
 	// index
-	SearchIndex     = "search_index"
-	AutoUpdateIndex = "auto_update_index"
-	IgnorePaths     = "ignore_paths"
-	MaxIndexDepth   = "max_index_depth"
+	SearchIndex          = "search_index"
+	AutoUpdateIndex      = "auto_update_index"
+	IgnorePaths          = "ignore_paths"
+	MaxIndexDepth        = "max_index_depth"
+	SearchContentExts    = "search_content_exts"
+	SearchContentMaxSize = "search_content_max_size"
+	IndexRefreshInterval = "index_refresh_interval"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -176,4 +185,13 @@ const (
 const (
 	NoTaskKey       = "no_task"
 	SkipExistingKey = "skip_existing"
+	NoTrashKey      = "no_trash"
+	// ConflictPolicyKey holds the conflict policy of copy and move, see fs.ConflictOverwrite
+	ConflictPolicyKey = "conflict_policy"
+	// S3SignedPathKey holds the path of a s3 request before the mount prefix is stripped from it
+	S3SignedPathKey = "s3_signed_path"
 )
+
+// S3MultipartDirName is the folder of the temp dir where the multipart uploads of the s3 server
+// are staged, it's kept when the temp dir is cleaned at startup.
+const S3MultipartDirName = "s3_multipart"
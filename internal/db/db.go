@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Role), new(model.Label), new(model.LabelFileBinding), new(model.ObjFile), new(model.Session), new(model.Share))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Role), new(model.Label), new(model.LabelFileBinding), new(model.ObjFile), new(model.Session), new(model.Share), new(model.TrashItem), new(model.SyncJob), new(model.SyncState), new(model.TusUpload), new(model.Webhook), new(model.WebhookDelivery), new(model.ShareAccessLog), new(model.ShareAccessDaily), new(model.S3AccessKey), new(model.WebdavProp), new(model.EncryptionKey), new(model.DedupEntry), new(model.DedupChunk), new(model.DedupFileChunk), new(model.IndexCursor), new(model.IndexedDir))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func whereDedupEntry(tx *gorm.DB, storageId, id uint) *gorm.DB {
+	return tx.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("storage_id"), columnName("id")), storageId, id)
+}
+
+func whereDedupChildren(tx *gorm.DB, storageId, parentId uint) *gorm.DB {
+	return tx.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("storage_id"), columnName("parent_id")), storageId, parentId)
+}
+
+func GetDedupEntries(storageId, parentId uint) ([]model.DedupEntry, error) {
+	var entries []model.DedupEntry
+	if err := whereDedupChildren(db, storageId, parentId).Find(&entries).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dedup entries")
+	}
+	return entries, nil
+}
+
+func GetDedupEntry(storageId, id uint) (*model.DedupEntry, error) {
+	var entry model.DedupEntry
+	if err := whereDedupEntry(db, storageId, id).First(&entry).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dedup entry")
+	}
+	return &entry, nil
+}
+
+func CreateDedupEntry(entry *model.DedupEntry) error {
+	return errors.WithStack(db.Create(entry).Error)
+}
+
+// MoveDedupEntry moves the entry into the folder parentId, which must not be under the entry
+func MoveDedupEntry(storageId, id, parentId uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for p := parentId; p != 0; {
+			if p == id {
+				return errors.New("can't move a folder into itself")
+			}
+			var parent model.DedupEntry
+			if err := whereDedupEntry(tx, storageId, p).First(&parent).Error; err != nil {
+				return err
+			}
+			p = parent.ParentID
+		}
+		return whereDedupEntry(tx.Model(&model.DedupEntry{}), storageId, id).Update("parent_id", parentId).Error
+	}))
+}
+
+func RenameDedupEntry(storageId, id uint, name string) error {
+	return errors.WithStack(whereDedupEntry(db.Model(&model.DedupEntry{}), storageId, id).Update("name", name).Error)
+}
+
+// CopyDedupEntry copies the entry and the entries under it into the folder parentId,
+// the copies refer to the same chunks
+func CopyDedupEntry(storageId, id, parentId uint) (*model.DedupEntry, error) {
+	var res *model.DedupEntry
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var entry model.DedupEntry
+		if err := whereDedupEntry(tx, storageId, id).First(&entry).Error; err != nil {
+			return err
+		}
+		var err error
+		res, err = copyDedupEntry(tx, entry, parentId)
+		return err
+	})
+	return res, errors.WithStack(err)
+}
+
+func copyDedupEntry(tx *gorm.DB, entry model.DedupEntry, parentId uint) (*model.DedupEntry, error) {
+	srcId := entry.ID
+	entry.ID, entry.ParentID = 0, parentId
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	if !entry.IsDir {
+		var chunks []model.DedupFileChunk
+		if err := tx.Where(fmt.Sprintf("%s = ?", columnName("entry_id")), srcId).Find(&chunks).Error; err != nil {
+			return nil, err
+		}
+		for i := range chunks {
+			chunks[i].EntryID = entry.ID
+		}
+		if len(chunks) > 0 {
+			if err := tx.CreateInBatches(chunks, 500).Error; err != nil {
+				return nil, err
+			}
+		}
+		return &entry, nil
+	}
+	var children []model.DedupEntry
+	if err := whereDedupChildren(tx, entry.StorageID, srcId).Find(&children).Error; err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if _, err := copyDedupEntry(tx, child, entry.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &entry, nil
+}
+
+// DeleteDedupEntry deletes the entry and the entries under it, their chunks are left to the garbage collection
+func DeleteDedupEntry(storageId, id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		return deleteDedupEntry(tx, storageId, id)
+	}))
+}
+
+func deleteDedupEntry(tx *gorm.DB, storageId, id uint) error {
+	var children []model.DedupEntry
+	if err := whereDedupChildren(tx, storageId, id).Find(&children).Error; err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := deleteDedupEntry(tx, storageId, child.ID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where(fmt.Sprintf("%s = ?", columnName("entry_id")), id).Delete(&model.DedupFileChunk{}).Error; err != nil {
+		return err
+	}
+	return whereDedupEntry(tx, storageId, id).Delete(&model.DedupEntry{}).Error
+}
+
+// PutDedupFile saves the file made of chunks, replacing the file with the same name in the same folder
+func PutDedupFile(entry *model.DedupEntry, chunks []model.DedupFileChunk) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var existing model.DedupEntry
+		err := whereDedupChildren(tx, entry.StorageID, entry.ParentID).
+			Where(fmt.Sprintf("%s = ?", columnName("name")), entry.Name).First(&existing).Error
+		switch {
+		case err == nil:
+			if existing.IsDir {
+				return errors.New("a folder with the same name exists")
+			}
+			if err := tx.Where(fmt.Sprintf("%s = ?", columnName("entry_id")), existing.ID).Delete(&model.DedupFileChunk{}).Error; err != nil {
+				return err
+			}
+			entry.ID, entry.Created = existing.ID, existing.Created
+			if err := tx.Save(entry).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		for i := range chunks {
+			chunks[i].EntryID, chunks[i].StorageID = entry.ID, entry.StorageID
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(chunks, 500).Error
+	}))
+}
+
+func GetDedupFileChunks(entryId uint) ([]model.DedupFileChunk, error) {
+	var chunks []model.DedupFileChunk
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("entry_id")), entryId).
+		Order(columnName("seq")).Find(&chunks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get chunks of dedup entry %d", entryId)
+	}
+	return chunks, nil
+}
+
+// GetDedupChunksOfEntry returns the stored chunks the file entryId is made of, each one once
+func GetDedupChunksOfEntry(storageId, entryId uint) ([]model.DedupChunk, error) {
+	var chunks []model.DedupChunk
+	hashes := db.Model(&model.DedupFileChunk{}).Select("hash").Where(fmt.Sprintf("%s = ?", columnName("entry_id")), entryId)
+	if err := db.Where(fmt.Sprintf("%s = ? AND %s IN (?)", columnName("storage_id"), columnName("hash")), storageId, hashes).
+		Find(&chunks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get chunks of dedup entry %d", entryId)
+	}
+	return chunks, nil
+}
+
+func HasDedupChunk(storageId uint, hash string) (bool, error) {
+	var count int64
+	if err := db.Model(&model.DedupChunk{}).
+		Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("storage_id"), columnName("hash")), storageId, hash).
+		Count(&count).Error; err != nil {
+		return false, errors.Wrapf(err, "failed get dedup chunk")
+	}
+	return count > 0, nil
+}
+
+// CreateDedupChunk saves a stored chunk, it's fine if it's already saved
+func CreateDedupChunk(chunk *model.DedupChunk) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{DoNothing: true}).Create(chunk).Error)
+}
+
+// GetUnusedDedupChunks returns the chunks no file of the storage is made of anymore
+func GetUnusedDedupChunks(storageId uint) ([]model.DedupChunk, error) {
+	var chunks []model.DedupChunk
+	used := db.Model(&model.DedupFileChunk{}).Select("hash").Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageId)
+	if err := db.Where(fmt.Sprintf("%s = ? AND %s NOT IN (?)", columnName("storage_id"), columnName("hash")), storageId, used).
+		Find(&chunks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get unused dedup chunks")
+	}
+	return chunks, nil
+}
+
+func DeleteDedupChunkById(id uint) error {
+	return errors.WithStack(db.Delete(&model.DedupChunk{}, id).Error)
+}
+
+// GetDedupStats returns the size of the files of the storage and the size of the chunks stored for them
+func GetDedupStats(storageId uint) (filesSize, chunksSize int64, err error) {
+	if err = db.Model(&model.DedupEntry{}).Select("COALESCE(SUM(size), 0)").
+		Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageId).Scan(&filesSize).Error; err != nil {
+		return 0, 0, errors.Wrapf(err, "failed get dedup stats")
+	}
+	if err = db.Model(&model.DedupChunk{}).Select("COALESCE(SUM(size), 0)").
+		Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageId).Scan(&chunksSize).Error; err != nil {
+		return 0, 0, errors.Wrapf(err, "failed get dedup stats")
+	}
+	return filesSize, chunksSize, nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetEncryptionKeys() ([]model.EncryptionKey, error) {
+	var keys []model.EncryptionKey
+	if err := db.Order(columnName("id")).Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get encryption keys")
+	}
+	return keys, nil
+}
+
+func GetEncryptionKeyById(id uint) (*model.EncryptionKey, error) {
+	var k model.EncryptionKey
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get encryption key")
+	}
+	return &k, nil
+}
+
+func GetEncryptionKeyByName(name string) (*model.EncryptionKey, error) {
+	k := model.EncryptionKey{Name: name}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find encryption key with name")
+	}
+	return &k, nil
+}
+
+// CountStoragesByEncryptionKeyId counts the storages referencing the key, disabled ones included
+func CountStoragesByEncryptionKeyId(id uint) (int64, error) {
+	var count int64
+	if err := db.Model(&model.Storage{}).Where(fmt.Sprintf("%s = ?", columnName("encryption_key_id")), id).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed get storages count of encryption key")
+	}
+	return count, nil
+}
+
+func CreateEncryptionKey(k *model.EncryptionKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func DeleteEncryptionKeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.EncryptionKey{}, id).Error)
+}
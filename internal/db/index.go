@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+// GetIndexCursor returns the cursor of the storage, which is empty if it has none yet
+func GetIndexCursor(storageID uint) (*model.IndexCursor, error) {
+	var cursor model.IndexCursor
+	if err := db.Where(model.IndexCursor{StorageID: storageID}).Limit(1).Find(&cursor).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get index cursor")
+	}
+	cursor.StorageID = storageID
+	return &cursor, nil
+}
+
+func SaveIndexCursor(cursor *model.IndexCursor) error {
+	return errors.WithStack(db.Save(cursor).Error)
+}
+
+func DeleteIndexCursor(storageID uint) error {
+	return errors.WithStack(db.Delete(&model.IndexCursor{}, storageID).Error)
+}
+
+// GetIndexedDirs returns the indexed versions of the folders of the paths which have one
+func GetIndexedDirs(paths []string) ([]model.IndexedDir, error) {
+	var dirs []model.IndexedDir
+	for batch := range slices.Chunk(paths, 500) {
+		var res []model.IndexedDir
+		if err := db.Where(fmt.Sprintf("%s IN ?", columnName("path")), batch).Find(&res).Error; err != nil {
+			return nil, errors.Wrapf(err, "failed get indexed dirs")
+		}
+		dirs = append(dirs, res...)
+	}
+	return dirs, nil
+}
+
+func SaveIndexedDir(dir *model.IndexedDir) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"modified", "hash"}),
+	}).Create(dir).Error)
+}
+
+// DeleteIndexedDirs deletes the folder of the path and the folders under it
+func DeleteIndexedDirs(path string) error {
+	path = utils.FixAndCleanPath(path)
+	query := db.Where("1 = 1")
+	if path != "/" {
+		query = db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).
+			Or(fmt.Sprintf("%s LIKE ?", columnName("path")), path+"/%")
+	}
+	return errors.WithStack(query.Delete(&model.IndexedDir{}).Error)
+}
+
+func ClearIndexState() error {
+	if err := db.Where("1 = 1").Delete(&model.IndexedDir{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Where("1 = 1").Delete(&model.IndexCursor{}).Error)
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetS3AccessKeysByUserId(userId uint, pageIndex, pageSize int) (keys []model.S3AccessKey, count int64, err error) {
+	keyDB := db.Model(&model.S3AccessKey{})
+	query := model.S3AccessKey{UserId: userId}
+	if err := keyDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's s3 keys count")
+	}
+	if err := keyDB.Where(query).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's s3 keys")
+	}
+	return keys, count, nil
+}
+
+func GetS3AccessKeyById(id uint) (*model.S3AccessKey, error) {
+	var k model.S3AccessKey
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3AccessKeyByAccessKeyId(accessKeyId string) (*model.S3AccessKey, error) {
+	k := model.S3AccessKey{AccessKeyId: accessKeyId}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 key with access key id")
+	}
+	return &k, nil
+}
+
+func GetS3AccessKeyByUserTitle(userId uint, title string) (*model.S3AccessKey, error) {
+	k := model.S3AccessKey{UserId: userId, Title: title}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 key with title of user")
+	}
+	return &k, nil
+}
+
+func CountS3AccessKeys() (int64, error) {
+	var count int64
+	if err := db.Model(&model.S3AccessKey{}).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed get s3 keys count")
+	}
+	return count, nil
+}
+
+func CreateS3AccessKey(k *model.S3AccessKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func UpdateS3AccessKey(k *model.S3AccessKey) error {
+	return errors.WithStack(db.Save(k).Error)
+}
+
+func DeleteS3AccessKeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3AccessKey{}, id).Error)
+}
+
+func DeleteS3AccessKeysByUserId(userId uint) error {
+	return errors.WithStack(db.Where(model.S3AccessKey{UserId: userId}).Delete(&model.S3AccessKey{}).Error)
+}
@@ -35,7 +35,7 @@ func DeleteSearchNodesByParent(path string) error {
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
+	dir, name := stdpath.Dir(path), stdpath.Base(path)
 	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
 		dir, name).Delete(&model.SearchNode{}).Error
@@ -55,37 +55,74 @@ func GetSearchNodesByParent(parent string) ([]model.SearchNode, error) {
 }
 
 func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
-	var searchDB *gorm.DB
-	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
-		keywordsClause := db.Where("1 = 1")
-		for _, keyword := range strings.Fields(req.Keywords) {
-			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
-		}
-		searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
-	} else {
-		switch conf.Conf.Database.Type {
-		case "mysql":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
-		case "postgres":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
+	searchDB := db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent))
+	if keywords := strings.Fields(req.Keywords); len(keywords) > 0 {
+		if !useFullText || conf.Conf.Database.Type == "sqlite3" {
+			for _, keyword := range keywords {
+				searchDB = searchDB.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
+			}
+		} else {
+			switch conf.Conf.Database.Type {
+			case "mysql":
+				searchDB = searchDB.Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
+			case "postgres":
+				searchDB = searchDB.Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(keywords, " & "))
+			}
 		}
 	}
 
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
-		searchDB.Where(db.Where("is_dir = ?", isDir))
+		searchDB = searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = whereSearchFilter(searchDB, &req.SearchFilter)
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	if err := searchDB.Order(searchOrder(req)).Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 	return files, count, nil
 }
+
+func whereSearchFilter(tx *gorm.DB, f *model.SearchFilter) *gorm.DB {
+	if f.FilesOnly() {
+		tx = tx.Where(fmt.Sprintf("%s = ?", columnName("is_dir")), false)
+	}
+	if f.MinSize != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("size")), *f.MinSize)
+	}
+	if f.MaxSize != nil {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", columnName("size")), *f.MaxSize)
+	}
+	if f.ModifiedAfter != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("modified")), *f.ModifiedAfter)
+	}
+	if f.ModifiedBefore != nil {
+		tx = tx.Where(fmt.Sprintf("%s < ?", columnName("modified")), *f.ModifiedBefore)
+	}
+	if len(f.Exts) > 0 {
+		tx = tx.Where(fmt.Sprintf("%s IN ?", columnName("ext")), f.Exts)
+	}
+	if len(f.Types) > 0 {
+		tx = tx.Where(fmt.Sprintf("%s IN ?", columnName("obj_type")), f.Types)
+	}
+	return tx
+}
+
+func searchOrder(req model.SearchReq) string {
+	direction := "asc"
+	if req.OrderDirection == "desc" {
+		direction = "desc"
+	}
+	switch req.OrderBy {
+	case "size", "modified":
+		return fmt.Sprintf("%s %s, %s asc", columnName(req.OrderBy), direction, columnName("name"))
+	default:
+		return fmt.Sprintf("%s %s", columnName("name"), direction)
+	}
+}
@@ -122,3 +122,32 @@ func RecordShareAccess(shareID string) (*model.Share, error) {
 	}
 	return &updated, nil
 }
+
+// ReserveShareUpload adds size to the uploaded size of the share if it stays within the quota,
+// reserved is false if the quota would be exceeded
+func ReserveShareUpload(shareID string, size int64) (reserved bool, err error) {
+	res := db.Model(&model.Share{}).
+		Where("share_id = ? AND (upload_quota = 0 OR uploaded_size + ? <= upload_quota)", shareID, size).
+		UpdateColumn("uploaded_size", gorm.Expr("uploaded_size + ?", size))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReleaseShareUpload gives back the size reserved for an upload which failed
+func ReleaseShareUpload(shareID string, size int64) error {
+	return db.Model(&model.Share{}).
+		Where("share_id = ?", shareID).
+		UpdateColumn("uploaded_size", gorm.Expr("uploaded_size - ?", size)).Error
+}
+
+func TouchShareUpload(shareID string) error {
+	now := time.Now()
+	return db.Model(&model.Share{}).
+		Where("share_id = ?", shareID).
+		UpdateColumns(map[string]interface{}{
+			"last_access_at": now,
+			"upload_count":   gorm.Expr("upload_count + ?", 1),
+		}).Error
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateShareAccessLog(l *model.ShareAccessLog) error {
+	return db.Create(l).Error
+}
+
+// shareAccessLogQuery selects the logs of the share in [from, to), the zero times are not bounds
+func shareAccessLogQuery(shareID string, from, to time.Time) *gorm.DB {
+	tx := db.Model(&model.ShareAccessLog{}).Where("share_id = ?", shareID)
+	if !from.IsZero() {
+		tx = tx.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		tx = tx.Where("created_at < ?", to)
+	}
+	return tx
+}
+
+func GetShareAccessLogs(shareID string, from, to time.Time, pageIndex, pageSize int) (logs []model.ShareAccessLog, count int64, err error) {
+	tx := shareAccessLogQuery(shareID, from, to)
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	err = tx.Order("id desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error
+	return
+}
+
+// EachShareAccessLog calls f with the logs of the share in [from, to) in batches, the oldest first
+func EachShareAccessLog(shareID string, from, to time.Time, f func(logs []model.ShareAccessLog) error) error {
+	var logs []model.ShareAccessLog
+	return shareAccessLogQuery(shareID, from, to).Order("id").FindInBatches(&logs, 500, func(tx *gorm.DB, batch int) error {
+		return f(logs)
+	}).Error
+}
+
+func RenameShareAccessLogs(oldShareID, newShareID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ShareAccessLog{}).Where("share_id = ?", oldShareID).
+			Update("share_id", newShareID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.ShareAccessDaily{}).Where("share_id = ?", oldShareID).
+			Update("share_id", newShareID).Error
+	})
+}
+
+func DeleteShareAccessLogsBefore(t time.Time) error {
+	return db.Where("created_at < ?", t).Delete(&model.ShareAccessLog{}).Error
+}
+
+// RollupShareAccess sums up the logs in [start, start+1 day) into the daily rollups of the day
+func RollupShareAccess(start time.Time) error {
+	end := start.AddDate(0, 0, 1)
+	date := start.Format("2006-01-02")
+	var counts []struct {
+		ShareID string
+		Action  string
+		Count   int64
+		Bytes   int64
+	}
+	if err := db.Model(&model.ShareAccessLog{}).
+		Select("share_id, action, count(*) as count, coalesce(sum(bytes), 0) as bytes").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("share_id, action").Scan(&counts).Error; err != nil {
+		return err
+	}
+	var ips []struct {
+		ShareID string
+		Count   int64
+	}
+	if err := db.Model(&model.ShareAccessLog{}).
+		Select("share_id, count(distinct ip) as count").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("share_id").Scan(&ips).Error; err != nil {
+		return err
+	}
+	rollups := make(map[string]*model.ShareAccessDaily)
+	for _, ip := range ips {
+		rollups[ip.ShareID] = &model.ShareAccessDaily{ShareID: ip.ShareID, Date: date, UniqueIPs: ip.Count}
+	}
+	for _, c := range counts {
+		r, ok := rollups[c.ShareID]
+		if !ok {
+			continue
+		}
+		r.Bytes += c.Bytes
+		switch c.Action {
+		case model.ShareActionView:
+			r.Views += c.Count
+		case model.ShareActionDownload:
+			r.Downloads += c.Count
+		case model.ShareActionPreview:
+			r.Previews += c.Count
+		case model.ShareActionUpload:
+			r.Uploads += c.Count
+		}
+	}
+	for _, r := range rollups {
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "share_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"views", "downloads", "previews", "uploads", "bytes", "unique_ips"}),
+		}).Create(r).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetShareAccessDailies(shareID, fromDate, toDate string) (dailies []model.ShareAccessDaily, err error) {
+	tx := db.Where("share_id = ?", shareID)
+	if fromDate != "" {
+		tx = tx.Where("date >= ?", fromDate)
+	}
+	if toDate != "" {
+		tx = tx.Where("date <= ?", toDate)
+	}
+	err = tx.Order("date").Find(&dailies).Error
+	return
+}
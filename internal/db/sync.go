@@ -0,0 +1,61 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &j, nil
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err := jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err := jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetScheduledSyncJobs() (jobs []model.SyncJob, err error) {
+	if err := db.Where(columnName("disabled")+" = ? AND "+columnName("cron")+" <> ?", false, "").Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find scheduled sync jobs")
+	}
+	return jobs, nil
+}
+
+func DeleteSyncJobById(id uint) error {
+	if err := db.Delete(&model.SyncState{}, id).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
+
+// GetSyncState returns the state of the last run of the job, with no entries if there is none
+func GetSyncState(jobID uint) (*model.SyncState, error) {
+	var state model.SyncState
+	if err := db.Where(model.SyncState{JobID: jobID}).Limit(1).Find(&state).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync state")
+	}
+	state.JobID = jobID
+	return &state, nil
+}
+
+func SaveSyncState(state *model.SyncState) error {
+	return errors.WithStack(db.Save(state).Error)
+}
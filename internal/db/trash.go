@@ -0,0 +1,53 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(t *model.TrashItem) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var t model.TrashItem
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &t, nil
+}
+
+// GetTrashItems returns the items removed by the user, or all items if userId is 0
+func GetTrashItems(userId uint, pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	if userId != 0 {
+		trashDB = trashDB.Where(model.TrashItem{UserID: userId})
+	}
+	if err := trashDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err := trashDB.Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+func GetInPlaceTrashItems() (items []model.TrashItem, err error) {
+	if err := db.Where(columnName("trash_path")+" = ?", "").Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find in place trash items")
+	}
+	return items, nil
+}
+
+func GetTrashItemsRemovedBefore(t time.Time) (items []model.TrashItem, err error) {
+	if err := db.Where(columnName("removed_at")+" < ?", t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find expired trash items")
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTusUpload(u *model.TusUpload) error {
+	return errors.WithStack(db.Create(u).Error)
+}
+
+func GetTusUploadById(id string) (*model.TusUpload, error) {
+	var u model.TusUpload
+	if err := db.Where(columnName("id")+" = ?", id).First(&u).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get tus upload")
+	}
+	return &u, nil
+}
+
+func UpdateTusUploadOffset(id string, offset int64) error {
+	return errors.WithStack(db.Model(&model.TusUpload{ID: id}).Update("offset", offset).Error)
+}
+
+func GetTusUploads() (uploads []model.TusUpload, err error) {
+	if err := db.Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find tus uploads")
+	}
+	return uploads, nil
+}
+
+func GetTusUploadsUpdatedBefore(t time.Time) (uploads []model.TusUpload, err error) {
+	if err := db.Where(columnName("updated_at")+" < ?", t).Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find expired tus uploads")
+	}
+	return uploads, nil
+}
+
+func DeleteTusUploadById(id string) error {
+	return errors.WithStack(db.Delete(&model.TusUpload{ID: id}).Error)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern, '!' is the escape character
+// as a backslash is taken as an escape in the string literals of mysql
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// whereInPath matches the path and the paths under it
+func whereInPath(tx *gorm.DB, path string) *gorm.DB {
+	return tx.Where(fmt.Sprintf("%s = ? OR %s LIKE ? ESCAPE '!'", columnName("path"), columnName("path")),
+		path, likeEscaper.Replace(strings.TrimSuffix(path, "/"))+"/%")
+}
+
+func CountWebdavProps() (int64, error) {
+	var count int64
+	if err := db.Model(&model.WebdavProp{}).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed get webdav props count")
+	}
+	return count, nil
+}
+
+func GetWebdavProps(path string) ([]model.WebdavProp, error) {
+	var props []model.WebdavProp
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav props of %s", path)
+	}
+	return props, nil
+}
+
+// PatchWebdavProps sets and removes the props of the path, either all or none of them
+func PatchWebdavProps(path string, set, remove []model.WebdavProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range append(remove, set...) {
+			if err := tx.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ?",
+				columnName("path"), columnName("space"), columnName("local")),
+				path, p.Space, p.Local).Delete(&model.WebdavProp{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range set {
+			set[i].ID, set[i].Path = 0, path
+			if err := tx.Create(&set[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// DeleteWebdavProps removes the props of the path and the paths under it
+func DeleteWebdavProps(path string) error {
+	return errors.WithStack(whereInPath(db, path).Delete(&model.WebdavProp{}).Error)
+}
+
+// MoveWebdavProps moves the props of the path and the paths under it to dst,
+// or copies them if keep is true
+func MoveWebdavProps(src, dst string, keep bool) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		// the destination is overwritten with its props
+		if err := whereInPath(tx, dst).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		var props []model.WebdavProp
+		if err := whereInPath(tx, src).Find(&props).Error; err != nil {
+			return err
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		for i := range props {
+			props[i].Path = dst + strings.TrimPrefix(props[i].Path, src)
+			if keep {
+				props[i].ID = 0
+			}
+		}
+		if keep {
+			return tx.CreateInBatches(&props, 100).Error
+		}
+		for i := range props {
+			if err := tx.Save(&props[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var w model.Webhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &w, nil
+}
+
+func GetWebhooks(pageIndex, pageSize int) (webhooks []model.Webhook, count int64, err error) {
+	webhookDB := db.Model(&model.Webhook{})
+	if err := webhookDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhooks count")
+	}
+	if err := webhookDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&webhooks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhooks")
+	}
+	return webhooks, count, nil
+}
+
+func GetEnabledWebhooks() (webhooks []model.Webhook, err error) {
+	if err := db.Where(columnName("disabled")+" = ?", false).Find(&webhooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled webhooks")
+	}
+	return webhooks, nil
+}
+
+func DeleteWebhookById(id uint) error {
+	if err := db.Where(columnName("webhook_id")+" = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete webhook deliveries")
+	}
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
+
+func CreateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Create(d).Error)
+}
+
+func UpdateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Save(d).Error)
+}
+
+func GetWebhookDeliveryById(id uint) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := db.First(&d, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook delivery")
+	}
+	return &d, nil
+}
+
+func GetWebhookDeliveries(webhookID uint, pageIndex, pageSize int) (deliveries []model.WebhookDelivery, count int64, err error) {
+	deliveryDB := db.Model(&model.WebhookDelivery{}).Where(columnName("webhook_id")+" = ?", webhookID)
+	if err := deliveryDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhook deliveries count")
+	}
+	if err := deliveryDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhook deliveries")
+	}
+	return deliveries, count, nil
+}
+
+func GetDueWebhookDeliveries(now time.Time) (deliveries []model.WebhookDelivery, err error) {
+	if err := db.Where(columnName("state")+" = ? AND "+columnName("next_attempt_at")+" <= ?", model.DeliveryPending, now).
+		Order(columnName("next_attempt_at")).Find(&deliveries).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find due webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+func DeleteWebhookDeliveriesUpdatedBefore(t time.Time) error {
+	return errors.WithStack(db.Where(columnName("state")+" <> ? AND "+columnName("updated_at")+" < ?", model.DeliveryPending, t).
+		Delete(&model.WebhookDelivery{}).Error)
+}
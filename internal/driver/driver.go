@@ -42,6 +42,34 @@ type GetRooter interface {
 	GetRoot(ctx context.Context) (model.Obj, error)
 }
 
+// StorageDetails is implemented by the drivers which can report the capacity of the storage
+type StorageDetails interface {
+	GetDetails(ctx context.Context) (*model.StorageDetails, error)
+}
+
+// GarbageCollector is implemented by the drivers keeping the data no file refers to anymore until it's collected,
+// e.g. the chunks of a dedup pool
+type GarbageCollector interface {
+	// CollectGarbage removes the data no file refers to, returns the number of the removed items and their size
+	CollectGarbage(ctx context.Context, up UpdateProgress) (int, int64, error)
+}
+
+// Scrubber is implemented by the drivers storing the files redundantly, e.g. the copies of a mirror
+type Scrubber interface {
+	// Scrub checks that every file has all its copies, and re-creates the missing ones if repair is true
+	Scrub(ctx context.Context, repair bool, up UpdateProgress) (*model.ScrubResult, error)
+}
+
+// ChangeFeed is implemented by the drivers which can tell the folders changed since a point in time,
+// e.g. by a delta api, so that the index is updated without walking the storage
+type ChangeFeed interface {
+	// ChangeFeedEnabled reports whether the changes include the deleted objects, the storage is walked otherwise
+	ChangeFeedEnabled() bool
+	// Changes returns the paths of the folders whose entries changed since the cursor, and the cursor to continue from.
+	// An empty cursor returns no change but the cursor of now.
+	Changes(ctx context.Context, cursor string) (dirs []string, next string, err error)
+}
+
 type Getter interface {
 	// Get file by path, the path haven't been joined with root path
 	Get(ctx context.Context, path string) (model.Obj, error)
@@ -208,3 +236,15 @@ type ArchiveDecompressResult interface {
 type Reference interface {
 	InitReference(storage Driver) error
 }
+
+type Versioner interface {
+	// NativeVersioning reports whether the storage keeps the previous versions of the files by itself,
+	// e.g. a s3 bucket with versioning enabled. Otherwise, they are moved to a hidden folder before overwriting.
+	NativeVersioning() bool
+	// ListVersions returns the previous versions of the file, the newest first
+	ListVersions(ctx context.Context, file model.Obj) ([]model.FileVersion, error)
+	// LinkVersion get the url/filepath/reader of a previous version of the file
+	LinkVersion(ctx context.Context, file model.Obj, versionID string, args model.LinkArgs) (*model.Link, error)
+	// RestoreVersion makes a previous version the current content of the file
+	RestoreVersion(ctx context.Context, file model.Obj, versionID string) error
+}
@@ -14,6 +14,8 @@ var (
 
 	MoveBetweenTwoStorages = errors.New("can't move files between two storages, try to copy")
 	UploadNotSupported     = errors.New("upload not supported")
+	UploadOffsetMismatch   = errors.New("upload offset mismatch")
+	UploadLocked           = errors.New("upload is being written by another request")
 
 	MetaNotFound     = errors.New("meta not found")
 	StorageNotFound  = errors.New("storage not found")
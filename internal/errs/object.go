@@ -10,6 +10,8 @@ var (
 	ObjectNotFound = errors.New("object not found")
 	NotFolder      = errors.New("not a folder")
 	NotFile        = errors.New("not a file")
+
+	ObjectAlreadyExists = errors.New("object already exists")
 )
 
 func IsObjectNotFound(err error) bool {
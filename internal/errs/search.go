@@ -5,4 +5,5 @@ import "fmt"
 var (
 	SearchNotAvailable  = fmt.Errorf("search not available")
 	BuildIndexIsRunning = fmt.Errorf("build index is running, please try later")
+	UpdateNotSupported  = fmt.Errorf("update is not supported for current index")
 )
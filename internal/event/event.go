@@ -0,0 +1,100 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// the paths in the data of the events are mount paths
+const (
+	FileCreated    = "file.created"
+	FileRemoved    = "file.removed"
+	FileRenamed    = "file.renamed"
+	FileMoved      = "file.moved"
+	UploadFinished = "upload.finished"
+	TaskSucceeded  = "task.succeeded"
+	TaskFailed     = "task.failed"
+	TaskCanceled   = "task.canceled"
+	ShareAccessed  = "share.accessed"
+	LoginFailed    = "login.failed"
+	StorageError   = "storage.error"
+)
+
+var Types = []string{
+	FileCreated, FileRemoved, FileRenamed, FileMoved, UploadFinished,
+	TaskSucceeded, TaskFailed, TaskCanceled, ShareAccessed, LoginFailed, StorageError,
+}
+
+func IsValidType(typ string) bool {
+	for _, t := range Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	ID   string         `json:"id"`
+	Type string         `json:"type"`
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data"`
+}
+
+type Subscriber func(e *Event)
+
+// queueSize is the number of events waiting for the subscribers, newer events are dropped beyond
+const queueSize = 1024
+
+var (
+	mu          sync.RWMutex
+	subscribers []Subscriber
+	queue       = make(chan *Event, queueSize)
+	dispatching sync.Once
+)
+
+// Subscribe registers s to receive all the events. The subscribers are called
+// one event after another in the order of publishing, they should not block.
+func Subscribe(s Subscriber) {
+	mu.Lock()
+	subscribers = append(subscribers, s)
+	mu.Unlock()
+	dispatching.Do(func() {
+		go dispatch()
+	})
+}
+
+func dispatch() {
+	for e := range queue {
+		mu.RLock()
+		subs := subscribers
+		mu.RUnlock()
+		for _, s := range subs {
+			s(e)
+		}
+	}
+}
+
+// Publish sends the event to the subscribers without waiting for them
+func Publish(typ string, data map[string]any) {
+	mu.RLock()
+	n := len(subscribers)
+	mu.RUnlock()
+	if n == 0 {
+		return
+	}
+	e := &Event{
+		ID:   uuid.NewString(),
+		Type: typ,
+		Time: time.Now(),
+		Data: data,
+	}
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("event queue is full, drop event %s: %v", typ, data)
+	}
+}
@@ -0,0 +1,175 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+// Conflict policies decide what to do when the destination of a copy or move
+// already exists, the policy is passed with the conf.ConflictPolicyKey context value.
+const (
+	// ConflictCancel fails the operation
+	ConflictCancel = "cancel"
+	// ConflictOverwrite replaces the existing object
+	ConflictOverwrite = "overwrite"
+	// ConflictSkip keeps the existing object and skips the source
+	ConflictSkip = "skip"
+	// ConflictAutoRename keeps both, the source gets a name like "name (1).ext"
+	ConflictAutoRename = "auto_rename"
+	// ConflictSkipSameSize skips if the sizes are equal, it's what the skip_existing flag used to do
+	ConflictSkipSameSize = "skip_if_same_size"
+	// ConflictSkipSameSizeAndMtime skips if both the size and the modified time are equal, otherwise overwrites
+	ConflictSkipSameSizeAndMtime = "skip_if_same_size_and_mtime"
+	// ConflictSkipSameHash skips if a hash known by both sides is equal, otherwise overwrites
+	ConflictSkipSameHash = "skip_if_same_hash"
+)
+
+func IsValidConflictPolicy(policy string) bool {
+	switch policy {
+	case "", ConflictCancel, ConflictOverwrite, ConflictSkip, ConflictAutoRename,
+		ConflictSkipSameSize, ConflictSkipSameSizeAndMtime, ConflictSkipSameHash:
+		return true
+	}
+	return false
+}
+
+func conflictPolicy(ctx context.Context) string {
+	if policy, ok := ctx.Value(conf.ConflictPolicyKey).(string); ok {
+		return policy
+	}
+	if ctx.Value(conf.SkipExistingKey) != nil {
+		return ConflictSkipSameSize
+	}
+	return ""
+}
+
+type conflictAction int
+
+const (
+	conflictProceed conflictAction = iota
+	conflictSkip
+	conflictRename
+	conflictFail
+)
+
+// resolveConflict decides what to do with src as dst already exists.
+// An empty policy keeps the old behaviour, which is letting the driver overwrite.
+func resolveConflict(policy string, src, dst model.Obj) conflictAction {
+	if src.IsDir() && dst.IsDir() && policy != ConflictAutoRename {
+		// merge the folders, the policy applies to the files inside
+		return conflictProceed
+	}
+	switch policy {
+	case ConflictCancel:
+		return conflictFail
+	case ConflictSkip:
+		return conflictSkip
+	case ConflictAutoRename:
+		return conflictRename
+	case ConflictSkipSameSize:
+		if !dst.IsDir() && dst.GetSize() == src.GetSize() {
+			return conflictSkip
+		}
+	case ConflictSkipSameSizeAndMtime:
+		if !dst.IsDir() && dst.GetSize() == src.GetSize() && dst.ModTime().Unix() == src.ModTime().Unix() {
+			return conflictSkip
+		}
+	case ConflictSkipSameHash:
+		// objects without any hash type in common are considered different
+		if equal, _ := compareHash(src, dst); !dst.IsDir() && dst.GetSize() == src.GetSize() && equal {
+			return conflictSkip
+		}
+	}
+	return conflictProceed
+}
+
+// compareHash compares the hashes of the types known by both objects,
+// compared is false if they have no hash type in common.
+func compareHash(a, b model.Obj) (equal, compared bool) {
+	bHash := b.GetHash()
+	for ht, v := range a.GetHash().All() {
+		if w := bHash.GetHash(ht); w != "" && v != "" {
+			if !strings.EqualFold(v, w) {
+				return false, true
+			}
+			compared = true
+		}
+	}
+	return compared, compared
+}
+
+// checkConflict looks up the destination of src in dstDirPath and returns the
+// action to take, with the name to use if it should be renamed.
+// excludeDirs are the other folders the new name must be free in.
+func checkConflict(ctx context.Context, policy string, storage driver.Driver, src model.Obj, dstDirPath string, excludeDirs ...string) (conflictAction, string, error) {
+	if policy == "" || policy == ConflictOverwrite {
+		return conflictProceed, src.GetName(), nil
+	}
+	dst, err := op.Get(ctx, storage, stdpath.Join(dstDirPath, src.GetName()))
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return conflictProceed, src.GetName(), nil
+		}
+		return conflictFail, "", errors.WithMessage(err, "failed to check the destination")
+	}
+	action := resolveConflict(policy, src, dst)
+	switch action {
+	case conflictFail:
+		return action, "", errors.Wrapf(errs.ObjectAlreadyExists, "[%s]", stdpath.Join(dstDirPath, src.GetName()))
+	case conflictRename:
+		name, err := freeName(ctx, storage, src.GetName(), append(excludeDirs, dstDirPath)...)
+		return action, name, err
+	}
+	return action, src.GetName(), nil
+}
+
+// freeName returns the first name like "name (1).ext" which is used in none of the dirs
+func freeName(ctx context.Context, storage driver.Driver, name string, dirs ...string) (string, error) {
+	used := make(map[string]struct{})
+	for _, dir := range dirs {
+		objs, err := op.List(ctx, storage, dir, model.ListArgs{Refresh: true})
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed list [%s]", dir)
+		}
+		for _, obj := range objs {
+			used[obj.GetName()] = struct{}{}
+		}
+	}
+	ext := stdpath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// dot files like ".env" have no extension
+		base, ext = name, ""
+	}
+	for i := 1; ; i++ {
+		n := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, ok := used[n]; !ok {
+			return n, nil
+		}
+	}
+}
+
+// FreeName returns name if nothing is named so in the folder at dirPath,
+// otherwise the first free name like "name (1).ext"
+func FreeName(ctx context.Context, dirPath, name string) (string, error) {
+	storage, actualDirPath, err := op.GetStorageAndActualPath(dirPath)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get storage")
+	}
+	if _, err = op.Get(ctx, storage, stdpath.Join(actualDirPath, name)); err != nil {
+		if errs.IsObjectNotFound(err) {
+			return name, nil
+		}
+		return "", err
+	}
+	return freeName(ctx, storage, name, actualDirPath)
+}
@@ -10,8 +10,10 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -28,7 +30,41 @@ type CopyTask struct {
 	dstStorage   driver.Driver `json:"-"`
 	SrcStorageMp string        `json:"src_storage_mp"`
 	DstStorageMp string        `json:"dst_storage_mp"`
-	SkipExisting bool          `json:"skip_existing"`
+	// SkipExisting is kept for the tasks persisted before ConflictPolicy
+	SkipExisting   bool   `json:"skip_existing"`
+	ConflictPolicy string `json:"conflict_policy"`
+	// DstName is the name picked by the auto_rename policy
+	DstName string `json:"dst_name,omitempty"`
+}
+
+func (t *CopyTask) conflictPolicy() string {
+	if t.ConflictPolicy == "" && t.SkipExisting {
+		return ConflictSkipSameSize
+	}
+	return t.ConflictPolicy
+}
+
+// checkConflict applies the conflict policy to srcObj and reports whether it should be skipped.
+// A picked name is kept in the task, so that a retry goes on with the same one.
+func (t *CopyTask) checkConflict(dstStorage driver.Driver, srcObj model.Obj, dstDirPath string) (bool, error) {
+	if t.DstName != "" {
+		return false, nil
+	}
+	action, name, err := checkConflict(t.Ctx(), t.conflictPolicy(), dstStorage, srcObj, dstDirPath)
+	if err != nil {
+		return false, err
+	}
+	if action == conflictRename {
+		t.DstName = name
+	}
+	return action == conflictSkip, nil
+}
+
+func (t *CopyTask) dstName(srcObj model.Obj) string {
+	if t.DstName != "" {
+		return t.DstName
+	}
+	return srcObj.GetName()
 }
 
 func (t *CopyTask) GetName() string {
@@ -70,21 +106,32 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
-	skipExisting := ctx.Value(conf.SkipExistingKey) != nil
+	policy := conflictPolicy(ctx)
 	// copy if in the same storage, just call driver.Copy
 	if srcStorage.GetStorage() == dstStorage.GetStorage() {
-		if skipExisting {
-			if srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath); err == nil && !srcObj.IsDir() {
-				dstFilePath := stdpath.Join(dstDirActualPath, srcObj.GetName())
-				if dstFile, err := op.Get(ctx, dstStorage, dstFilePath); err == nil &&
-					!dstFile.IsDir() && dstFile.GetSize() == srcObj.GetSize() {
-					return nil, nil
+		native := true
+		if policy != "" && policy != ConflictOverwrite {
+			if srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath); err == nil {
+				dstObj, err := op.Get(ctx, dstStorage, stdpath.Join(dstDirActualPath, srcObj.GetName()))
+				if err == nil {
+					switch resolveConflict(policy, srcObj, dstObj) {
+					case conflictSkip:
+						return nil, nil
+					case conflictFail:
+						return nil, errors.Wrapf(errs.ObjectAlreadyExists, "[%s]", stdpath.Join(dstDirPath, srcObj.GetName()))
+					default:
+						// the driver can't rename the copy or apply the policy to the
+						// files of a merged folder, leave it to the copy task
+						native = false
+					}
 				}
 			}
 		}
-		err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
-		if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
-			return nil, err
+		if native {
+			err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
+				return nil, err
+			}
 		}
 	}
 	if ctx.Value(conf.NoTaskKey) != nil {
@@ -93,23 +140,15 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 			return nil, errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
 		}
 		if !srcObj.IsDir() {
-			// copy file directly
-			link, _, err := op.Link(ctx, srcStorage, srcObjActualPath, model.LinkArgs{
-				Header: http.Header{},
-			})
+			action, name, err := checkConflict(ctx, policy, dstStorage, srcObj, dstDirActualPath)
 			if err != nil {
-				return nil, errors.WithMessagef(err, "failed get [%s] link", srcObjPath)
-			}
-			fs := stream.FileStream{
-				Obj: srcObj,
-				Ctx: ctx,
+				return nil, err
 			}
-			// any link provided is seekable
-			ss, err := stream.NewSeekableStream(fs, link)
-			if err != nil {
-				return nil, errors.WithMessagef(err, "failed get [%s] stream", srcObjPath)
+			if action == conflictSkip {
+				return nil, nil
 			}
-			return nil, op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false)
+			// copy file directly
+			return nil, copyFileStream(ctx, srcStorage, srcObjActualPath, renamedObj(srcObj, name), dstStorage, dstDirActualPath, nil, lazyCache...)
 		}
 	}
 	// not in the same storage
@@ -118,13 +157,13 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 		TaskExtension: task.TaskExtension{
 			Creator: taskCreator,
 		},
-		srcStorage:   srcStorage,
-		dstStorage:   dstStorage,
-		SrcObjPath:   srcObjActualPath,
-		DstDirPath:   dstDirActualPath,
-		SrcStorageMp: srcStorage.GetStorage().MountPath,
-		DstStorageMp: dstStorage.GetStorage().MountPath,
-		SkipExisting: skipExisting,
+		srcStorage:     srcStorage,
+		dstStorage:     dstStorage,
+		SrcObjPath:     srcObjActualPath,
+		DstDirPath:     dstDirActualPath,
+		SrcStorageMp:   srcStorage.GetStorage().MountPath,
+		DstStorageMp:   dstStorage.GetStorage().MountPath,
+		ConflictPolicy: policy,
 	}
 	CopyTaskManager.Add(t)
 	return t, nil
@@ -137,6 +176,14 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 		return errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
 	}
 	if srcObj.IsDir() {
+		skip, err := t.checkConflict(dstStorage, srcObj, dstDirPath)
+		if err != nil {
+			return err
+		}
+		if skip {
+			t.Status = "skipped: destination already exists"
+			return nil
+		}
 		t.Status = "src object is dir, listing objs"
 		objs, err := op.List(t.Ctx(), srcStorage, srcObjPath, model.ListArgs{})
 		if err != nil {
@@ -147,18 +194,19 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 				return nil
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
-			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
+			dstObjPath := stdpath.Join(dstDirPath, t.dstName(srcObj))
 			CopyTaskManager.Add(&CopyTask{
 				TaskExtension: task.TaskExtension{
 					Creator: t.GetCreator(),
 				},
-				srcStorage:   srcStorage,
-				dstStorage:   dstStorage,
-				SrcObjPath:   srcObjPath,
-				DstDirPath:   dstObjPath,
-				SrcStorageMp: srcStorage.GetStorage().MountPath,
-				DstStorageMp: dstStorage.GetStorage().MountPath,
-				SkipExisting: t.SkipExisting,
+				srcStorage:     srcStorage,
+				dstStorage:     dstStorage,
+				SrcObjPath:     srcObjPath,
+				DstDirPath:     dstObjPath,
+				SrcStorageMp:   srcStorage.GetStorage().MountPath,
+				DstStorageMp:   dstStorage.GetStorage().MountPath,
+				SkipExisting:   t.SkipExisting,
+				ConflictPolicy: t.ConflictPolicy,
 			})
 		}
 		t.Status = "src object is dir, added all copy tasks of objs"
@@ -173,17 +221,21 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 		return errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
 	}
 	tsk.SetTotalBytes(srcFile.GetSize())
-	if tsk.SkipExisting {
-		dstFilePath := stdpath.Join(dstDirPath, srcFile.GetName())
-		// a failed probe falls through to a normal copy, worst case is a redundant transfer
-		if dstFile, err := op.Get(tsk.Ctx(), dstStorage, dstFilePath); err == nil &&
-			!dstFile.IsDir() && dstFile.GetSize() == srcFile.GetSize() {
-			tsk.Status = "skipped: destination file already exists"
-			tsk.SetProgress(100)
-			return nil
-		}
+	skip, err := tsk.checkConflict(dstStorage, srcFile, dstDirPath)
+	if err != nil {
+		return err
+	}
+	if skip {
+		tsk.Status = "skipped: destination file already exists"
+		tsk.SetProgress(100)
+		return nil
 	}
-	link, _, err := op.Link(tsk.Ctx(), srcStorage, srcFilePath, model.LinkArgs{
+	return copyFileStream(tsk.Ctx(), srcStorage, srcFilePath, renamedObj(srcFile, tsk.dstName(srcFile)), dstStorage, dstDirPath, tsk.SetProgress, true)
+}
+
+// copyFileStream uploads srcFile to dstDirPath, reading it from the link of srcFilePath
+func copyFileStream(ctx context.Context, srcStorage driver.Driver, srcFilePath string, srcFile model.Obj, dstStorage driver.Driver, dstDirPath string, up driver.UpdateProgress, lazyCache ...bool) error {
+	link, _, err := op.Link(ctx, srcStorage, srcFilePath, model.LinkArgs{
 		Header: http.Header{},
 	})
 	if err != nil {
@@ -191,12 +243,32 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 	}
 	fs := stream.FileStream{
 		Obj: srcFile,
-		Ctx: tsk.Ctx(),
+		Ctx: ctx,
 	}
 	// any link provided is seekable
 	ss, err := stream.NewSeekableStream(fs, link)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
-	return op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	if err = op.Put(ctx, dstStorage, dstDirPath, ss, up, lazyCache...); err != nil {
+		return err
+	}
+	if setting.GetBool(conf.CopyVerifyHash) {
+		if err = verifyCopy(ctx, srcStorage, srcFilePath, srcFile, dstStorage, stdpath.Join(dstDirPath, srcFile.GetName())); err != nil {
+			return err
+		}
+	}
+	event.Publish(event.FileCreated, map[string]any{
+		"path":   stdpath.Join(dstStorage.GetStorage().MountPath, dstDirPath, srcFile.GetName()),
+		"size":   srcFile.GetSize(),
+		"is_dir": false,
+	})
+	return nil
+}
+
+func renamedObj(obj model.Obj, name string) model.Obj {
+	if name == "" || name == obj.GetName() {
+		return obj
+	}
+	return &model.ObjWrapName{Name: name, Obj: obj}
 }
@@ -4,12 +4,15 @@ import (
 	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
+	stdpath "path"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -65,6 +68,8 @@ func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
 	err := makeDir(ctx, path, lazyCache...)
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
+	} else {
+		event.Publish(event.FileCreated, map[string]any{"path": path, "is_dir": true})
 	}
 	return err
 }
@@ -73,6 +78,8 @@ func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 	err := move(ctx, srcPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
+	} else {
+		event.Publish(event.FileMoved, map[string]any{"path": srcPath, "dst_dir": dstDirPath})
 	}
 	return err
 }
@@ -89,6 +96,8 @@ func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	err := rename(ctx, srcPath, dstName, lazyCache...)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
+	} else {
+		event.Publish(event.FileRenamed, map[string]any{"path": srcPath, "new_path": stdpath.Join(stdpath.Dir(srcPath), dstName)})
 	}
 	return err
 }
@@ -97,6 +106,8 @@ func Remove(ctx context.Context, path string) error {
 	err := remove(ctx, path)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
+	} else {
+		event.Publish(event.FileRemoved, map[string]any{"path": path})
 	}
 	return err
 }
@@ -105,6 +116,8 @@ func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
+	} else {
+		publishUploaded(stdpath.Join(dstDirPath, file.GetName()), file.GetSize())
 	}
 	return err
 }
@@ -168,6 +181,47 @@ func GetStorage(path string, args *GetStoragesArgs) (driver.Driver, error) {
 	return storageDriver, nil
 }
 
+// GetStorageDetails returns the capacity of the storage the path is in, the path of
+// a virtual folder gets the total of the storages mounted in it that can report it.
+// The TotalSpace is 0 if the capacity is unknown, e.g. unlimited, then the FreeSpace is meaningless
+func GetStorageDetails(ctx context.Context, path string) (*model.StorageDetails, error) {
+	path = utils.FixAndCleanPath(path)
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err == nil {
+		return op.GetStorageDetails(ctx, storage)
+	}
+	if !errors.Is(errors.Cause(err), errs.StorageNotFound) {
+		return nil, err
+	}
+	var total *model.StorageDetails
+	unlimited := false
+	for _, storage := range op.GetAllStorages() {
+		if !utils.IsSubPath(path, utils.GetActualMountPath(storage.GetStorage().MountPath)) {
+			continue
+		}
+		details, err := op.GetStorageDetails(ctx, storage)
+		if err != nil {
+			continue
+		}
+		if total == nil {
+			total = &model.StorageDetails{}
+		}
+		total.TotalSpace += details.TotalSpace
+		total.UsedSpace += details.UsedSpace
+		total.FreeSpace += details.FreeSpace
+		total.UsedSpaceTruncated = total.UsedSpaceTruncated || details.UsedSpaceTruncated
+		unlimited = unlimited || details.TotalSpace <= 0
+	}
+	if total == nil {
+		return nil, errs.NotImplement
+	}
+	if unlimited {
+		// the total of the others isn't the capacity of the folder
+		total.TotalSpace, total.FreeSpace = 0, 0
+	}
+	return total, nil
+}
+
 func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 	res, err := other(ctx, args)
 	if err != nil {
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// GCTask removes the data no file of a storage refers to anymore, see driver.GarbageCollector
+type GCTask struct {
+	task.TaskExtension
+	Status    string `json:"-"` //don't save status to save space
+	MountPath string `json:"mount_path"`
+	Removed   int    `json:"removed"`
+	Freed     int64  `json:"freed"`
+}
+
+var GCTaskManager *tache.Manager[*GCTask]
+
+func (t *GCTask) GetName() string {
+	return fmt.Sprintf("collect garbage [%s]", t.MountPath)
+}
+
+func (t *GCTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *GCTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+
+	storage, err := op.GetStorageByMountPath(t.MountPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	gc, ok := storage.(driver.GarbageCollector)
+	if !ok {
+		return errors.New("the storage doesn't need garbage collection")
+	}
+	t.Status = "collecting"
+	t.Removed, t.Freed, err = gc.CollectGarbage(t.Ctx(), t.SetProgress)
+	if err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("removed %d items, freed %d bytes", t.Removed, t.Freed)
+	t.SetProgress(100)
+	return nil
+}
+
+// CollectGarbage starts a task collecting the garbage of the storage mounted at mountPath
+func CollectGarbage(ctx context.Context, mountPath string) (task.TaskExtensionInfo, error) {
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	if _, ok := storage.(driver.GarbageCollector); !ok {
+		return nil, errors.New("the storage doesn't need garbage collection")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &GCTask{
+		TaskExtension: task.TaskExtension{Creator: taskCreator},
+		MountPath:     storage.GetStorage().MountPath,
+	}
+	GCTaskManager.Add(t)
+	return t, nil
+}
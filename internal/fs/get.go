@@ -5,6 +5,7 @@ import (
 	stdpath "path"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -35,5 +36,12 @@ func get(ctx context.Context, path string) (model.Obj, error) {
 		}
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
-	return op.Get(ctx, storage, actualPath)
+	if inHiddenFolder(ctx, actualPath) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err == nil && isTrashed(path, obj) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	return obj, err
 }
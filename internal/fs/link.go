@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -16,6 +17,9 @@ func link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
 	}
+	if inHiddenFolder(ctx, actualPath) {
+		return nil, nil, errors.WithStack(errs.ObjectNotFound)
+	}
 	l, obj, err := op.Link(ctx, storage, actualPath, args)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed link")
@@ -3,6 +3,7 @@ package fs
 import (
 	"context"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -21,6 +22,10 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
 
+	if storage != nil && inHiddenFolder(ctx, actualPath) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+
 	var _objs []model.Obj
 	if storage != nil {
 		_objs, err = op.List(ctx, storage, actualPath, model.ListArgs{
@@ -36,6 +41,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
+		_objs = filterHidden(path, actualPath, _objs)
 	}
 
 	om := model.NewObjMerge()
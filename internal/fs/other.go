@@ -11,7 +11,9 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func makeDir(ctx context.Context, path string, lazyCache ...bool) error {
@@ -34,6 +36,39 @@ func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 	if srcStorage.GetStorage() != dstStorage.GetStorage() {
 		return errors.WithStack(errs.MoveBetweenTwoStorages)
 	}
+	// the trash and the versions are only changed by restore and purge
+	if inHiddenFolder(ctx, srcActualPath) || inHiddenFolder(ctx, dstDirActualPath) {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	srcDirActualPath := stdpath.Dir(srcActualPath)
+	if policy := conflictPolicy(ctx); policy != "" && !utils.PathEqual(srcDirActualPath, dstDirActualPath) {
+		srcObj, err := op.Get(ctx, srcStorage, srcActualPath)
+		if err != nil {
+			return errors.WithMessage(err, "failed get src object")
+		}
+		action, name, err := checkConflict(ctx, policy, srcStorage, srcObj, dstDirActualPath, srcDirActualPath)
+		if err != nil {
+			return err
+		}
+		switch action {
+		case conflictSkip:
+			return nil
+		case conflictRename:
+			// rename it in place first, so that it can be moved without a conflict
+			if err = op.Rename(ctx, srcStorage, srcActualPath, name); err != nil {
+				return errors.WithMessage(err, "failed rename before moving")
+			}
+			renamedPath := stdpath.Join(srcDirActualPath, name)
+			err = op.Move(ctx, srcStorage, renamedPath, dstDirActualPath, lazyCache...)
+			if err != nil {
+				// give it its name back, as it's still in the source folder
+				if rErr := op.Rename(ctx, srcStorage, renamedPath, stdpath.Base(srcActualPath)); rErr != nil {
+					log.Errorf("failed rename [%s] back after the move failed: %+v", renamedPath, rErr)
+				}
+			}
+			return err
+		}
+	}
 	return op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
 }
 
@@ -42,6 +77,9 @@ func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if inHiddenFolder(ctx, srcActualPath) || inHiddenFolder(ctx, stdpath.Join(stdpath.Dir(srcActualPath), dstName)) {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
 	return op.Rename(ctx, storage, srcActualPath, dstName, lazyCache...)
 }
 
@@ -50,6 +88,12 @@ func remove(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if inHiddenFolder(ctx, actualPath) {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	if trashEnabled(ctx) && !inTrashFolder(actualPath) && !utils.PathEqual(actualPath, "/") {
+		return moveToTrash(ctx, storage, path, actualPath)
+	}
 	return op.Remove(ctx, storage, actualPath)
 }
 
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/pkg/errors"
 	"github.com/xhofe/tache"
+	stdpath "path"
 	"time"
 )
 
@@ -32,7 +34,17 @@ func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
+	err := op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
+	if err == nil {
+		publishUploaded(stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath, t.file.GetName()), t.file.GetSize())
+	}
+	return err
+}
+
+func publishUploaded(path string, size int64) {
+	data := map[string]any{"path": path, "size": size, "is_dir": false}
+	event.Publish(event.FileCreated, data)
+	event.Publish(event.UploadFinished, data)
 }
 
 var UploadTaskManager *tache.Manager[*UploadTask]
@@ -0,0 +1,106 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// ReencryptTask brings all the files of a storage to its current encryption settings,
+// e.g. after the key is rotated, the names encryption is switched or the encryption is disabled
+type ReencryptTask struct {
+	task.TaskExtension
+	Status    string `json:"-"` //don't save status to save space
+	MountPath string `json:"mount_path"`
+	Done      int    `json:"done"`
+	Failed    int    `json:"failed"`
+}
+
+var ReencryptTaskManager *tache.Manager[*ReencryptTask]
+
+func (t *ReencryptTask) GetName() string {
+	return fmt.Sprintf("re-encrypt [%s]", t.MountPath)
+}
+
+func (t *ReencryptTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ReencryptTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+
+	storage, err := op.GetStorageByMountPath(t.MountPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	t.Done, t.Failed = 0, 0
+	if err = t.walk(storage, "/"); err != nil {
+		return err
+	}
+	if t.Failed > 0 {
+		return errors.Errorf("failed to re-encrypt %d of %d objs", t.Failed, t.Done+t.Failed)
+	}
+	t.Status = fmt.Sprintf("re-encrypted: %d objs", t.Done)
+	t.SetProgress(100)
+	return nil
+}
+
+// walk re-encrypts the children of dir, a folder is renamed before its children,
+// which doesn't change their paths since the paths are made of the plain names
+func (t *ReencryptTask) walk(storage driver.Driver, dir string) error {
+	objs, err := op.List(t.Ctx(), storage, dir, model.ListArgs{Refresh: true, NoUpdateIndex: true})
+	if err != nil {
+		return errors.WithMessagef(err, "failed list [%s]", dir)
+	}
+	for _, obj := range objs {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		path := stdpath.Join(dir, obj.GetName())
+		t.Status = fmt.Sprintf("re-encrypting %s (%d done)", path, t.Done)
+		if err := op.ReencryptObj(t.Ctx(), storage, path, nil); err != nil {
+			t.Failed++
+			log.Errorf("failed re-encrypt [%s]: %+v", path, err)
+			continue
+		}
+		t.Done++
+		if obj.IsDir() {
+			if err := t.walk(storage, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reencrypt starts a task re-encrypting the storage mounted at mountPath
+func Reencrypt(ctx context.Context, mountPath string) (task.TaskExtensionInfo, error) {
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	s := storage.GetStorage()
+	if !s.EnableEncryption && s.EncryptionKeyId == 0 {
+		return nil, errors.New("the storage has never been encrypted")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &ReencryptTask{
+		TaskExtension: task.TaskExtension{Creator: taskCreator},
+		MountPath:     s.MountPath,
+	}
+	ReencryptTaskManager.Add(t)
+	return t, nil
+}
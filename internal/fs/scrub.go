@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// ScrubTask checks the redundancy of the files of a storage and re-creates their missing copies,
+// see driver.Scrubber
+type ScrubTask struct {
+	task.TaskExtension
+	Status    string             `json:"-"` //don't save status to save space
+	MountPath string             `json:"mount_path"`
+	Repair    bool               `json:"repair"`
+	Result    *model.ScrubResult `json:"result"`
+}
+
+var ScrubTaskManager *tache.Manager[*ScrubTask]
+
+func (t *ScrubTask) GetName() string {
+	if t.Repair {
+		return fmt.Sprintf("scrub and repair [%s]", t.MountPath)
+	}
+	return fmt.Sprintf("scrub [%s]", t.MountPath)
+}
+
+func (t *ScrubTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ScrubTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+
+	storage, err := op.GetStorageByMountPath(t.MountPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	scrubber, ok := storage.(driver.Scrubber)
+	if !ok {
+		return errors.New("the storage doesn't store the files redundantly")
+	}
+	t.Status = "scrubbing"
+	t.Result, err = scrubber.Scrub(t.Ctx(), t.Repair, t.SetProgress)
+	if err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("%d files, %d degraded, %d repaired, %d lost",
+		t.Result.Files, t.Result.Degraded, t.Result.Repaired, len(t.Result.Lost))
+	t.SetProgress(100)
+	if len(t.Result.Lost) > 0 {
+		return errors.Errorf("%d files can't be recovered: %v", len(t.Result.Lost), t.Result.Lost)
+	}
+	return nil
+}
+
+// Scrub starts a task checking the storage mounted at mountPath, and repairing it if repair is true
+func Scrub(ctx context.Context, mountPath string, repair bool) (task.TaskExtensionInfo, error) {
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	if _, ok := storage.(driver.Scrubber); !ok {
+		return nil, errors.New("the storage doesn't store the files redundantly")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &ScrubTask{
+		TaskExtension: task.TaskExtension{Creator: taskCreator},
+		MountPath:     storage.GetStorage().MountPath,
+		Repair:        repair,
+	}
+	ScrubTaskManager.Add(t)
+	return t, nil
+}
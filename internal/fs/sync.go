@@ -0,0 +1,546 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+const (
+	SyncActionCopy       = "copy"      // copy from the source to the destination
+	SyncActionCopyBack   = "copy_back" // copy from the destination to the source, two-way only
+	SyncActionMkdir      = "mkdir"
+	SyncActionMkdirBack  = "mkdir_back"
+	SyncActionDelete     = "delete"      // delete from the destination
+	SyncActionDeleteBack = "delete_back" // delete from the source, two-way only
+	// SyncActionConflict is a file on one side and a folder on the other, it's reported only
+	SyncActionConflict = "conflict"
+)
+
+const (
+	SyncItemPending = "pending"
+	SyncItemDone    = "done"
+	SyncItemFailed  = "failed"
+	SyncItemSkipped = "skipped"
+)
+
+// SyncItem is one action of a sync task, Path is relative to the synced folders
+type SyncItem struct {
+	Path     string  `json:"path"`
+	Action   string  `json:"action"`
+	Size     int64   `json:"size"`
+	State    string  `json:"state"`
+	Progress float64 `json:"progress"`
+	Error    string  `json:"error,omitempty"`
+
+	obj model.Obj
+}
+
+type SyncTask struct {
+	task.TaskExtension
+	Status           string `json:"-"` //don't save status to save space
+	JobID            uint   `json:"job_id"`
+	SrcPath          string `json:"src_path"`
+	DstPath          string `json:"dst_path"`
+	Mode             string `json:"mode"`
+	DeleteExtraneous bool   `json:"delete_extraneous"`
+	DryRun           bool   `json:"dry_run"`
+
+	mu     sync.Mutex
+	report []*SyncItem
+	// the trees walked by plan, reused as the state of a two-way job when there is nothing to apply
+	srcObjs, dstObjs map[string]model.Obj
+}
+
+var SyncTaskManager *tache.Manager[*SyncTask]
+
+func (t *SyncTask) GetName() string {
+	arrow := "->"
+	if t.Mode == model.SyncTwoWay {
+		arrow = "<->"
+	}
+	name := fmt.Sprintf("sync [%s] %s [%s]", t.SrcPath, arrow, t.DstPath)
+	if t.DryRun {
+		name += " (dry run)"
+	}
+	return name
+}
+
+func (t *SyncTask) GetStatus() string {
+	return t.Status
+}
+
+// GetReport returns a snapshot of the actions of the task with their progress
+func (t *SyncTask) GetReport() []SyncItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]SyncItem, len(t.report))
+	for i, item := range t.report {
+		res[i] = *item
+	}
+	return res
+}
+
+func (t *SyncTask) setItem(item *SyncItem, state string, progress float64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item.State = state
+	item.Progress = progress
+	if err != nil {
+		item.Error = err.Error()
+	}
+}
+
+func (t *SyncTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+
+	t.Status = "comparing"
+	var last map[string]syncEntry
+	if t.Mode == model.SyncTwoWay {
+		last = t.lastState()
+	}
+	items, err := t.plan(last)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.report = items
+	t.mu.Unlock()
+	if t.DryRun {
+		t.Status = fmt.Sprintf("dry run: %d actions", len(items))
+		t.SetProgress(100)
+		return nil
+	}
+	// every item weighs one more than its size, so that folders and empty files count too
+	var total, done int64
+	for _, item := range items {
+		total += item.Size + 1
+	}
+	t.SetTotalBytes(total - int64(len(items)))
+	failed := 0
+	for i, item := range items {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		t.Status = fmt.Sprintf("%s %s (%d/%d)", item.Action, item.Path, i+1, len(items))
+		err := t.apply(item, func(p float64) {
+			t.setItem(item, SyncItemPending, p, nil)
+			t.SetProgress((float64(done) + float64(item.Size+1)*p/100) / float64(total) * 100)
+		})
+		done += item.Size + 1
+		t.SetProgress(float64(done) / float64(total) * 100)
+		switch {
+		case item.Action == SyncActionConflict:
+			t.setItem(item, SyncItemSkipped, 0, nil)
+		case err != nil:
+			failed++
+			log.Errorf("failed sync %s [%s]: %+v", item.Action, item.Path, err)
+			t.setItem(item, SyncItemFailed, 0, err)
+		default:
+			t.setItem(item, SyncItemDone, 100, nil)
+		}
+	}
+	if t.Mode == model.SyncTwoWay {
+		t.saveState(items, last)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d actions failed", failed, len(items))
+	}
+	t.Status = fmt.Sprintf("synced: %d actions", len(items))
+	return nil
+}
+
+func (t *SyncTask) apply(item *SyncItem, up func(float64)) error {
+	ctx := t.Ctx()
+	src, dst := stdpath.Join(t.SrcPath, item.Path), stdpath.Join(t.DstPath, item.Path)
+	switch item.Action {
+	case SyncActionCopy:
+		return syncCopy(ctx, src, item.obj, stdpath.Dir(dst), up)
+	case SyncActionCopyBack:
+		return syncCopy(ctx, dst, item.obj, stdpath.Dir(src), up)
+	case SyncActionMkdir:
+		return makeDir(ctx, dst)
+	case SyncActionMkdirBack:
+		return makeDir(ctx, src)
+	case SyncActionDelete:
+		return remove(ctx, dst)
+	case SyncActionDeleteBack:
+		return remove(ctx, src)
+	}
+	return nil
+}
+
+func syncCopy(ctx context.Context, srcFilePath string, srcFile model.Obj, dstDirPath string, up func(float64)) error {
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcFilePath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	return copyFileStream(ctx, srcStorage, srcActualPath, srcFile, dstStorage, dstDirActualPath, up, true)
+}
+
+// walkTree lists all the objects under root keyed by their path relative to root.
+// Unlike WalkFS, a folder failing to list fails the whole walk, as a partial
+// tree would make the sync copy or delete the wrong files.
+func walkTree(ctx context.Context, root string) (map[string]model.Obj, error) {
+	objs := make(map[string]model.Obj)
+	rootObj, err := get(ctx, root)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return objs, nil
+		}
+		return nil, err
+	}
+	if !rootObj.IsDir() {
+		return nil, errors.WithStack(errs.NotFolder)
+	}
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		children, err := list(ctx, stdpath.Join(root, rel), &ListArgs{Refresh: true, NoLog: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", stdpath.Join(root, rel))
+		}
+		for _, child := range children {
+			childRel := stdpath.Join(rel, child.GetName())
+			objs[childRel] = child
+			if child.IsDir() {
+				if err = walk(childRel); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return objs, walk("/")
+}
+
+// syncChanged reports whether the file at the destination is out of date.
+// Hashes are trusted if both sides know a common type, otherwise a newer
+// source is considered changed, as most storages set the upload time as the
+// modified time of the copy.
+func syncChanged(src, dst model.Obj) bool {
+	if src.GetSize() != dst.GetSize() {
+		return true
+	}
+	if equal, compared := compareHash(src, dst); compared {
+		return !equal
+	}
+	return src.ModTime().After(dst.ModTime())
+}
+
+// syncEntry is an object as it was on both sides at the end of the last run of a two-way job
+type syncEntry struct {
+	Dir         bool      `json:"dir,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	SrcModified time.Time `json:"src_modified"`
+	DstModified time.Time `json:"dst_modified"`
+}
+
+// changed reports whether obj, found at the source if src is true, is not as it was at the last run
+func (e *syncEntry) changed(obj model.Obj, src bool) bool {
+	if e.Dir != obj.IsDir() {
+		return true
+	}
+	if e.Dir {
+		return false
+	}
+	modified := e.DstModified
+	if src {
+		modified = e.SrcModified
+	}
+	return e.Size != obj.GetSize() || !obj.ModTime().Equal(modified)
+}
+
+// syncDiffer is the two-way counterpart of syncChanged. Without a common hash,
+// files changed on either side since the last run differ, and files never
+// synced before differ unless their modified times are the same.
+func syncDiffer(src, dst model.Obj, last *syncEntry) bool {
+	if src.GetSize() != dst.GetSize() {
+		return true
+	}
+	if equal, compared := compareHash(src, dst); compared {
+		return !equal
+	}
+	if last != nil {
+		return last.changed(src, true) || last.changed(dst, false)
+	}
+	return !src.ModTime().Equal(dst.ModTime())
+}
+
+// syncSrcWins tells which one of two differing files is kept, the one changed
+// since the last run, or the newer one if both or none of them changed.
+func syncSrcWins(src, dst model.Obj, last *syncEntry) bool {
+	if last != nil {
+		srcChanged, dstChanged := last.changed(src, true), last.changed(dst, false)
+		if srcChanged != dstChanged {
+			return srcChanged
+		}
+	}
+	return !dst.ModTime().After(src.ModTime())
+}
+
+// dirtyDirs returns the folders holding objects added or changed on one side since the last run,
+// such folders are kept even if they were removed from the other side.
+func dirtyDirs(objs map[string]model.Obj, last map[string]syncEntry, src bool) map[string]bool {
+	dirty := make(map[string]bool)
+	for rel, obj := range objs {
+		if e, ok := last[rel]; ok && !e.changed(obj, src) {
+			continue
+		}
+		for dir := stdpath.Dir(rel); dir != "/" && !dirty[dir]; dir = stdpath.Dir(dir) {
+			dirty[dir] = true
+		}
+	}
+	return dirty
+}
+
+// lastState returns the entries saved by the last run of the two-way job, nil if there are none
+// or if the job has been moved to other folders since.
+func (t *SyncTask) lastState() map[string]syncEntry {
+	state, err := op.GetSyncState(t.JobID)
+	if err != nil {
+		log.Warnf("failed get the state of sync job %d: %+v", t.JobID, err)
+		return nil
+	}
+	if state.Entries == "" || state.SrcPath != t.SrcPath || state.DstPath != t.DstPath {
+		return nil
+	}
+	var entries map[string]syncEntry
+	if err = utils.Json.UnmarshalFromString(state.Entries, &entries); err != nil {
+		log.Warnf("invalid state of sync job %d: %s", t.JobID, err)
+		return nil
+	}
+	return entries
+}
+
+// saveState records the objects found on both sides once the actions are applied,
+// so that the next run tells an object removed from one side from a new one.
+func (t *SyncTask) saveState(items []*SyncItem, last map[string]syncEntry) {
+	srcObjs, dstObjs := t.srcObjs, t.dstObjs
+	if len(items) > 0 {
+		var err error
+		if srcObjs, err = walkTree(t.Ctx(), t.SrcPath); err == nil {
+			dstObjs, err = walkTree(t.Ctx(), t.DstPath)
+		}
+		if err != nil {
+			log.Warnf("failed walk the folders of sync job %d, its state is left as is: %+v", t.JobID, err)
+			return
+		}
+	}
+	entries := make(map[string]syncEntry)
+	for rel, src := range srcObjs {
+		dst, ok := dstObjs[rel]
+		if !ok || src.IsDir() != dst.IsDir() || (!src.IsDir() && src.GetSize() != dst.GetSize()) {
+			continue
+		}
+		e := syncEntry{Dir: src.IsDir(), SrcModified: src.ModTime(), DstModified: dst.ModTime()}
+		if !e.Dir {
+			e.Size = src.GetSize()
+		}
+		entries[rel] = e
+	}
+	// a failed removal is tried again by the next run instead of being undone
+	for _, item := range items {
+		if item.State != SyncItemFailed || (item.Action != SyncActionDelete && item.Action != SyncActionDeleteBack) {
+			continue
+		}
+		for rel, e := range last {
+			if _, ok := entries[rel]; !ok && utils.IsSubPath(item.Path, rel) {
+				entries[rel] = e
+			}
+		}
+	}
+	str, err := utils.Json.MarshalToString(entries)
+	if err != nil {
+		log.Warnf("failed marshal the state of sync job %d: %s", t.JobID, err)
+		return
+	}
+	err = op.SaveSyncState(&model.SyncState{JobID: t.JobID, SrcPath: t.SrcPath, DstPath: t.DstPath, Entries: str})
+	if err != nil {
+		log.Errorf("failed save the state of sync job %d: %+v", t.JobID, err)
+	}
+}
+
+// plan compares both sides and returns the actions to apply. For two-way jobs, last
+// is the state saved by the previous run, an object found on one side only that was
+// synced then and is unchanged since has been removed from the other side.
+func (t *SyncTask) plan(last map[string]syncEntry) ([]*SyncItem, error) {
+	srcObjs, err := walkTree(t.Ctx(), t.SrcPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed walk the source")
+	}
+	dstObjs, err := walkTree(t.Ctx(), t.DstPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed walk the destination")
+	}
+	t.srcObjs, t.dstObjs = srcObjs, dstObjs
+	twoWay := t.Mode == model.SyncTwoWay
+	lastOf := func(rel string) *syncEntry {
+		if e, ok := last[rel]; ok {
+			return &e
+		}
+		return nil
+	}
+	var srcDirty, dstDirty map[string]bool
+	if last != nil {
+		srcDirty, dstDirty = dirtyDirs(srcObjs, last, true), dirtyDirs(dstObjs, last, false)
+	}
+	// removed reports whether obj, found on one side only, has been removed from the other side
+	removed := func(rel string, obj model.Obj, src bool, dirty map[string]bool) bool {
+		e := lastOf(rel)
+		return e != nil && !e.changed(obj, src) && !dirty[rel]
+	}
+	var items []*SyncItem
+	add := func(rel, action string, obj model.Obj) {
+		item := &SyncItem{Path: rel, Action: action, State: SyncItemPending, obj: obj}
+		if action == SyncActionCopy || action == SyncActionCopyBack {
+			item.Size = obj.GetSize()
+		}
+		items = append(items, item)
+	}
+	for rel, src := range srcObjs {
+		dst, ok := dstObjs[rel]
+		switch {
+		case !ok && twoWay && removed(rel, src, true, srcDirty):
+			add(rel, SyncActionDeleteBack, src)
+		case !ok && src.IsDir():
+			add(rel, SyncActionMkdir, src)
+		case !ok:
+			add(rel, SyncActionCopy, src)
+		case src.IsDir() != dst.IsDir():
+			add(rel, SyncActionConflict, src)
+		case src.IsDir():
+		case !twoWay:
+			if syncChanged(src, dst) {
+				add(rel, SyncActionCopy, src)
+			}
+		case syncDiffer(src, dst, lastOf(rel)):
+			if syncSrcWins(src, dst, lastOf(rel)) {
+				add(rel, SyncActionCopy, src)
+			} else {
+				add(rel, SyncActionCopyBack, dst)
+			}
+		}
+	}
+	for rel, dst := range dstObjs {
+		if _, ok := srcObjs[rel]; ok {
+			continue
+		}
+		switch {
+		case twoWay && removed(rel, dst, false, dstDirty):
+			add(rel, SyncActionDelete, dst)
+		case twoWay && dst.IsDir():
+			add(rel, SyncActionMkdirBack, dst)
+		case twoWay:
+			add(rel, SyncActionCopyBack, dst)
+		case t.DeleteExtraneous:
+			add(rel, SyncActionDelete, dst)
+		}
+	}
+	// parents go before their children
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	// deleting a folder deletes its children as well
+	res := items[:0]
+	deleted := make(map[string][]string)
+	for _, item := range items {
+		if item.Action == SyncActionDelete || item.Action == SyncActionDeleteBack {
+			covered := false
+			for _, d := range deleted[item.Action] {
+				if utils.IsSubPath(d, item.Path) {
+					covered = true
+					break
+				}
+			}
+			if covered {
+				continue
+			}
+			deleted[item.Action] = append(deleted[item.Action], item.Path)
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// RunSyncJob adds a sync task of the job on behalf of its creator
+func RunSyncJob(job *model.SyncJob, dryRun bool) (task.TaskExtensionInfo, error) {
+	creator, err := op.GetUserById(job.CreatorID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get the creator of the sync job")
+	}
+	t := &SyncTask{
+		TaskExtension: task.TaskExtension{
+			Creator: creator,
+		},
+		JobID:            job.ID,
+		SrcPath:          job.SrcPath,
+		DstPath:          job.DstPath,
+		Mode:             job.Mode,
+		DeleteExtraneous: job.DeleteExtraneous,
+		DryRun:           dryRun,
+	}
+	SyncTaskManager.Add(t)
+	return t, nil
+}
+
+// RunScheduledSyncJobs adds the tasks of the jobs whose schedule is due,
+// jobs still running since the last time are left alone.
+func RunScheduledSyncJobs() {
+	jobs, err := op.GetScheduledSyncJobs()
+	if err != nil {
+		log.Errorf("failed get scheduled sync jobs: %+v", err)
+		return
+	}
+	now := time.Now()
+	for i := range jobs {
+		job := &jobs[i]
+		schedule, err := cron.ParseSchedule(job.Cron)
+		if err != nil {
+			log.Warnf("invalid cron of sync job %d: %s", job.ID, err)
+			continue
+		}
+		last := job.CreatedAt
+		if job.LastRunAt != nil {
+			last = *job.LastRunAt
+		}
+		if next := schedule.Next(last); next.IsZero() || next.After(now) {
+			continue
+		}
+		running := SyncTaskManager.GetByCondition(func(t *SyncTask) bool {
+			return t.JobID == job.ID && !t.DryRun && utils.SliceContains([]tache.State{
+				tache.StatePending, tache.StateRunning, tache.StateWaitingRetry, tache.StateBeforeRetry,
+			}, t.GetState())
+		})
+		if len(running) > 0 {
+			continue
+		}
+		if _, err = RunSyncJob(job, false); err != nil {
+			log.Errorf("failed run sync job %d: %+v", job.ID, err)
+			continue
+		}
+		job.LastRunAt = &now
+		if err = op.UpdateSyncJob(job); err != nil {
+			log.Errorf("failed update sync job %d: %+v", job.ID, err)
+		}
+	}
+}
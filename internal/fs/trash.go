@@ -0,0 +1,235 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrashFolder is created under the root of every storage which objects are
+// moved to when they are removed, it's hidden from the listings.
+const TrashFolder = ".alist_trash"
+
+// inPlaceTrash holds the objects which are removed but kept in place,
+// because their storage can't move them, keyed by their path.
+var inPlaceTrash = struct {
+	sync.RWMutex
+	once  sync.Once
+	items map[string]model.TrashItem
+}{items: make(map[string]model.TrashItem)}
+
+func loadInPlaceTrash() {
+	inPlaceTrash.once.Do(func() {
+		items, err := op.GetInPlaceTrashItems()
+		if err != nil {
+			log.Errorf("failed load trash items: %+v", err)
+			return
+		}
+		inPlaceTrash.Lock()
+		defer inPlaceTrash.Unlock()
+		for _, item := range items {
+			inPlaceTrash.items[item.Path] = item
+		}
+	})
+}
+
+// isTrashed reports whether the object at path is in the trash. The size and
+// modified time are compared too, so that a new object uploaded to the same
+// path after the removal is not hidden.
+func isTrashed(path string, obj model.Obj) bool {
+	loadInPlaceTrash()
+	inPlaceTrash.RLock()
+	item, ok := inPlaceTrash.items[path]
+	inPlaceTrash.RUnlock()
+	return ok && item.IsDir == obj.IsDir() && item.Size == obj.GetSize() &&
+		item.Modified.Unix() == obj.ModTime().Unix()
+}
+
+func inTrashFolder(actualPath string) bool {
+	return utils.IsSubPath(stdpath.Join("/", TrashFolder), actualPath)
+}
+
+// inHiddenFolder reports whether actualPath is in the trash or versions folder and
+// the user of ctx may not access it, only the admins and the internal calls may
+func inHiddenFolder(ctx context.Context, actualPath string) bool {
+	if !inTrashFolder(actualPath) && !op.InVersionsFolder(actualPath) {
+		return false
+	}
+	user, ok := ctx.Value("user").(*model.User)
+	return ok && !user.IsAdmin()
+}
+
+// filterHidden hides the trash and versions folders and the objects removed in place from a listing
+func filterHidden(path, actualPath string, objs []model.Obj) []model.Obj {
+	res := objs[:0]
+	for _, obj := range objs {
+		if utils.PathEqual(actualPath, "/") && (obj.GetName() == TrashFolder || obj.GetName() == op.VersionsFolder) {
+			continue
+		}
+		if isTrashed(stdpath.Join(path, obj.GetName()), obj) {
+			continue
+		}
+		res = append(res, obj)
+	}
+	return res
+}
+
+func trashEnabled(ctx context.Context) bool {
+	return ctx.Value(conf.NoTrashKey) == nil && setting.GetBool(conf.TrashEnabled)
+}
+
+func canMove(storage driver.Driver) bool {
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return true
+	}
+	return false
+}
+
+func moveToTrash(ctx context.Context, storage driver.Driver, path, actualPath string) error {
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return errors.WithMessage(err, "failed to get object")
+	}
+	item := &model.TrashItem{
+		Path:      path,
+		Name:      obj.GetName(),
+		Size:      obj.GetSize(),
+		IsDir:     obj.IsDir(),
+		Modified:  obj.ModTime(),
+		RemovedAt: time.Now(),
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		item.UserID = user.ID
+	}
+	if canMove(storage) {
+		// every object gets its own folder, so that objects with the same name won't conflict
+		trashDir := stdpath.Join("/", TrashFolder, strconv.FormatInt(item.RemovedAt.UnixNano(), 10))
+		err = op.MakeDir(ctx, storage, trashDir)
+		if err == nil {
+			err = op.Move(ctx, storage, actualPath, trashDir)
+			if err == nil {
+				item.TrashPath = stdpath.Join(storage.GetStorage().MountPath, trashDir, item.Name)
+				return op.CreateTrashItem(item)
+			}
+			_ = op.Remove(ctx, storage, trashDir)
+		}
+		log.Warnf("failed move [%s] to trash, keep it in place: %+v", path, err)
+	}
+	if err = op.CreateTrashItem(item); err != nil {
+		return err
+	}
+	loadInPlaceTrash()
+	inPlaceTrash.Lock()
+	inPlaceTrash.items[item.Path] = *item
+	inPlaceTrash.Unlock()
+	op.ClearCache(storage, stdpath.Dir(actualPath))
+	return nil
+}
+
+func forgetInPlaceTrash(item *model.TrashItem) {
+	if !item.InPlace() {
+		return
+	}
+	loadInPlaceTrash()
+	inPlaceTrash.Lock()
+	defer inPlaceTrash.Unlock()
+	if cur, ok := inPlaceTrash.items[item.Path]; ok && cur.ID == item.ID {
+		delete(inPlaceTrash.items, item.Path)
+	}
+}
+
+// trashCtx lets restore and purge change the trash folder whoever the user of ctx is,
+// the owner of the item is checked by the caller
+func trashCtx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, "user", nil)
+}
+
+// RestoreTrash moves the removed object back to its original path
+func RestoreTrash(ctx context.Context, item *model.TrashItem) error {
+	if item.InPlace() {
+		forgetInPlaceTrash(item)
+		if storage, actualPath, err := op.GetStorageAndActualPath(item.Path); err == nil {
+			op.ClearCache(storage, stdpath.Dir(actualPath))
+		}
+		return op.DeleteTrashItemById(item.ID)
+	}
+	if _, err := get(ctx, item.Path); err == nil {
+		return errors.Errorf("%s already exists", item.Path)
+	} else if !errs.IsObjectNotFound(err) {
+		return err
+	}
+	dstDir := stdpath.Dir(item.Path)
+	if err := makeDir(ctx, dstDir); err != nil {
+		return errors.WithMessage(err, "failed to make the original folder")
+	}
+	if err := move(trashCtx(ctx), item.TrashPath, dstDir); err != nil {
+		return errors.WithMessage(err, "failed to move out of the trash")
+	}
+	if err := remove(context.WithValue(trashCtx(ctx), conf.NoTrashKey, struct{}{}), stdpath.Dir(item.TrashPath)); err != nil {
+		log.Warnf("failed remove trash folder of [%s]: %+v", item.Path, err)
+	}
+	return op.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrash removes the object permanently
+func PurgeTrash(ctx context.Context, item *model.TrashItem) error {
+	ctx = context.WithValue(ctx, conf.NoTrashKey, struct{}{})
+	var err error
+	if item.InPlace() {
+		// only remove it if it's still the removed one
+		var (
+			storage    driver.Driver
+			actualPath string
+			obj        model.Obj
+		)
+		storage, actualPath, err = op.GetStorageAndActualPath(item.Path)
+		if err == nil {
+			obj, err = op.Get(ctx, storage, actualPath)
+		}
+		if err == nil && isTrashed(item.Path, obj) {
+			err = op.Remove(ctx, storage, actualPath)
+		}
+	} else {
+		err = remove(trashCtx(ctx), stdpath.Dir(item.TrashPath))
+	}
+	if err != nil && !errs.IsObjectNotFound(err) && !errors.Is(err, errs.StorageNotFound) {
+		return err
+	}
+	forgetInPlaceTrash(item)
+	return op.DeleteTrashItemById(item.ID)
+}
+
+// PurgeExpiredTrash purges the objects kept in the trash longer than the retention
+func PurgeExpiredTrash() {
+	days := setting.GetInt(conf.TrashRetentionDays, 30)
+	if days <= 0 {
+		return
+	}
+	items, err := op.GetTrashItemsRemovedBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed get expired trash items: %+v", err)
+		return
+	}
+	ctx := context.Background()
+	for i := range items {
+		if err = PurgeTrash(ctx, &items[i]); err != nil {
+			log.Errorf("failed purge [%s] from trash: %+v", items[i].Path, err)
+		}
+	}
+}
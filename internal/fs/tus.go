@@ -0,0 +1,223 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TusDirName is the folder of the temp dir where resumable uploads are staged,
+// it's kept when the temp dir is cleaned at startup.
+const TusDirName = "tus"
+
+// TusUploadExpiration is how long an upload can stay without receiving any data
+const TusUploadExpiration = 7 * 24 * time.Hour
+
+// tusLocks holds a mutex per upload id, so that two requests can't write the same upload
+var tusLocks sync.Map
+
+func tusFilePath(id string) string {
+	return filepath.Join(conf.Conf.TempDir, TusDirName, id)
+}
+
+// CreateTusUpload registers the upload and creates its empty staging file
+func CreateTusUpload(u *model.TusUpload) error {
+	u.ID = uuid.NewString()
+	u.Offset = 0
+	if err := os.MkdirAll(filepath.Join(conf.Conf.TempDir, TusDirName), 0o777); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(tusFilePath(u.ID))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_ = f.Close()
+	if err = op.CreateTusUpload(u); err != nil {
+		_ = os.Remove(tusFilePath(u.ID))
+		return err
+	}
+	return nil
+}
+
+// WriteTusUpload appends the content of r at offset, which must be the current
+// offset of the upload. What has been received is kept even if r fails, so
+// that the client can resume from there. Once complete, the file is put to its
+// path, and the task is returned if it's put as a task.
+func WriteTusUpload(ctx context.Context, u *model.TusUpload, offset int64, r io.Reader) (task.TaskExtensionInfo, error) {
+	lock, _ := tusLocks.LoadOrStore(u.ID, &sync.Mutex{})
+	if !lock.(*sync.Mutex).TryLock() {
+		return nil, errors.WithStack(errs.UploadLocked)
+	}
+	defer lock.(*sync.Mutex).Unlock()
+	// reload it as another request may have written it meanwhile
+	cur, err := op.GetTusUploadById(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	*u = *cur
+	if offset != u.Offset {
+		return nil, errors.WithStack(errs.UploadOffsetMismatch)
+	}
+	if err = writeTusFile(u, r); err != nil {
+		return nil, err
+	}
+	if u.Offset < u.Size {
+		return nil, nil
+	}
+	return finishTusUpload(ctx, u)
+}
+
+func writeTusFile(u *model.TusUpload, r io.Reader) error {
+	f, err := os.OpenFile(tusFilePath(u.ID), os.O_WRONLY, 0o666)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	// drop the bytes written after the last saved offset, e.g. before a crash
+	if err = f.Truncate(u.Offset); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = f.Seek(u.Offset, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+	n, copyErr := utils.CopyWithBuffer(f, io.LimitReader(r, u.Size-u.Offset))
+	if n > 0 {
+		if err = op.UpdateTusUploadOffset(u.ID, u.Offset+n); err != nil {
+			return err
+		}
+		u.Offset += n
+	}
+	return errors.WithStack(copyErr)
+}
+
+// finishTusUpload puts the assembled file to its path and removes the upload.
+// The upload is kept if the put fails, so that the client can finish it again.
+func finishTusUpload(ctx context.Context, u *model.TusUpload) (task.TaskExtensionInfo, error) {
+	f, err := openTusPutFile(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	dir, name := stdpath.Split(u.Path)
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     u.Size,
+			Modified: u.Modified,
+			HashInfo: utils.FromString(u.Hash),
+		},
+		Mimetype:     u.Mimetype,
+		WebPutAsTask: u.AsTask,
+	}
+	// the file is removed once the stream is closed, the staging file is not
+	s.SetTmpFile(f)
+	var t task.TaskExtensionInfo
+	if u.AsTask {
+		t, err = PutAsTask(ctx, dir, s)
+	} else {
+		err = PutDirectly(ctx, dir, s, true)
+	}
+	if err != nil {
+		return t, err
+	}
+	return t, DeleteTusUpload(u)
+}
+
+// openTusPutFile opens a new link to the staging file of the upload, outside
+// the tus dir, so that the put can remove it without losing the staging file.
+func openTusPutFile(id string) (*os.File, error) {
+	name := filepath.Join(conf.Conf.TempDir, "tus-"+uuid.NewString())
+	if err := os.Link(tusFilePath(id), name); err != nil {
+		// hard links aren't supported, copy it
+		src, err := os.Open(tusFilePath(id))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer src.Close()
+		dst, err := os.Create(name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err = utils.CopyWithBuffer(dst, src); err != nil {
+			_ = dst.Close()
+			_ = os.Remove(name)
+			return nil, errors.WithStack(err)
+		}
+		_ = dst.Close()
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		_ = os.Remove(name)
+		return nil, errors.WithStack(err)
+	}
+	return f, nil
+}
+
+// DeleteTusUpload terminates the upload and removes what has been received
+func DeleteTusUpload(u *model.TusUpload) error {
+	if err := op.DeleteTusUploadById(u.ID); err != nil {
+		return err
+	}
+	tusLocks.Delete(u.ID)
+	if err := os.Remove(tusFilePath(u.ID)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// PurgeExpiredTusUploads removes the uploads left without data for too long,
+// and the staging files no upload knows.
+func PurgeExpiredTusUploads() {
+	expired, err := op.GetTusUploadsUpdatedBefore(time.Now().Add(-TusUploadExpiration))
+	if err != nil {
+		log.Errorf("failed get expired tus uploads: %+v", err)
+		return
+	}
+	for i := range expired {
+		if err = DeleteTusUpload(&expired[i]); err != nil {
+			log.Errorf("failed delete expired tus upload [%s]: %+v", expired[i].Path, err)
+		}
+	}
+	uploads, err := op.GetTusUploads()
+	if err != nil {
+		log.Errorf("failed get tus uploads: %+v", err)
+		return
+	}
+	known := make(map[string]struct{}, len(uploads))
+	for _, u := range uploads {
+		known[u.ID] = struct{}{}
+	}
+	files, err := os.ReadDir(filepath.Join(conf.Conf.TempDir, TusDirName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed list tus dir: %+v", err)
+		}
+		return
+	}
+	for _, file := range files {
+		if _, ok := known[file.Name()]; ok {
+			continue
+		}
+		// a staging file is created before its upload, leave the new ones alone
+		if info, err := file.Info(); err == nil && time.Since(info.ModTime()) < time.Hour {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(conf.Conf.TempDir, TusDirName, file.Name())); err != nil {
+			log.Errorf("failed remove tus file: %+v", err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package fs
+
+import (
+	"context"
+	"net/http"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// verifyHashTypes are the hash types a copy is verified with
+var verifyHashTypes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// verifyCopy checks that the file at dstPath has the content of srcFile.
+// A corrupted copy is removed, so that a retry doesn't skip it as existing.
+func verifyCopy(ctx context.Context, srcStorage driver.Driver, srcFilePath string, srcFile model.Obj, dstStorage driver.Driver, dstPath string) error {
+	ok, err := checkCopy(ctx, srcStorage, srcFilePath, srcFile, dstStorage, dstPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed verify [%s]", dstPath)
+	}
+	if ok {
+		return nil
+	}
+	if err := op.Remove(ctx, dstStorage, dstPath); err != nil {
+		log.Errorf("failed remove corrupted copy [%s]: %+v", dstPath, err)
+	}
+	return errors.Errorf("the copy [%s] doesn't match the source", dstPath)
+}
+
+func checkCopy(ctx context.Context, srcStorage driver.Driver, srcFilePath string, srcFile model.Obj, dstStorage driver.Driver, dstPath string) (bool, error) {
+	dstFile, err := op.Get(ctx, dstStorage, dstPath)
+	if err != nil || dstFile.GetSize() != srcFile.GetSize() {
+		// the cache may still have the previous object
+		dstFile, err = getRefreshed(ctx, dstStorage, dstPath)
+		if err != nil {
+			return false, err
+		}
+	}
+	if dstFile.GetSize() != srcFile.GetSize() {
+		return false, nil
+	}
+	// the hashes reported by the driver are trusted if they match only,
+	// as they may be stale or computed in another way
+	if equal, _ := compareHash(srcFile, dstFile); equal {
+		return true, nil
+	}
+	srcHash := srcFile.GetHash()
+	var types []*utils.HashType
+	for _, ht := range verifyHashTypes {
+		if srcHash.GetHash(ht) != "" {
+			types = append(types, ht)
+		}
+	}
+	if len(types) == 0 {
+		types = []*utils.HashType{utils.MD5}
+		h, err := hashObj(ctx, srcStorage, srcFilePath, srcFile, types)
+		if err != nil {
+			return false, errors.WithMessage(err, "failed hash the source")
+		}
+		srcHash = *h
+	}
+	dstHash, err := hashObj(ctx, dstStorage, dstPath, dstFile, types)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed hash the copy")
+	}
+	for _, ht := range types {
+		if !strings.EqualFold(srcHash.GetHash(ht), dstHash.GetHash(ht)) {
+			log.Warnf("%s of [%s] is %s, expected %s", ht.Name, dstPath, dstHash.GetHash(ht), srcHash.GetHash(ht))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func getRefreshed(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	dir, name := stdpath.Split(path)
+	objs, err := op.List(ctx, storage, dir, model.ListArgs{Refresh: true})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list [%s]", dir)
+	}
+	for _, obj := range objs {
+		if obj.GetName() == name {
+			return obj, nil
+		}
+	}
+	return nil, errors.WithStack(errs.ObjectNotFound)
+}
+
+// hashObj reads the content of obj and computes its hashes of types
+func hashObj(ctx context.Context, storage driver.Driver, path string, obj model.Obj, types []*utils.HashType) (*utils.HashInfo, error) {
+	link, _, err := op.Link(ctx, storage, path, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] link", path)
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] stream", path)
+	}
+	defer ss.Close()
+	h := utils.NewMultiHasher(types)
+	if _, err = utils.CopyWithBuffer(h, ss); err != nil {
+		return nil, errors.WithMessagef(err, "failed read [%s]", path)
+	}
+	return h.GetHashInfo(), nil
+}
@@ -0,0 +1,55 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func ListVersions(ctx context.Context, path string) ([]model.FileVersion, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	return op.ListVersions(ctx, storage, actualPath)
+}
+
+// LinkVersion returns the link of a previous version of the file at path,
+// the returned obj is named as the file.
+func LinkVersion(ctx context.Context, path, id string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get storage")
+	}
+	link, obj, err := op.LinkVersion(ctx, storage, actualPath, id, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, renamedObj(obj, stdpath.Base(path)), nil
+}
+
+func RestoreVersion(ctx context.Context, path, id string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	return op.RestoreVersion(ctx, storage, actualPath, id)
+}
+
+// PruneVersions applies the retention of the storages with versioning enabled
+func PruneVersions() {
+	ctx := context.Background()
+	for _, storage := range op.GetAllStorages() {
+		s := storage.GetStorage()
+		if !s.EnableVersioning || (s.MaxVersions <= 0 && s.MaxVersionAge <= 0) {
+			continue
+		}
+		if err := op.PruneAllVersions(ctx, storage); err != nil {
+			log.Errorf("failed prune versions of [%s]: %+v", s.MountPath, err)
+		}
+	}
+}
@@ -1,170 +1,390 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
-
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+const blockSize = 4096
+
+// Fs maps the FUSE callbacks onto the mount paths of internal/fs,
+// RootFolder is the AList path exposed as the root of the mount point.
 type Fs struct {
 	RootFolder string
+	User       *model.User
 	fuse.FileSystemBase
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	handles map[uint64]*fileHandle
+	nextFh  uint64
 }
 
 func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
+	fs.ctx, fs.cancel = context.WithCancel(context.Background())
+	if fs.User != nil {
+		fs.ctx = context.WithValue(fs.ctx, "user", fs.User)
+	}
+	fs.handles = make(map[uint64]*fileHandle)
+	fs.nextFh = 1
 }
 
 func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
+	fs.mu.Lock()
+	handles := fs.handles
+	fs.handles = make(map[uint64]*fileHandle)
+	fs.mu.Unlock()
+	for _, h := range handles {
+		if err := h.close(); err != nil {
+			log.Errorf("fuse: failed to close %s: %+v", h.path, err)
+		}
+	}
+	fs.cancel()
+}
+
+func (fs *Fs) realPath(path string) string {
+	return utils.FixAndCleanPath(stdpath.Join(fs.RootFolder, path))
+}
+
+func (fs *Fs) openHandle(h *fileHandle) uint64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fh := fs.nextFh
+	fs.nextFh++
+	fs.handles[fh] = h
+	return fh
+}
+
+func (fs *Fs) getHandle(fh uint64) *fileHandle {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.handles[fh]
+}
+
+func (fs *Fs) releaseHandle(fh uint64) *fileHandle {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	h := fs.handles[fh]
+	delete(fs.handles, fh)
+	return h
+}
+
+// openedSize returns the size of a file opened by any handle, so that
+// Getattr reports staged content which has not been uploaded yet.
+func (fs *Fs) openedSize(path string) (int64, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, h := range fs.handles {
+		if h.path != path {
+			continue
+		}
+		// doesn't lock the handle, which may be busy with a download
+		if size, ok := h.stagedSize(); ok {
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+func errno(err error) int {
+	if err == nil {
+		return 0
+	}
+	cause := errors.Cause(err)
+	switch {
+	case errors.Is(cause, errs.ObjectNotFound), errors.Is(cause, errs.StorageNotFound):
+		return -fuse.ENOENT
+	case errors.Is(cause, errs.PermissionDenied):
+		return -fuse.EACCES
+	case errors.Is(cause, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(cause, errs.NotFile):
+		return -fuse.EISDIR
+	case errors.Is(cause, errs.NotImplement), errors.Is(cause, errs.NotSupport),
+		errors.Is(cause, errs.UploadNotSupported):
+		return -fuse.ENOSYS
+	case errors.Is(cause, errs.MoveBetweenTwoStorages):
+		return -fuse.EXDEV
+	}
+	if errs.IsObjectNotFound(err) {
+		return -fuse.ENOENT
+	}
+	return -fuse.EIO
+}
+
+func fillStat(obj model.Obj, stat *fuse.Stat_t) {
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0644
+		stat.Nlink = 1
+		stat.Size = obj.GetSize()
+		stat.Blocks = (stat.Size + 511) / 512
+	}
+	stat.Blksize = blockSize
+	mtime := fuse.NewTimespec(obj.ModTime())
+	stat.Mtim = mtime
+	stat.Atim = mtime
+	stat.Ctim = fuse.NewTimespec(obj.CreateTime())
 }
 
 func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
+	stat.Bsize = blockSize
+	stat.Frsize = blockSize
+	stat.Namemax = 255
+	// the number of files isn't known
+	stat.Files = 1e9
+	stat.Ffree = 1e9
+	if details := fsStatfs(fs.ctx, fs.realPath(path)); details != nil {
+		stat.Blocks = uint64(details.TotalSpace) / blockSize
+		stat.Bfree = uint64(max(details.FreeSpace, 0)) / blockSize
+	} else {
+		// report a large free space when the capacity is unknown, so that writes aren't refused
+		stat.Blocks = (1 << 50) / blockSize
+		stat.Bfree = stat.Blocks
+	}
+	stat.Bavail = stat.Bfree
+	return 0
 }
 
 func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return errno(fsMakeDir(fs.ctx, fs.realPath(path)))
 }
 
 func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
+	return errno(fsRemove(fs.ctx, fs.realPath(path)))
 }
 
 func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
+	return errno(fsRemove(fs.ctx, fs.realPath(path)))
 }
 
 func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS, ""
 }
 
 func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	return errno(fsRename(fs.ctx, fs.realPath(oldpath), fs.realPath(newpath)))
 }
 
 func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	realPath := fs.realPath(path)
+	if err := checkWrite(fs.ctx, realPath); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	h := &fileHandle{ctx: fs.ctx, path: realPath, writable: true}
+	h.mu.Lock()
+	err := h.stage(true)
+	h.dirty = true
+	h.mu.Unlock()
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	return 0, fs.openHandle(h)
 }
 
 func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	realPath := fs.realPath(path)
+	obj, err := fsGet(fs.ctx, realPath)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	h := &fileHandle{ctx: fs.ctx, path: realPath, obj: obj}
+	if flags&fuse.O_ACCMODE != fuse.O_RDONLY {
+		if err = checkWrite(fs.ctx, realPath); err != nil {
+			return errno(err), ^uint64(0)
+		}
+		h.writable = true
+	}
+	if h.writable && flags&fuse.O_TRUNC != 0 {
+		if err = h.truncate(0); err != nil {
+			return errno(err), ^uint64(0)
+		}
+	}
+	return 0, fs.openHandle(h)
 }
 
 func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	realPath := fs.realPath(path)
+	if size, ok := fs.openedSize(realPath); ok {
+		stat.Mode = fuse.S_IFREG | 0644
+		stat.Nlink = 1
+		stat.Size = size
+		stat.Blocks = (size + 511) / 512
+		stat.Blksize = blockSize
+		now := fuse.NewTimespec(time.Now())
+		stat.Mtim, stat.Atim, stat.Ctim = now, now, now
+		return 0
+	}
+	obj, err := fsGet(fs.ctx, realPath)
+	if err != nil {
+		return errno(err)
+	}
+	fillStat(obj, stat)
+	return 0
 }
 
 func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	if h := fs.getHandle(fh); h != nil {
+		return errno(h.truncate(size))
+	}
+	// truncate without an opened handle, e.g. truncate(2)
+	realPath := fs.realPath(path)
+	obj, err := fsGet(fs.ctx, realPath)
+	if err != nil {
+		return errno(err)
+	}
+	if err = checkWrite(fs.ctx, realPath); err != nil {
+		return errno(err)
+	}
+	h := &fileHandle{ctx: fs.ctx, path: realPath, obj: obj, writable: true}
+	err = h.truncate(size)
+	if err == nil {
+		err = h.flush()
+	}
+	_ = h.close()
+	return errno(err)
 }
 
 func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h := fs.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.readAt(buff, ofst)
+	if err != nil && err != io.EOF {
+		log.Errorf("fuse: failed to read %s: %+v", h.path, err)
+		return errno(err)
+	}
+	return n
 }
 
 func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h := fs.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		log.Errorf("fuse: failed to write %s: %+v", h.path, err)
+		return errno(err)
+	}
+	return n
 }
 
 func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h := fs.getHandle(fh)
+	if h == nil {
+		return 0
+	}
+	return errno(h.flush())
 }
 
 func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h := fs.releaseHandle(fh)
+	if h == nil {
+		return 0
+	}
+	return errno(h.close())
 }
 
 func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return fs.Flush(path, fh)
 }
 
 func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	obj, err := fsGet(fs.ctx, fs.realPath(path))
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
 }
 
 func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	objs, err := fsList(fs.ctx, fs.realPath(path))
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	for _, obj := range objs {
+		stat := &fuse.Stat_t{}
+		fillStat(obj, stat)
+		if !fill(obj.GetName(), stat, 0) {
+			break
+		}
+	}
+	return 0
 }
 
 func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS, nil
 }
 
 func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
@@ -0,0 +1,143 @@
+package fuse
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// The receivers in fs.go are named fs after the FUSE convention, which shadows
+// the internal/fs package, so the calls into it are gathered here.
+
+func fsGet(ctx context.Context, path string) (model.Obj, error) {
+	if err := checkRead(ctx, path); err != nil {
+		return nil, err
+	}
+	return fs.Get(ctx, path, &fs.GetArgs{NoLog: true})
+}
+
+// fsList lists the folder at path, leaving out the objs the user may not access
+func fsList(ctx context.Context, path string) ([]model.Obj, error) {
+	if err := checkRead(ctx, path); err != nil {
+		return nil, err
+	}
+	objs, err := fs.List(ctx, path, &fs.ListArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Value("user").(*model.User); !ok {
+		return objs, nil
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if checkRead(ctx, stdpath.Join(path, obj.GetName())) == nil {
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+// The permissions of the user of the mount are checked the same way as by the FTP server,
+// nothing is checked if the mount has no user.
+
+func checkPerm(ctx context.Context, path string, bits ...uint) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok {
+		return nil
+	}
+	perm := common.MergeRolePermissions(user, path)
+	for _, bit := range bits {
+		if !common.HasPermission(perm, bit) {
+			return errs.PermissionDenied
+		}
+	}
+	return nil
+}
+
+// checkRead checks that the user may access the obj at path like the FTP server does,
+// the hidden objs are denied as well as the ones protected by a password as the mount can't give it
+func checkRead(ctx context.Context, path string) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok {
+		return nil
+	}
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return err
+	}
+	if !common.CanAccessWithRoles(user, meta, path, "") {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+// checkWrite checks that the user may create or overwrite the obj at path,
+// either by its roles or by the meta of the folder
+func checkWrite(ctx context.Context, path string) error {
+	if checkPerm(ctx, path, common.PermWrite) == nil {
+		return nil
+	}
+	meta, err := op.GetNearestMeta(stdpath.Dir(path))
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return err
+	}
+	if !common.CanWrite(meta, path) {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+func fsMakeDir(ctx context.Context, path string) error {
+	if err := checkWrite(ctx, path); err != nil {
+		return err
+	}
+	return fs.MakeDir(ctx, path)
+}
+
+func fsRemove(ctx context.Context, path string) error {
+	if err := checkPerm(ctx, path, common.PermRemove); err != nil {
+		return err
+	}
+	return fs.Remove(ctx, path)
+}
+
+func fsRename(ctx context.Context, srcPath, dstPath string) error {
+	srcDir, srcBase := stdpath.Split(srcPath)
+	dstDir, dstBase := stdpath.Split(dstPath)
+	if srcDir == dstDir {
+		if err := checkPerm(ctx, srcPath, common.PermRename); err != nil {
+			return err
+		}
+		return fs.Rename(ctx, srcPath, dstBase)
+	}
+	bits := []uint{common.PermMove}
+	if srcBase != dstBase {
+		bits = append(bits, common.PermRename)
+	}
+	if err := checkPerm(ctx, srcPath, bits...); err != nil {
+		return err
+	}
+	// a move between storages fails with EXDEV, so that the caller copies and removes it itself
+	if err := fs.Move(ctx, srcPath, dstDir); err != nil {
+		return err
+	}
+	if srcBase != dstBase {
+		return fs.Rename(ctx, stdpath.Join(dstDir, srcBase), dstBase)
+	}
+	return nil
+}
+
+// fsStatfs returns the capacity of the storage the path is in, nil if it's unknown
+func fsStatfs(ctx context.Context, path string) *model.StorageDetails {
+	details, err := fs.GetStorageDetails(ctx, path)
+	if err != nil || details.TotalSpace <= 0 {
+		return nil
+	}
+	return details
+}
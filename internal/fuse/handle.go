@@ -0,0 +1,282 @@
+package fuse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/pkg/errors"
+)
+
+// fileHandle is an opened file. Reads are served from the remote link with
+// ranged requests until the file is written, after which the content is staged
+// in a local temp file and uploaded back on flush.
+// mu guards the local content and is never held during network I/O, the remote
+// reader is guarded by readMu, the staging by stageMu and the uploads are serialized by flushMu.
+type fileHandle struct {
+	mu       sync.Mutex
+	readMu   sync.Mutex
+	stageMu  sync.Mutex
+	flushMu  sync.Mutex
+	ctx      context.Context
+	path     string
+	obj      model.Obj
+	writable bool
+	reader   stream.SStreamReadAtSeeker
+	buffer   *os.File
+	dirty    bool
+	// staged and size mirror the local content so that they can be read without mu
+	staged atomic.Bool
+	size   atomic.Int64
+}
+
+func (h *fileHandle) readAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	if h.buffer != nil {
+		defer h.mu.Unlock()
+		return h.buffer.ReadAt(p, off)
+	}
+	obj := h.obj
+	h.mu.Unlock()
+	if obj == nil || off >= obj.GetSize() {
+		return 0, io.EOF
+	}
+	return h.readRemote(p, off)
+}
+
+func (h *fileHandle) readRemote(p []byte, off int64) (int, error) {
+	h.readMu.Lock()
+	if h.staged.Load() {
+		// staged while waiting for the reader, mu must not be taken while holding readMu
+		h.readMu.Unlock()
+		return h.readAt(p, off)
+	}
+	defer h.readMu.Unlock()
+	if h.reader == nil {
+		link, obj, err := fs.Link(h.ctx, h.path, model.LinkArgs{Header: http.Header{}})
+		if err != nil {
+			return 0, err
+		}
+		ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: h.ctx}, link)
+		if err != nil {
+			return 0, err
+		}
+		reader, err := stream.NewReadAtSeeker(ss, 0)
+		if err != nil {
+			_ = ss.Close()
+			return 0, err
+		}
+		h.reader = reader
+	}
+	n, err := h.reader.ReadAt(p, off)
+	if err != nil {
+		return n, err
+	}
+	err = stream.ClientDownloadLimit.WaitN(h.ctx, n)
+	return n, err
+}
+
+// stage makes sure the content is held in the local temp file, downloading the
+// current remote content first unless trunc is set. It's called without mu,
+// so that the handle can still be read from the remote while downloading.
+func (h *fileHandle) stage(trunc bool) error {
+	if h.staged.Load() {
+		return nil
+	}
+	h.stageMu.Lock()
+	defer h.stageMu.Unlock()
+	if h.staged.Load() {
+		return nil
+	}
+	h.mu.Lock()
+	obj := h.obj
+	h.mu.Unlock()
+	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return err
+	}
+	var size int64
+	if !trunc && obj != nil && obj.GetSize() > 0 {
+		link, obj, err := fs.Link(h.ctx, h.path, model.LinkArgs{Header: http.Header{}})
+		if err == nil {
+			var ss *stream.SeekableStream
+			ss, err = stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: h.ctx}, link)
+			if err == nil {
+				size, err = io.Copy(tmpFile, ss)
+				_ = ss.Close()
+			}
+		}
+		if err != nil {
+			_ = tmpFile.Close()
+			_ = os.Remove(tmpFile.Name())
+			return errors.WithMessage(err, "failed to stage remote content")
+		}
+	}
+	h.mu.Lock()
+	h.buffer = tmpFile
+	h.size.Store(size)
+	h.staged.Store(true)
+	h.mu.Unlock()
+	h.closeReader()
+	return nil
+}
+
+// closeReader closes the remote reader, waiting for the read in progress
+func (h *fileHandle) closeReader() {
+	h.readMu.Lock()
+	defer h.readMu.Unlock()
+	if h.reader != nil {
+		_ = h.reader.Close()
+		h.reader = nil
+	}
+}
+
+func (h *fileHandle) writeAt(p []byte, off int64) (int, error) {
+	if !h.writable {
+		return 0, errs.PermissionDenied
+	}
+	if err := h.stage(false); err != nil {
+		return 0, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.buffer == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := h.buffer.WriteAt(p, off)
+	if n > 0 {
+		h.dirty = true
+		if end := off + int64(n); end > h.size.Load() {
+			h.size.Store(end)
+		}
+	}
+	if err != nil {
+		return n, err
+	}
+	err = stream.ClientUploadLimit.WaitN(h.ctx, n)
+	return n, err
+}
+
+func (h *fileHandle) truncate(size int64) error {
+	if !h.writable {
+		return errs.PermissionDenied
+	}
+	if err := h.stage(size == 0); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.buffer == nil {
+		return os.ErrClosed
+	}
+	if err := h.buffer.Truncate(size); err != nil {
+		return err
+	}
+	h.dirty = true
+	h.size.Store(size)
+	return nil
+}
+
+// stagedSize reports the size of the local content if the file is staged.
+func (h *fileHandle) stagedSize() (int64, bool) {
+	if !h.staged.Load() {
+		return 0, false
+	}
+	return h.size.Load(), true
+}
+
+// snapshot copies the staged content to a new temp file to be uploaded,
+// so that the handle can be read and written during the upload.
+func (h *fileHandle) snapshot() (*os.File, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty || h.buffer == nil {
+		return nil, nil
+	}
+	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(tmpFile, io.NewSectionReader(h.buffer, 0, h.size.Load())); err == nil {
+		_, err = tmpFile.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return nil, err
+	}
+	h.dirty = false
+	return tmpFile, nil
+}
+
+// flush uploads the staged content if it has been changed since the last flush.
+func (h *fileHandle) flush() error {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+	file, err := h.snapshot()
+	if err != nil || file == nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(h.path)
+	s := &stream.FileStream{
+		Ctx: h.ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		Mimetype: http.DetectContentType(head[:n]),
+		Reader:   file,
+	}
+	err = fs.PutDirectly(h.ctx, dir, s, true)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		// upload it again on the next flush
+		h.dirty = true
+		return err
+	}
+	h.obj = s.Obj
+	return nil
+}
+
+func (h *fileHandle) close() error {
+	err := h.flush()
+	h.closeReader()
+	// wait for a staging in progress so that its temp file is removed too
+	h.stageMu.Lock()
+	defer h.stageMu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.buffer != nil {
+		_ = h.buffer.Close()
+		_ = os.Remove(h.buffer.Name())
+		h.buffer = nil
+	}
+	h.staged.Store(false)
+	return err
+}
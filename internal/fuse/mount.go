@@ -1,9 +1,19 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/winfsp/cgofuse/fuse"
+)
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+// Mount exposes mountSrc of the AList tree at mountDst in background,
+// the returned channel receives the result once the file system is unmounted
+// or fails to mount.
+func Mount(mountSrc, mountDst string, user *model.User, opts []string) (*fuse.FileSystemHost, <-chan bool) {
+	fs := &Fs{RootFolder: mountSrc, User: user}
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	done := make(chan bool, 1)
+	go func() {
+		done <- host.Mount(mountDst, opts)
+	}()
+	return host, done
 }
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// DedupEntry is a file or a folder of a dedup pool storage, see drivers/dedup.
+// The content of a file is made of the chunks listed by DedupFileChunk.
+type DedupEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StorageID uint      `json:"storage_id" gorm:"uniqueIndex:idx_dedup_entry"`
+	ParentID  uint      `json:"parent_id" gorm:"uniqueIndex:idx_dedup_entry"` // 0 for the root folder
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_dedup_entry"`
+	IsDir     bool      `json:"is_dir"`
+	Size      int64     `json:"size"`
+	Hash      string    `json:"hash"` // sha256 of the content
+	Modified  time.Time `json:"modified"`
+	Created   time.Time `json:"created"`
+}
+
+// DedupChunk is a chunk of content stored once by a dedup pool storage,
+// named by its sha256 under the Remote mount path
+type DedupChunk struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	StorageID uint   `json:"storage_id" gorm:"uniqueIndex:idx_dedup_chunk"`
+	Hash      string `json:"hash" gorm:"uniqueIndex:idx_dedup_chunk"`
+	Size      int64  `json:"size"`
+	Remote    string `json:"remote"`
+}
+
+// DedupFileChunk is the Seq-th chunk of the file EntryID, starting from 0
+type DedupFileChunk struct {
+	EntryID   uint   `json:"entry_id" gorm:"primaryKey;autoIncrement:false"`
+	Seq       int    `json:"seq" gorm:"primaryKey;autoIncrement:false"`
+	StorageID uint   `json:"storage_id" gorm:"index"`
+	Hash      string `json:"hash" gorm:"index"`
+	Offset    int64  `json:"offset"`
+	Size      int64  `json:"size"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// EncryptionKey encrypts the files of the storages with encryption enabled, see Encryption.
+// The password and the salt are obscured the same way as in the config of rclone.
+type EncryptionKey struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Name     string    `json:"name" gorm:"unique" binding:"required"`
+	Password string    `json:"-"`
+	Salt     string    `json:"-"`
+	Created  time.Time `json:"created"`
+}
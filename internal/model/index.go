@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// IndexCursor is the position in the change feed of a storage which the index is updated to
+type IndexCursor struct {
+	StorageID uint      `json:"storage_id" gorm:"primaryKey;autoIncrement:false"`
+	Cursor    string    `json:"cursor" gorm:"type:text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IndexedDir is the version of a folder when its entries were last indexed, a folder which
+// hasn't changed since is skipped by the incremental update of the index
+type IndexedDir struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Path     string    `json:"path" gorm:"uniqueIndex"`
+	Modified time.Time `json:"modified"`
+	Hash     string    `json:"hash"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// S3AccessKey is an access key pair of the S3 server, the requests signed with it
+// are authorized as the user it belongs to.
+type S3AccessKey struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	UserId          uint      `json:"-"`
+	Title           string    `json:"title"`
+	AccessKeyId     string    `json:"access_key_id" gorm:"uniqueIndex;size:64"`
+	SecretAccessKey string    `json:"-"`
+	AddedTime       time.Time `json:"added_time"`
+	LastUsedTime    time.Time `json:"last_used_time"`
+}
+
+func (k *S3AccessKey) UpdateLastUsedTime() {
+	k.LastUsedTime = time.Now()
+}
@@ -0,0 +1,11 @@
+package model
+
+// ScrubResult is the result of checking the redundancy of the files of a storage
+type ScrubResult struct {
+	Files int `json:"files"`
+	// the files missing some of their copies or shards
+	Degraded int `json:"degraded"`
+	Repaired int `json:"repaired"`
+	// the paths of the files which can't be recovered anymore
+	Lost []string `json:"lost"`
+}
@@ -2,7 +2,10 @@ package model
 
 import (
 	"fmt"
+	"slices"
 	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type IndexProgress struct {
@@ -17,14 +20,60 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	SearchFilter
+	// name, size or modified
+	OrderBy string `json:"order_by"`
+	// asc or desc
+	OrderDirection string `json:"order_direction"`
 	PageReq
 }
 
+// SearchFilter narrows the search results down, the size and ext filters only match files
+type SearchFilter struct {
+	MinSize       *int64     `json:"min_size"`
+	MaxSize       *int64     `json:"max_size"`
+	ModifiedAfter *time.Time `json:"modified_after"`
+	// exclusive
+	ModifiedBefore *time.Time `json:"modified_before"`
+	// in lower case without the dot
+	Exts []string `json:"exts"`
+	// see conf.FOLDER, conf.VIDEO...
+	Types []int `json:"types"`
+}
+
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent   string    `json:"parent" gorm:"index"`
+	Name     string    `json:"name"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified" gorm:"index"`
+	// in lower case without the dot, empty for the folders
+	Ext string `json:"ext" gorm:"index"`
+	// see conf.FOLDER, conf.VIDEO...
+	ObjType int    `json:"obj_type"`
+	Hash    string `json:"hash"`
+	// the text extracted from the file, only indexed by the searchers searching the content
+	Content string `json:"content,omitempty" gorm:"-"`
+	// the part of the content matching the keywords in html, the matches are in <mark>
+	Snippet string `json:"snippet,omitempty" gorm:"-"`
+}
+
+func NewSearchNode(parent string, obj Obj) SearchNode {
+	node := SearchNode{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		ObjType:  utils.GetObjType(obj.GetName(), obj.IsDir()),
+	}
+	if !obj.IsDir() {
+		node.Ext = utils.Ext(obj.GetName())
+		if hash := obj.GetHash(); len(hash.Export()) > 0 {
+			node.Hash = hash.String()
+		}
+	}
+	return node
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,9 +83,38 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	if !slices.Contains([]string{"", "name", "size", "modified"}, p.OrderBy) {
+		return fmt.Errorf("can't order by %s", p.OrderBy)
+	}
+	if !slices.Contains([]string{"", "asc", "desc"}, p.OrderDirection) {
+		return fmt.Errorf("invalid order direction %s", p.OrderDirection)
+	}
 	return nil
 }
 
+// FilesOnly reports whether only the files can match the filter
+func (f *SearchFilter) FilesOnly() bool {
+	return f.MinSize != nil || f.MaxSize != nil || len(f.Exts) > 0
+}
+
+// Match reports whether the node matches the filter, for the searchers filtering the nodes themselves
+func (f *SearchFilter) Match(node *SearchNode) bool {
+	if node.IsDir && f.FilesOnly() {
+		return false
+	}
+	if (f.MinSize != nil && node.Size < *f.MinSize) || (f.MaxSize != nil && node.Size > *f.MaxSize) {
+		return false
+	}
+	if (f.ModifiedAfter != nil && node.Modified.Before(*f.ModifiedAfter)) ||
+		(f.ModifiedBefore != nil && !node.Modified.Before(*f.ModifiedBefore)) {
+		return false
+	}
+	if len(f.Exts) > 0 && !slices.Contains(f.Exts, node.Ext) {
+		return false
+	}
+	return len(f.Types) == 0 || slices.Contains(f.Types, node.ObjType)
+}
+
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
@@ -1,21 +1,34 @@
 package model
 
-import "time"
+import (
+	"path"
+	"strings"
+	"time"
+)
 
 type Share struct {
-	ID            uint       `json:"id" gorm:"primaryKey"`
-	ShareID       string     `json:"share_id" gorm:"uniqueIndex;size:32;not null"`
-	CreatorID     uint       `json:"creator_id" gorm:"index;not null"`
-	Name          string     `json:"name" gorm:"size:255;not null"`
-	RootPath      string     `json:"root_path" gorm:"size:4096;not null"`
-	IsDir         bool       `json:"is_dir"`
-	PasswordHash  string     `json:"-" gorm:"size:64"`
-	PasswordSalt  string     `json:"-" gorm:"size:32"`
-	BurnAfterRead bool       `json:"burn_after_read" gorm:"default:false"`
-	AccessLimit   int64      `json:"access_limit"`
-	AccessCount   int64      `json:"access_count"`
-	AllowPreview  bool       `json:"allow_preview" gorm:"default:true"`
-	AllowDownload bool       `json:"allow_download" gorm:"default:true"`
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	ShareID       string `json:"share_id" gorm:"uniqueIndex;size:32;not null"`
+	CreatorID     uint   `json:"creator_id" gorm:"index;not null"`
+	Name          string `json:"name" gorm:"size:255;not null"`
+	RootPath      string `json:"root_path" gorm:"size:4096;not null"`
+	IsDir         bool   `json:"is_dir"`
+	PasswordHash  string `json:"-" gorm:"size:64"`
+	PasswordSalt  string `json:"-" gorm:"size:32"`
+	BurnAfterRead bool   `json:"burn_after_read" gorm:"default:false"`
+	AccessLimit   int64  `json:"access_limit"`
+	AccessCount   int64  `json:"access_count"`
+	AllowPreview  bool   `json:"allow_preview" gorm:"default:true"`
+	AllowDownload bool   `json:"allow_download" gorm:"default:true"`
+	// AllowUpload lets the recipients upload files into a shared folder, with the permissions of the creator
+	AllowUpload       bool   `json:"allow_upload"`
+	UploadMaxSize     int64  `json:"upload_max_size"`     // in bytes, 0 for no limit
+	UploadAllowedExts string `json:"upload_allowed_exts"` // comma separated extensions without dot, empty for any
+	UploadQuota       int64  `json:"upload_quota"`        // total bytes of the uploads, 0 for no limit
+	UploadedSize      int64  `json:"uploaded_size"`
+	UploadCount       int64  `json:"upload_count"`
+	// DropBox hides the content of the share, the recipients can only upload
+	DropBox       bool       `json:"drop_box"`
 	Enabled       bool       `json:"enabled" gorm:"default:true;index"`
 	ViewCount     int64      `json:"view_count"`
 	DownloadCount int64      `json:"download_count"`
@@ -60,3 +73,17 @@ func (s Share) IsConsumed() bool {
 func (s Share) IsExpired(now time.Time) bool {
 	return s.ExpiresAt != nil && !s.ExpiresAt.After(now)
 }
+
+// UploadExtAllowed reports whether a file named name can be uploaded to the share
+func (s Share) UploadExtAllowed(name string) bool {
+	if strings.TrimSpace(s.UploadAllowedExts) == "" {
+		return true
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	for _, allowed := range strings.Split(s.UploadAllowedExts, ",") {
+		if strings.ToLower(strings.TrimPrefix(strings.TrimSpace(allowed), ".")) == ext && ext != "" {
+			return true
+		}
+	}
+	return false
+}
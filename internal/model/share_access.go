@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	ShareActionView     = "view"
+	ShareActionDownload = "download"
+	ShareActionPreview  = "preview"
+	ShareActionUpload   = "upload"
+)
+
+// ShareAccessLog is a view, download, preview or upload of a share
+type ShareAccessLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ShareID   string    `json:"share_id" gorm:"index;size:32;not null"`
+	Action    string    `json:"action" gorm:"size:16"`
+	Path      string    `json:"path" gorm:"size:4096"` // within the share
+	IP        string    `json:"ip"`                    // masked
+	UserAgent string    `json:"user_agent" gorm:"size:512"`
+	Bytes     int64     `json:"bytes"` // requested, a range request only counts the range
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// ShareAccessDaily is the rollup of the access logs of a share in a day
+type ShareAccessDaily struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ShareID   string `json:"share_id" gorm:"uniqueIndex:idx_share_access_daily;size:32;not null"`
+	Date      string `json:"date" gorm:"uniqueIndex:idx_share_access_daily;size:10"` // 2006-01-02 in local time
+	Views     int64  `json:"views"`
+	Downloads int64  `json:"downloads"`
+	Previews  int64  `json:"previews"`
+	Uploads   int64  `json:"uploads"`
+	Bytes     int64  `json:"bytes"`
+	UniqueIPs int64  `json:"unique_ips"`
+}
@@ -19,6 +19,8 @@ type Storage struct {
 	EnableSign      bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	Versioning
+	Encryption
 }
 
 type Sort struct {
@@ -35,6 +37,23 @@ type Proxy struct {
 	DownProxySign bool   `json:"down_proxy_sign" gorm:"default:true"`
 }
 
+// Versioning keeps the previous content of the files overwritten in the storage
+type Versioning struct {
+	EnableVersioning bool `json:"enable_versioning"`
+	MaxVersions      int  `json:"max_versions"`    // versions kept per file, 0 for no limit
+	MaxVersionAge    int  `json:"max_version_age"` // in days, 0 for no limit
+}
+
+// Encryption encrypts the content, and optionally the names, of the files put in the storage
+// with the key EncryptionKeyId. The files encrypted with a previous key stay readable until
+// they are re-encrypted, and so do the encrypted files if encryption is disabled while the
+// key is kept.
+type Encryption struct {
+	EnableEncryption bool `json:"enable_encryption"`
+	EncryptionKeyId  uint `json:"encryption_key_id"`
+	EncryptNames     bool `json:"encrypt_names"`
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
@@ -58,3 +77,12 @@ func (p Proxy) WebdavProxy() bool {
 func (p Proxy) WebdavNative() bool {
 	return !p.Webdav302() && !p.WebdavProxy()
 }
+
+// StorageDetails is the capacity of a storage in bytes
+type StorageDetails struct {
+	TotalSpace int64 `json:"total_space"`
+	UsedSpace  int64 `json:"used_space"`
+	FreeSpace  int64 `json:"free_space"`
+	// UsedSpaceTruncated is set when only part of the storage was counted, the UsedSpace is then a lower bound
+	UsedSpaceTruncated bool `json:"used_space_truncated,omitempty"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+const (
+	SyncOneWay = "one_way"
+	SyncTwoWay = "two_way"
+)
+
+// SyncJob keeps the folder at DstPath in sync with SrcPath.
+// Two-way jobs remove from a side what was removed from the other side since
+// their last run, as told by their SyncState, so DeleteExtraneous only applies to one-way jobs.
+type SyncJob struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	Name             string     `json:"name"`
+	SrcPath          string     `json:"src_path" gorm:"size:4096" binding:"required"`
+	DstPath          string     `json:"dst_path" gorm:"size:4096" binding:"required"`
+	Mode             string     `json:"mode"`
+	DeleteExtraneous bool       `json:"delete_extraneous"`
+	Cron             string     `json:"cron"` // empty to run manually only
+	Disabled         bool       `json:"disabled"`
+	CreatorID        uint       `json:"creator_id"`
+	LastRunAt        *time.Time `json:"last_run_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// SyncState is what a two-way sync job found on both sides at the end of its last run,
+// so that a file removed from one side is told apart from a file added to the other side
+type SyncState struct {
+	JobID   uint   `json:"job_id" gorm:"primaryKey;autoIncrement:false"`
+	SrcPath string `json:"src_path" gorm:"size:4096"`
+	DstPath string `json:"dst_path" gorm:"size:4096"`
+	// Entries is the json of the objects found on both sides keyed by their path relative to the synced folders
+	Entries   string    `json:"entries" gorm:"type:text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
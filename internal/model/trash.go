@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// TrashItem is an object removed while the recycle bin is enabled.
+// TrashPath is the path the object has been moved to, it's empty when the
+// storage can't move objects and the object is only hidden in place.
+type TrashItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Path      string    `json:"path" gorm:"size:4096;not null"`
+	TrashPath string    `json:"trash_path" gorm:"size:4096"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	IsDir     bool      `json:"is_dir"`
+	Modified  time.Time `json:"modified"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	RemovedAt time.Time `json:"removed_at" gorm:"index"`
+}
+
+func (t TrashItem) InPlace() bool {
+	return t.TrashPath == ""
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TusUpload is the state of a resumable upload, the bytes received so far
+// are staged in a file of the temp dir named by the id.
+type TusUpload struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:64"`
+	Path      string    `json:"path" gorm:"size:4096;not null"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	Mimetype  string    `json:"mimetype"`
+	Hash      string    `json:"hash"`
+	Modified  time.Time `json:"modified"`
+	AsTask    bool      `json:"as_task"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// FileVersion is a previous content of a file, kept when it was overwritten
+type FileVersion struct {
+	ID       string    `json:"id"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// WebdavProp is a dead property of a webdav resource, set by the clients with PROPPATCH
+type WebdavProp struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Path     string `json:"path" gorm:"index"`
+	Space    string `json:"space"`
+	Local    string `json:"local"`
+	Lang     string `json:"lang"`
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
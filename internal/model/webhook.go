@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Webhook receives the events of the types in Events, all the events if Events is empty
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url" gorm:"size:4096" binding:"required"`
+	Secret    string    `json:"secret"` // the payloads are signed with it if not empty
+	Events    string    `json:"events"` // comma separated event types
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is an event sent or to be sent to a webhook
+type WebhookDelivery struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	WebhookID     uint      `json:"webhook_id" gorm:"index"`
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Payload       string    `json:"payload" gorm:"type:text"`
+	Attempts      int       `json:"attempts"`
+	StatusCode    int       `json:"status_code"`
+	Response      string    `json:"response" gorm:"type:text"`
+	Error         string    `json:"error"`
+	State         string    `json:"state" gorm:"index"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"index"`
+}
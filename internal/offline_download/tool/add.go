@@ -85,28 +85,30 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 	tempDir := filepath.Join(conf.Conf.TempDir, args.Tool, uid)
 	deletePolicy := args.DeletePolicy
 
+	// the files downloaded by the cloud itself can't be encrypted, so they are transferred
+	direct := !storage.GetStorage().EnableEncryption
 	// 如果当前 storage 是对应网盘，则直接下载到目标路径，无需转存
 	switch args.Tool {
 	case "115 Cloud":
-		if _, ok := storage.(*_115.Pan115); ok {
+		if _, ok := storage.(*_115.Pan115); ok && direct {
 			tempDir = args.DstDirPath
 		} else {
 			tempDir = filepath.Join(setting.GetStr(conf.Pan115TempDir), uid)
 		}
 	case "PikPak":
-		if _, ok := storage.(*pikpak.PikPak); ok {
+		if _, ok := storage.(*pikpak.PikPak); ok && direct {
 			tempDir = args.DstDirPath
 		} else {
 			tempDir = filepath.Join(setting.GetStr(conf.PikPakTempDir), uid)
 		}
 	case "Thunder":
-		if _, ok := storage.(*thunder.Thunder); ok {
+		if _, ok := storage.(*thunder.Thunder); ok && direct {
 			tempDir = args.DstDirPath
 		} else {
 			tempDir = filepath.Join(setting.GetStr(conf.ThunderTempDir), uid)
 		}
 	case Open123ToolName:
-		if _, ok := storage.(*_123Open.Open123); ok {
+		if _, ok := storage.(*_123Open.Open123); ok && direct {
 			tempDir = args.DstDirPath
 		} else {
 			tempBase := setting.GetStr(conf.Open123TempDir)
@@ -116,7 +118,7 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 			tempDir = filepath.Join(tempBase, uid)
 		}
 	case "GuangYaPan":
-		if _, ok := storage.(*guangyapan.GuangYaPan); ok {
+		if _, ok := storage.(*guangyapan.GuangYaPan); ok && direct {
 			tempDir = args.DstDirPath
 		} else {
 			tempBase := setting.GetStr(conf.GuangYaPanTempDir)
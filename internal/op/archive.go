@@ -12,7 +12,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/archive/tool"
 	"github.com/alist-org/alist/v3/internal/stream"
 
-	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -22,7 +22,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var archiveMetaCache = cache.NewMemCache(cache.WithShards[*model.ArchiveMetaProvider](64))
+var archiveMetaCache = cache.NewCache[*model.ArchiveMetaProvider]("archive_meta", archiveMetaCodec{}, 64)
 var archiveMetaG singleflight.Group[*model.ArchiveMetaProvider]
 
 func GetArchiveMeta(ctx context.Context, storage driver.Driver, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
@@ -129,7 +129,7 @@ func GetArchiveToolAndStream(ctx context.Context, storage driver.Driver, path st
 
 func getArchiveMeta(ctx context.Context, storage driver.Driver, path string, args model.ArchiveMetaArgs) (model.Obj, *model.ArchiveMetaProvider, error) {
 	storageAr, ok := storage.(driver.ArchiveReader)
-	if ok {
+	if ok && !decodesNames(storage) {
 		obj, err := GetUnwrap(ctx, storage, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed to get file")
@@ -181,7 +181,7 @@ func getArchiveMeta(ctx context.Context, storage driver.Driver, path string, arg
 	return obj, archiveMetaProvider, err
 }
 
-var archiveListCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
+var archiveListCache = cache.NewCache[[]model.Obj]("archive_list", objsCodec{}, 64)
 var archiveListG singleflight.Group[[]model.Obj]
 
 func ListArchive(ctx context.Context, storage driver.Driver, path string, args model.ArchiveListArgs) ([]model.Obj, error) {
@@ -235,7 +235,7 @@ func ListArchive(ctx context.Context, storage driver.Driver, path string, args m
 
 func _listArchive(ctx context.Context, storage driver.Driver, path string, args model.ArchiveListArgs) (model.Obj, []model.Obj, error) {
 	storageAr, ok := storage.(driver.ArchiveReader)
-	if ok {
+	if ok && !decodesNames(storage) {
 		obj, err := GetUnwrap(ctx, storage, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed to get file")
@@ -342,7 +342,7 @@ func ArchiveGet(ctx context.Context, storage driver.Driver, path string, args mo
 	if af.IsDir() {
 		return nil, nil, errors.WithStack(errs.NotFile)
 	}
-	if g, ok := storage.(driver.ArchiveGetter); ok {
+	if g, ok := storage.(driver.ArchiveGetter); ok && !decodesNames(storage) {
 		obj, err := g.ArchiveGet(ctx, af, args.ArchiveInnerArgs)
 		if err == nil {
 			return af, model.WrapObjName(obj), nil
@@ -382,7 +382,7 @@ type extractLink struct {
 	Obj  model.Obj
 }
 
-var extractCache = cache.NewMemCache(cache.WithShards[*extractLink](16))
+var extractCache = cache.NewCache[*extractLink]("extract", extractLinkCodec{}, 16)
 var extractG singleflight.Group[*extractLink]
 
 func DriverExtract(ctx context.Context, storage driver.Driver, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
@@ -424,7 +424,7 @@ func DriverExtract(ctx context.Context, storage driver.Driver, path string, args
 
 func driverExtract(ctx context.Context, storage driver.Driver, path string, args model.ArchiveInnerArgs) (*extractLink, error) {
 	storageAr, ok := storage.(driver.ArchiveReader)
-	if !ok {
+	if !ok || decodesNames(storage) {
 		return nil, errs.DriverExtractNotSupported
 	}
 	archiveFile, extracted, err := ArchiveGet(ctx, storage, path, model.ArchiveListArgs{
@@ -482,6 +482,10 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	// the driver would decompress the encrypted content
+	if decodesNames(storage) {
+		return errs.NotImplement
+	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	srcObj, err := GetUnwrap(ctx, storage, srcPath)
@@ -500,7 +504,7 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 		if err == nil {
 			if newObjs != nil && len(newObjs) > 0 {
 				for _, newObj := range newObjs {
-					addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+					addCacheObj(storage, dstDirPath, wrapObj(storage, newObj))
 				}
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
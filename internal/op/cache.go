@@ -0,0 +1,245 @@
+package op
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cache"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// restoredObj marks an object loaded back from the persistent cache.
+// It only carries the generic fields, so it must not be passed to drivers,
+// which usually expect their own obj types, see GetUnwrap.
+type restoredObj struct {
+	model.Obj
+}
+
+func (o *restoredObj) Unwrap() model.Obj {
+	return o.Obj
+}
+
+type storedObj struct {
+	ID           string       `json:"id,omitempty"`
+	Path         string       `json:"path,omitempty"`
+	Name         string       `json:"name"`
+	Size         int64        `json:"size"`
+	Modified     time.Time    `json:"modified"`
+	Ctime        time.Time    `json:"ctime"`
+	IsFolder     bool         `json:"is_folder"`
+	Hash         string       `json:"hash,omitempty"`
+	Thumb        string       `json:"thumb,omitempty"`
+	URL          string       `json:"url,omitempty"`
+	StorageClass string       `json:"storage_class,omitempty"`
+	Children     []*storedObj `json:"children,omitempty"`
+}
+
+func toStoredObj(obj model.Obj) *storedObj {
+	s := &storedObj{
+		ID:       obj.GetID(),
+		Path:     obj.GetPath(),
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		Hash:     obj.GetHash().String(),
+	}
+	s.Thumb, _ = model.GetThumb(obj)
+	s.URL, _ = model.GetUrl(obj)
+	s.StorageClass, _ = model.GetStorageClass(obj)
+	if tree, ok := obj.(model.ObjTree); ok {
+		for _, child := range tree.GetChildren() {
+			s.Children = append(s.Children, toStoredObj(child))
+		}
+	}
+	return s
+}
+
+func (s *storedObj) object() model.Object {
+	return model.Object{
+		ID:       s.ID,
+		Path:     s.Path,
+		Name:     s.Name,
+		Size:     s.Size,
+		Modified: s.Modified,
+		Ctime:    s.Ctime,
+		IsFolder: s.IsFolder,
+		HashInfo: utils.FromString(s.Hash),
+	}
+}
+
+func (s *storedObj) toObj() model.Obj {
+	var obj model.Obj
+	switch {
+	case s.Thumb != "" && s.URL != "":
+		obj = &model.ObjThumbURL{Object: s.object(), Thumbnail: model.Thumbnail{Thumbnail: s.Thumb}, Url: model.Url{Url: s.URL}}
+	case s.Thumb != "":
+		obj = &model.ObjThumb{Object: s.object(), Thumbnail: model.Thumbnail{Thumbnail: s.Thumb}}
+	case s.URL != "":
+		obj = &model.ObjectURL{Object: s.object(), Url: model.Url{Url: s.URL}}
+	default:
+		o := s.object()
+		obj = &o
+	}
+	return &restoredObj{Obj: model.WrapObjStorageClass(obj, s.StorageClass)}
+}
+
+func (s *storedObj) toObjTree() model.ObjTree {
+	t := &model.ObjectTree{Object: s.object()}
+	for _, child := range s.Children {
+		t.Children = append(t.Children, child.toObjTree())
+	}
+	return t
+}
+
+type objsCodec struct{}
+
+func (objsCodec) Encode(objs []model.Obj) ([]byte, error) {
+	stored := make([]*storedObj, len(objs))
+	for i, obj := range objs {
+		stored[i] = toStoredObj(obj)
+	}
+	return json.Marshal(stored)
+}
+
+func (objsCodec) Decode(data []byte) ([]model.Obj, error) {
+	var stored []*storedObj
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	objs := make([]model.Obj, len(stored))
+	for i, s := range stored {
+		objs[i] = s.toObj()
+	}
+	return objs, nil
+}
+
+type storedLink struct {
+	URL         string         `json:"url"`
+	Header      http.Header    `json:"header,omitempty"`
+	Expiration  *time.Duration `json:"expiration,omitempty"`
+	IPCacheKey  bool           `json:"ip_cache_key,omitempty"`
+	Concurrency int            `json:"concurrency,omitempty"`
+	PartSize    int            `json:"part_size,omitempty"`
+}
+
+// toStoredLink only accepts plain url links,
+// links holding a reader or an opened file can't leave the process.
+func toStoredLink(link *model.Link) (*storedLink, error) {
+	if link.URL == "" || link.RangeReadCloser != nil || link.MFile != nil {
+		return nil, cache.ErrNotPersistable
+	}
+	return &storedLink{
+		URL:         link.URL,
+		Header:      link.Header,
+		Expiration:  link.Expiration,
+		IPCacheKey:  link.IPCacheKey,
+		Concurrency: link.Concurrency,
+		PartSize:    link.PartSize,
+	}, nil
+}
+
+func (s *storedLink) toLink() *model.Link {
+	return &model.Link{
+		URL:         s.URL,
+		Header:      s.Header,
+		Expiration:  s.Expiration,
+		IPCacheKey:  s.IPCacheKey,
+		Concurrency: s.Concurrency,
+		PartSize:    s.PartSize,
+	}
+}
+
+type linkCodec struct{}
+
+func (linkCodec) Encode(link *model.Link) ([]byte, error) {
+	s, err := toStoredLink(link)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+func (linkCodec) Decode(data []byte) (*model.Link, error) {
+	var s storedLink
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s.toLink(), nil
+}
+
+type storedExtractLink struct {
+	Link *storedLink `json:"link"`
+	Obj  *storedObj  `json:"obj"`
+}
+
+type extractLinkCodec struct{}
+
+func (extractLinkCodec) Encode(l *extractLink) ([]byte, error) {
+	s, err := toStoredLink(l.Link)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(storedExtractLink{Link: s, Obj: toStoredObj(l.Obj)})
+}
+
+func (extractLinkCodec) Decode(data []byte) (*extractLink, error) {
+	var s storedExtractLink
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &extractLink{Link: s.Link.toLink(), Obj: s.Obj.toObj()}, nil
+}
+
+type storedArchiveMeta struct {
+	Comment         string         `json:"comment,omitempty"`
+	Encrypted       bool           `json:"encrypted"`
+	Tree            []*storedObj   `json:"tree,omitempty"`
+	Sort            *model.Sort    `json:"sort,omitempty"`
+	DriverProviding bool           `json:"driver_providing"`
+	Expiration      *time.Duration `json:"expiration,omitempty"`
+}
+
+type archiveMetaCodec struct{}
+
+func (archiveMetaCodec) Encode(m *model.ArchiveMetaProvider) ([]byte, error) {
+	s := storedArchiveMeta{
+		Sort:            m.Sort,
+		DriverProviding: m.DriverProviding,
+		Expiration:      m.Expiration,
+	}
+	if m.ArchiveMeta != nil {
+		s.Comment = m.GetComment()
+		s.Encrypted = m.IsEncrypted()
+		for _, t := range m.GetTree() {
+			s.Tree = append(s.Tree, toStoredObj(t))
+		}
+	}
+	return json.Marshal(s)
+}
+
+func (archiveMetaCodec) Decode(data []byte) (*model.ArchiveMetaProvider, error) {
+	var s storedArchiveMeta
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	meta := &model.ArchiveMetaInfo{
+		Comment:   s.Comment,
+		Encrypted: s.Encrypted,
+	}
+	if s.Tree != nil {
+		meta.Tree = make([]model.ObjTree, len(s.Tree))
+		for i, t := range s.Tree {
+			meta.Tree[i] = t.toObjTree()
+		}
+	}
+	return &model.ArchiveMetaProvider{
+		ArchiveMeta:     meta,
+		Sort:            s.Sort,
+		DriverProviding: s.DriverProviding,
+		Expiration:      s.Expiration,
+	}, nil
+}
@@ -0,0 +1,554 @@
+package op
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	rcCrypt "github.com/rclone/rclone/backend/crypt"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/obscure"
+	log "github.com/sirupsen/logrus"
+)
+
+// EncryptedSuffix marks the entries written by the encryption of a storage.
+// Files without it are plain, so that the files stored before enabling encryption stay readable.
+const EncryptedSuffix = ".alenc"
+
+// every encrypted file starts with the magic and the id of the key its content is encrypted with,
+// followed by the data encrypted the same way as the crypt driver does
+const (
+	encryptionMagic      = "ALISTENC"
+	encryptionHeaderSize = int64(len(encryptionMagic) + 8)
+)
+
+type keyCipher struct {
+	id     uint
+	cipher *rcCrypt.Cipher
+}
+
+var (
+	keyCiphersMu     sync.Mutex
+	keyCiphers       []keyCipher
+	keyCiphersLoaded bool
+)
+
+func newKeyCipher(key *model.EncryptionKey) (*rcCrypt.Cipher, error) {
+	return rcCrypt.NewCipher(configmap.Simple{
+		"password":                  key.Password,
+		"password2":                 key.Salt,
+		"filename_encryption":       "standard",
+		"directory_name_encryption": "true",
+		"filename_encoding":         "base32",
+	})
+}
+
+// getKeyCiphers returns the ciphers of all the keys, deriving a cipher is slow so they are cached
+func getKeyCiphers() ([]keyCipher, error) {
+	keyCiphersMu.Lock()
+	defer keyCiphersMu.Unlock()
+	if keyCiphersLoaded {
+		return keyCiphers, nil
+	}
+	keys, err := db.GetEncryptionKeys()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get encryption keys")
+	}
+	ciphers := make([]keyCipher, 0, len(keys))
+	for i := range keys {
+		c, err := newKeyCipher(&keys[i])
+		if err != nil {
+			log.Errorf("failed create cipher of encryption key [%s]: %+v", keys[i].Name, err)
+			continue
+		}
+		ciphers = append(ciphers, keyCipher{id: keys[i].ID, cipher: c})
+	}
+	keyCiphers, keyCiphersLoaded = ciphers, true
+	return keyCiphers, nil
+}
+
+func resetKeyCiphers() {
+	keyCiphersMu.Lock()
+	defer keyCiphersMu.Unlock()
+	keyCiphers, keyCiphersLoaded = nil, false
+}
+
+func getKeyCipher(id uint) (*rcCrypt.Cipher, error) {
+	ciphers, err := getKeyCiphers()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range ciphers {
+		if c.id == id {
+			return c.cipher, nil
+		}
+	}
+	return nil, errors.Errorf("encryption key %d not found", id)
+}
+
+// storageKeyCiphers returns the ciphers to decrypt names with, the current key of the storage first
+func storageKeyCiphers(storage driver.Driver) []keyCipher {
+	ciphers, err := getKeyCiphers()
+	if err != nil {
+		log.Errorf("%+v", err)
+		return nil
+	}
+	current := storage.GetStorage().EncryptionKeyId
+	res := make([]keyCipher, 0, len(ciphers))
+	for _, c := range ciphers {
+		if c.id == current {
+			res = append([]keyCipher{c}, res...)
+		} else {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// decodesNames reports whether the entries of the storage may be encrypted.
+// It's still the case after disabling encryption as long as the key is kept.
+func decodesNames(storage driver.Driver) bool {
+	s := storage.GetStorage()
+	return s.EnableEncryption || s.EncryptionKeyId != 0
+}
+
+// encryptsNames reports whether the names of the entries may be encrypted as well,
+// then the stored path of an entry can't be told without listing its parent
+func encryptsNames(storage driver.Driver) bool {
+	return decodesNames(storage) && storage.GetStorage().EncryptNames
+}
+
+func encryptsContent(storage driver.Driver) bool {
+	return storage.GetStorage().EnableEncryption
+}
+
+// encodeName returns the name an entry called name is stored with
+func encodeName(storage driver.Driver, name string, isDir bool) (string, error) {
+	s := storage.GetStorage()
+	if !s.EnableEncryption {
+		return name, nil
+	}
+	if s.EncryptNames {
+		c, err := getKeyCipher(s.EncryptionKeyId)
+		if err != nil {
+			return "", err
+		}
+		return c.EncryptFileName(name) + EncryptedSuffix, nil
+	}
+	if isDir {
+		return name, nil
+	}
+	return name + EncryptedSuffix, nil
+}
+
+// encodeRename returns the name to rename the stored entry raw to, encrypted entries stay encrypted
+func encodeRename(storage driver.Driver, raw model.Obj, name string) (string, error) {
+	if !decodesNames(storage) {
+		return name, nil
+	}
+	if _, encoded := decodeName(storageKeyCiphers(storage), raw.GetName(), raw.IsDir()); !encoded {
+		return name, nil
+	}
+	s := storage.GetStorage()
+	if s.EnableEncryption && s.EncryptNames {
+		return encodeName(storage, name, raw.IsDir())
+	}
+	if raw.IsDir() {
+		return name, nil
+	}
+	return name + EncryptedSuffix, nil
+}
+
+// decodeName returns the name of the entry stored as rawName and whether it's encoded,
+// an encoded file has its content encrypted while an encoded dir has its name encrypted
+func decodeName(ciphers []keyCipher, rawName string, isDir bool) (string, bool) {
+	base, found := strings.CutSuffix(rawName, EncryptedSuffix)
+	if !found {
+		return rawName, false
+	}
+	for _, c := range ciphers {
+		if name, err := c.cipher.DecryptFileName(base); err == nil {
+			return name, true
+		}
+	}
+	if isDir {
+		return rawName, false
+	}
+	return base, true
+}
+
+// encryptedObj is an entry of a storage with encryption, named and sized as it was put
+type encryptedObj struct {
+	model.Obj
+	name string
+	size int64
+}
+
+func (o *encryptedObj) Unwrap() model.Obj {
+	return o.Obj
+}
+
+func (o *encryptedObj) GetName() string {
+	return o.name
+}
+
+func (o *encryptedObj) GetSize() int64 {
+	return o.size
+}
+
+// GetHash the hashes of the driver are the ones of the encrypted content
+func (o *encryptedObj) GetHash() utils.HashInfo {
+	return utils.NewHashInfo(nil, "")
+}
+
+// Thumb the thumbnails of the driver can't be generated from the encrypted content
+func (o *encryptedObj) Thumb() string {
+	return ""
+}
+
+func decodeObj(ciphers []keyCipher, obj model.Obj) model.Obj {
+	name, encoded := decodeName(ciphers, obj.GetName(), obj.IsDir())
+	if !encoded {
+		return model.WrapObjName(obj)
+	}
+	size := obj.GetSize()
+	if !obj.IsDir() {
+		size = decryptedSize(ciphers, size)
+	}
+	return &encryptedObj{Obj: obj, name: utils.MappingName(name), size: size}
+}
+
+// decryptedSize returns the size of the content of an encrypted file sized size,
+// a file too short or not laid out as encrypted keeps its size
+func decryptedSize(ciphers []keyCipher, size int64) int64 {
+	if len(ciphers) == 0 {
+		return size
+	}
+	// the layout of the encrypted data doesn't depend on the key
+	res, err := ciphers[0].cipher.DecryptedSize(size - encryptionHeaderSize)
+	if err != nil {
+		return size
+	}
+	return res
+}
+
+// wrapObj wraps an obj returned by the driver of storage
+func wrapObj(storage driver.Driver, obj model.Obj) model.Obj {
+	if !decodesNames(storage) {
+		return model.WrapObjName(obj)
+	}
+	return decodeObj(storageKeyCiphers(storage), obj)
+}
+
+func wrapObjs(storage driver.Driver, objs []model.Obj) {
+	if !decodesNames(storage) {
+		model.WrapObjsName(objs)
+		return
+	}
+	ciphers := storageKeyCiphers(storage)
+	for i := range objs {
+		objs[i] = decodeObj(ciphers, objs[i])
+	}
+}
+
+func encryptionHeader(keyId uint) []byte {
+	header := make([]byte, encryptionHeaderSize)
+	copy(header, encryptionMagic)
+	binary.BigEndian.PutUint64(header[len(encryptionMagic):], uint64(keyId))
+	return header
+}
+
+func parseEncryptionHeader(header []byte) (uint, error) {
+	if len(header) != int(encryptionHeaderSize) || string(header[:len(encryptionMagic)]) != encryptionMagic {
+		return 0, errors.New("not an encrypted file")
+	}
+	return uint(binary.BigEndian.Uint64(header[len(encryptionMagic):])), nil
+}
+
+// linkRangeReader returns a function reading the given range of the file linked by link
+func linkRangeReader(link *model.Link, size int64, closers *utils.Closers) (rcCrypt.OpenRangeSeek, error) {
+	if link.RangeReadCloser == nil && link.MFile == nil && len(link.URL) == 0 {
+		return nil, errors.New("the driver need to be enhanced to support encryption")
+	}
+	return func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		if length >= 0 && offset+length >= size {
+			length = -1
+		}
+		rrc := link.RangeReadCloser
+		if len(link.URL) > 0 {
+			converted, err := stream.GetRangeReadCloserFromLink(size, link)
+			if err != nil {
+				return nil, err
+			}
+			rrc = converted
+		}
+		if rrc != nil {
+			rc, err := rrc.RangeRead(ctx, http_range.Range{Start: offset, Length: length})
+			closers.AddClosers(rrc.GetClosers())
+			if err != nil {
+				return nil, err
+			}
+			return rc, nil
+		}
+		if link.MFile != nil {
+			if _, err := link.MFile.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			// the same MFile is reused, close it at last
+			closers.Add(link.MFile)
+			return io.NopCloser(link.MFile), nil
+		}
+		return nil, errs.NotSupport
+	}, nil
+}
+
+func readContentKeyId(ctx context.Context, read rcCrypt.OpenRangeSeek) (uint, error) {
+	rc, err := read(ctx, 0, encryptionHeaderSize)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	header := make([]byte, encryptionHeaderSize)
+	if _, err = io.ReadFull(rc, header); err != nil {
+		return 0, errors.Wrap(err, "failed read encryption header")
+	}
+	return parseEncryptionHeader(header)
+}
+
+// decryptLink wraps the link of the encrypted file raw into a link of its plain content
+func decryptLink(link *model.Link, raw model.Obj) (*model.Link, error) {
+	res := &model.RangeReadCloser{Closers: utils.EmptyClosers()}
+	read, err := linkRangeReader(link, raw.GetSize(), &res.Closers)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mu     sync.Mutex
+		cipher *rcCrypt.Cipher
+	)
+	// the key is read from the header of the content at the first read
+	getCipher := func(ctx context.Context) (*rcCrypt.Cipher, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cipher != nil {
+			return cipher, nil
+		}
+		keyId, err := readContentKeyId(ctx, read)
+		if err != nil {
+			return nil, err
+		}
+		cipher, err = getKeyCipher(keyId)
+		return cipher, err
+	}
+	res.RangeReader = func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		c, err := getCipher(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.DecryptDataSeek(ctx, func(ctx context.Context, offset, limit int64) (io.ReadCloser, error) {
+			return read(ctx, offset+encryptionHeaderSize, limit)
+		}, httpRange.Start, httpRange.Length)
+	}
+	return &model.Link{
+		RangeReadCloser: res,
+		Expiration:      link.Expiration,
+	}, nil
+}
+
+// encryptStream returns the stream to put instead of file, named name
+func encryptStream(storage driver.Driver, file model.FileStreamer, name string) (*stream.FileStream, error) {
+	keyId := storage.GetStorage().EncryptionKeyId
+	c, err := getKeyCipher(keyId)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := c.EncryptData(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt data")
+	}
+	// doesn't support rapid upload, since the encrypted data is different every time
+	res := &stream.FileStream{
+		Obj: &model.Object{
+			ID:       file.GetID(),
+			Path:     file.GetPath(),
+			Name:     name,
+			Size:     encryptionHeaderSize + c.EncryptedSize(file.GetSize()),
+			Modified: file.ModTime(),
+			Ctime:    file.CreateTime(),
+		},
+		Reader:            io.MultiReader(bytes.NewReader(encryptionHeader(keyId)), encrypted),
+		Mimetype:          "application/octet-stream",
+		WebPutAsTask:      file.NeedStore(),
+		ForceStreamUpload: true,
+	}
+	if exist := file.GetExist(); exist != nil && exist.GetName() == name {
+		res.Exist = exist
+	}
+	return res, nil
+}
+
+// checkEncryption checks the encryption settings of storage and fixes the ones it doesn't work with
+func checkEncryption(storage *model.Storage) error {
+	if storage.EncryptionKeyId == 0 {
+		if storage.EnableEncryption {
+			return errors.New("encryption needs a key")
+		}
+		return nil
+	}
+	if _, err := db.GetEncryptionKeyById(storage.EncryptionKeyId); err != nil {
+		return errors.WithMessage(err, "failed get encryption key")
+	}
+	// only alist can decrypt the content, so it must not be downloaded from the driver directly
+	storage.WebProxy = true
+	storage.DownProxyUrl = ""
+	if storage.Webdav302() || storage.WebdavProxy() {
+		storage.WebdavPolicy = "native_proxy"
+	}
+	return nil
+}
+
+func GetEncryptionKeys() ([]model.EncryptionKey, error) {
+	return db.GetEncryptionKeys()
+}
+
+// CreateEncryptionKey creates a key from password, a random one is generated if it's empty.
+// The password is returned since it can't be read back, keep it to decrypt the files without alist.
+func CreateEncryptionKey(name, password string) (*model.EncryptionKey, string, error) {
+	if password == "" {
+		password = random.String(32)
+	}
+	obscuredPassword, err := obscure.Obscure(password)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	salt, err := obscure.Obscure(random.String(32))
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	key := &model.EncryptionKey{
+		Name:     name,
+		Password: obscuredPassword,
+		Salt:     salt,
+		Created:  time.Now(),
+	}
+	if err = db.CreateEncryptionKey(key); err != nil {
+		return nil, "", err
+	}
+	resetKeyCiphers()
+	return key, password, nil
+}
+
+// DeleteEncryptionKeyById deletes a key no storage uses anymore,
+// the files still encrypted with it can't be decrypted after that
+func DeleteEncryptionKeyById(id uint) error {
+	count, err := db.CountStoragesByEncryptionKeyId(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.Errorf("the key is used by %d storages", count)
+	}
+	if err = db.DeleteEncryptionKeyById(id); err != nil {
+		return err
+	}
+	resetKeyCiphers()
+	return nil
+}
+
+// ReencryptObj brings the obj at path to the current encryption settings of storage:
+// its content is encrypted with the current key, or decrypted if encryption is disabled,
+// and its name is encrypted or not as configured. Folders are not walked.
+func ReencryptObj(ctx context.Context, storage driver.Driver, path string, up driver.UpdateProgress) error {
+	path = utils.FixAndCleanPath(path)
+	if utils.PathEqual(path, "/") {
+		return nil
+	}
+	obj, err := getDriverObj(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get obj")
+	}
+	raw := model.UnwrapObj(obj)
+	ciphers := storageKeyCiphers(storage)
+	name, encoded := decodeName(ciphers, raw.GetName(), raw.IsDir())
+	rawName, err := encodeName(storage, name, raw.IsDir())
+	if err != nil {
+		return err
+	}
+	if !raw.IsDir() {
+		rewrite := encoded != encryptsContent(storage)
+		if encoded && !rewrite {
+			keyId, err := contentKeyId(ctx, storage, path, raw)
+			if err != nil {
+				return err
+			}
+			rewrite = keyId != storage.GetStorage().EncryptionKeyId
+		}
+		if rewrite {
+			return rewriteObj(ctx, storage, path, obj, up)
+		}
+	}
+	if rawName == raw.GetName() {
+		return nil
+	}
+	return rename(ctx, storage, path, obj, rawName)
+}
+
+func contentKeyId(ctx context.Context, storage driver.Driver, path string, raw model.Obj) (uint, error) {
+	link, _, err := rawLink(ctx, storage, path, model.LinkArgs{})
+	if err != nil {
+		return 0, err
+	}
+	closers := utils.EmptyClosers()
+	defer closers.Close()
+	read, err := linkRangeReader(link, raw.GetSize(), &closers)
+	if err != nil {
+		return 0, err
+	}
+	return readContentKeyId(ctx, read)
+}
+
+// rewriteObj puts the content of obj again, the previous entry is kept until it's done
+func rewriteObj(ctx context.Context, storage driver.Driver, path string, obj model.Obj, up driver.UpdateProgress) error {
+	link, _, err := Link(ctx, storage, path, model.LinkArgs{})
+	if err != nil {
+		return errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return errors.WithMessage(err, "failed get stream")
+	}
+	// the content is read before the entry is renamed away
+	if _, err = ss.CacheFullInTempFile(); err != nil {
+		_ = ss.Close()
+		return errors.WithMessage(err, "failed cache the content")
+	}
+	dirPath, name := stdpath.Split(path)
+	tempName := name + ".alist_reencrypt"
+	tempPath := stdpath.Join(dirPath, tempName)
+	if err = Rename(ctx, storage, path, tempName); err != nil {
+		_ = ss.Close()
+		return err
+	}
+	if err = Put(ctx, storage, dirPath, ss, up); err != nil {
+		if err := Rename(ctx, storage, tempPath, name); err != nil {
+			log.Errorf("failed recover obj: %+v", err)
+		}
+		return err
+	}
+	linkCache.Del(Key(storage, path))
+	return Remove(ctx, storage, tempPath)
+}
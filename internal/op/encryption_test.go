@@ -0,0 +1,139 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+func TestEncryption(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	// the content is cached in a temp file while re-encrypting it
+	conf.Conf.TempDir = t.TempDir()
+	key, _, err := op.CreateEncryptionKey("first", "")
+	if err != nil {
+		t.Fatalf("failed create key: %+v", err)
+	}
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:     "Local",
+		MountPath:  "/encrypted",
+		Addition:   fmt.Sprintf(`{"root_folder_path":%q}`, root),
+		Encryption: model.Encryption{EnableEncryption: true, EncryptionKeyId: key.ID, EncryptNames: true},
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/encrypted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !storage.GetStorage().WebProxy {
+		t.Errorf("the content of an encrypted storage must be proxied")
+	}
+	const content = "secret content"
+	err = op.Put(ctx, storage, "/dir", &stream.FileStream{
+		Obj: &model.Object{
+			Name:     "a.txt",
+			Size:     int64(len(content)),
+			Modified: time.Now(),
+		},
+		Reader: strings.NewReader(content),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	rawFile := func() string {
+		dirs, _ := os.ReadDir(root)
+		if len(dirs) != 1 || strings.Contains(dirs[0].Name(), "dir") {
+			t.Fatalf("unexpected raw dirs: %v", dirs)
+		}
+		files, _ := os.ReadDir(filepath.Join(root, dirs[0].Name()))
+		if len(files) != 1 {
+			t.Fatalf("unexpected raw files: %v", files)
+		}
+		return filepath.Join(root, dirs[0].Name(), files[0].Name())
+	}
+	raw, _ := os.ReadFile(rawFile())
+	if strings.Contains(string(raw), content) {
+		t.Errorf("the content is stored in plain")
+	}
+	check := func(storage driver.Driver) {
+		t.Helper()
+		objs, err := op.List(ctx, storage, "/dir", model.ListArgs{Refresh: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(objs) != 1 || objs[0].GetName() != "a.txt" || objs[0].GetSize() != int64(len(content)) {
+			t.Fatalf("unexpected objs: %+v", objs)
+		}
+		link, _, err := op.Link(ctx, storage, "/dir/a.txt", model.LinkArgs{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, err := link.RangeReadCloser.RangeRead(ctx, http_range.Range{Start: 7, Length: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		read, _ := io.ReadAll(rc)
+		_ = link.RangeReadCloser.Close()
+		if string(read) != content[7:] {
+			t.Errorf("decrypted content is %q", read)
+		}
+	}
+	check(storage)
+
+	// rotate the key, the files are readable before and after re-encrypting them
+	second, _, err := op.CreateEncryptionKey("second", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := db.GetStorageById(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.EncryptionKeyId = second.ID
+	if err = op.UpdateStorage(ctx, *s); err != nil {
+		t.Fatalf("failed update storage: %+v", err)
+	}
+	storage, _ = op.GetStorageByMountPath("/encrypted")
+	check(storage)
+	for _, path := range []string{"/dir", "/dir/a.txt"} {
+		if err = op.ReencryptObj(ctx, storage, path, nil); err != nil {
+			t.Fatalf("failed re-encrypt %s: %+v", path, err)
+		}
+	}
+	check(storage)
+	if err = op.DeleteEncryptionKeyById(second.ID); err == nil {
+		t.Errorf("a key in use must not be deleted")
+	}
+	if err = op.DeleteEncryptionKeyById(key.ID); err != nil {
+		t.Fatalf("failed delete the previous key: %+v", err)
+	}
+	check(storage)
+
+	// a file too short to be encrypted keeps its size rather than listing as empty
+	if err = os.WriteFile(filepath.Join(root, "foreign"+op.EncryptedSuffix), []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := op.Get(ctx, storage, "/foreign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.GetSize() != 3 {
+		t.Errorf("the size of a foreign file is %d", obj.GetSize())
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"slices"
 	"time"
 
-	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -20,7 +20,7 @@ import (
 
 // In order to facilitate adding some other things before and after file op
 
-var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
+var listCache = cache.NewCache[[]model.Obj]("list", objsCodec{}, 64)
 var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
@@ -66,6 +66,7 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 		for i, obj := range objs {
 			if obj.GetName() == newObj.GetName() {
 				objs[i] = newObj
+				listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
 				return
 			}
 		}
@@ -141,7 +142,7 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 			}
 		}
 		// warp obj name
-		model.WrapObjsName(files)
+		wrapObjs(storage, files)
 		// call hooks
 		if !args.NoUpdateIndex {
 			go func(reqPath string, files []model.Obj) {
@@ -174,11 +175,16 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 	path = utils.FixAndCleanPath(path)
 	log.Debugf("op.Get %s", path)
 
-	// get the obj directly without list so that we can reduce the io
-	if g, ok := storage.(driver.Getter); ok {
+	// get the obj directly without list so that we can reduce the io,
+	// the path is not the one stored by the driver if the names are encrypted
+	if g, ok := storage.(driver.Getter); ok && !encryptsNames(storage) {
 		obj, err := g.Get(ctx, path)
+		if err != nil && decodesNames(storage) && !utils.PathEqual(path, "/") {
+			// the file may be stored encrypted under its name with the suffix
+			obj, err = g.Get(ctx, path+EncryptedSuffix)
+		}
 		if err == nil {
-			return model.WrapObjName(obj), nil
+			return wrapObj(storage, obj), nil
 		}
 	}
 
@@ -223,8 +229,12 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 	}
 
 	// not root folder
+	return getFromList(ctx, storage, path, false)
+}
+
+func getFromList(ctx context.Context, storage driver.Driver, path string, refresh bool) (model.Obj, error) {
 	dir, name := stdpath.Split(path)
-	files, err := List(ctx, storage, dir, model.ListArgs{})
+	files, err := List(ctx, storage, dir, model.ListArgs{Refresh: refresh})
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get parent list")
 	}
@@ -237,19 +247,46 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 	return nil, errors.WithStack(errs.ObjectNotFound)
 }
 
-func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+// getDriverObj is Get, but the obj can be passed to the driver once unwrapped
+func getDriverObj(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
 	obj, err := Get(ctx, storage, path)
 	if err != nil {
 		return nil, err
 	}
-	return model.UnwrapObj(obj), err
+	if _, ok := obj.(*restoredObj); ok {
+		// the obj comes from the persistent cache, list again to get the driver's own obj
+		return getFromList(ctx, storage, utils.FixAndCleanPath(path), true)
+	}
+	return obj, nil
+}
+
+func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	obj, err := getDriverObj(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	return model.UnwrapObj(obj), nil
 }
 
-var linkCache = cache.NewMemCache(cache.WithShards[*model.Link](16))
+var linkCache = cache.NewCache[*model.Link]("link", linkCodec{}, 16)
 var linkG singleflight.Group[*model.Link]
 
 // Link get link, if is an url. should have an expiry time
 func Link(ctx context.Context, storage driver.Driver, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	link, file, err := rawLink(ctx, storage, path, args)
+	if err != nil || !decodesNames(storage) {
+		return link, file, err
+	}
+	obj := wrapObj(storage, file)
+	if _, ok := obj.(*encryptedObj); !ok {
+		return link, file, nil
+	}
+	link, err = decryptLink(link, file)
+	return link, obj, err
+}
+
+// rawLink get the link of the content stored by the driver
+func rawLink(ctx context.Context, storage driver.Driver, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return nil, nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
@@ -327,6 +364,10 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 				if err != nil {
 					return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
 				}
+				dirName, err = encodeName(storage, dirName, true)
+				if err != nil {
+					return nil, err
+				}
 
 				switch s := storage.(type) {
 				case driver.MkdirResult:
@@ -334,7 +375,7 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 					newObj, err = s.MakeDir(ctx, parentDir, dirName)
 					if err == nil {
 						if newObj != nil {
-							addCacheObj(storage, parentPath, model.WrapObjName(newObj))
+							addCacheObj(storage, parentPath, wrapObj(storage, newObj))
 						} else if !utils.IsBool(lazyCache...) {
 							ClearCache(storage, parentPath)
 						}
@@ -367,7 +408,7 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
-	srcRawObj, err := Get(ctx, storage, srcPath)
+	srcRawObj, err := getDriverObj(ctx, storage, srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get src object")
 	}
@@ -385,7 +426,7 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		if err == nil {
 			delCacheObj(storage, srcDirPath, srcRawObj)
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObj(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
@@ -409,12 +450,22 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
 	srcPath = utils.FixAndCleanPath(srcPath)
-	srcRawObj, err := Get(ctx, storage, srcPath)
+	srcRawObj, err := getDriverObj(ctx, storage, srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get src object")
 	}
+	dstName, err = encodeRename(storage, model.UnwrapObj(srcRawObj), dstName)
+	if err != nil {
+		return err
+	}
+	return rename(ctx, storage, srcPath, srcRawObj, dstName, lazyCache...)
+}
+
+// rename renames the obj at srcPath to dstName as stored by the driver
+func rename(ctx context.Context, storage driver.Driver, srcPath string, srcRawObj model.Obj, dstName string, lazyCache ...bool) error {
 	srcObj := model.UnwrapObj(srcRawObj)
 	srcDirPath := stdpath.Dir(srcPath)
+	var err error
 
 	switch s := storage.(type) {
 	case driver.RenameResult:
@@ -422,7 +473,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 		newObj, err = s.Rename(ctx, srcObj, dstName)
 		if err == nil {
 			if newObj != nil {
-				updateCacheObj(storage, srcDirPath, srcRawObj, model.WrapObjName(newObj))
+				updateCacheObj(storage, srcDirPath, srcRawObj, wrapObj(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, srcDirPath)
 			}
@@ -460,7 +511,7 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		newObj, err = s.Copy(ctx, srcObj, dstDir)
 		if err == nil {
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObj(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
@@ -484,7 +535,7 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		return errors.New("delete root folder is not allowed, please goto the manage page to delete the storage instead")
 	}
 	path = utils.FixAndCleanPath(path)
-	rawObj, err := Get(ctx, storage, path)
+	rawObj, err := getDriverObj(ctx, storage, path)
 	if err != nil {
 		// if object not found, it's ok
 		if errs.IsObjectNotFound(err) {
@@ -531,19 +582,35 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	dstPath := stdpath.Join(dstDirPath, file.GetName())
 	tempName := file.GetName() + ".alist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
+	rawName, err := encodeName(storage, file.GetName(), false)
+	if err != nil {
+		return err
+	}
 	fi, err := GetUnwrap(ctx, storage, dstPath)
+	var versionPath string
+	// the existing obj stored under another name, it's removed after putting the file
+	var replaced model.Obj
 	if err == nil {
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
+		} else if shouldKeepVersion(storage, dstPath, fi) {
+			versionPath, err = saveVersion(ctx, storage, dstPath)
+			if err != nil {
+				return errors.WithMessagef(err, "while uploading, failed keep the previous version")
+			}
+			fi = nil
 		} else if storage.Config().NoOverwriteUpload {
 			// try to rename old obj
 			err = Rename(ctx, storage, dstPath, tempName)
 			if err != nil {
 				return err
 			}
+		} else if decodesNames(storage) && fi.GetName() != rawName {
+			// e.g. stored before the encryption is enabled
+			replaced = fi
 		} else {
 			file.SetExist(fi)
 		}
@@ -561,20 +628,33 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	if up == nil {
 		up = func(p float64) {}
 	}
+	upload := file
+	if encryptsContent(storage) {
+		encrypted, err := encryptStream(storage, file, rawName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := encrypted.Close(); err != nil {
+				log.Errorf("failed to close encrypted streamer, %v", err)
+			}
+		}()
+		upload = encrypted
+	}
 
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
+		newObj, err = s.Put(ctx, parentDir, upload, up)
 		if err == nil {
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObj(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
 		}
 	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
+		err = s.Put(ctx, parentDir, upload, up)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
@@ -582,6 +662,24 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		return errs.NotImplement
 	}
 	log.Debugf("put file [%s] done", file.GetName())
+	if err == nil && replaced != nil {
+		if s, ok := storage.(driver.Remove); ok {
+			if err := s.Remove(ctx, replaced); err != nil {
+				log.Errorf("failed remove replaced obj: %+v", err)
+			}
+		}
+		ClearCache(storage, dstDirPath)
+	}
+	if versionPath != "" {
+		if err != nil {
+			// upload failed, recover the previous version
+			if err := putBackVersion(ctx, storage, versionPath, dstPath); err != nil {
+				log.Errorf("failed recover previous version: %+v", err)
+			}
+		} else {
+			pruneVersions(ctx, storage, dstPath)
+		}
+	}
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
@@ -607,6 +705,10 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	// the content is fetched by the driver, so it can't be encrypted
+	if encryptsContent(storage) {
+		return errs.NotImplement
+	}
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	_, err := GetUnwrap(ctx, storage, stdpath.Join(dstDirPath, dstName))
 	if err == nil {
@@ -626,7 +728,7 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 		newObj, err = s.PutURL(ctx, dstDir, dstName, url)
 		if err == nil {
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObj(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
@@ -0,0 +1,187 @@
+package op
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// healthHistorySize is the number of checks kept per storage
+	healthHistorySize  = 50
+	healthCheckTimeout = 30 * time.Second
+	// reloadAfterFailures is the number of failed checks in a row before the storage is reloaded,
+	// so that a transient error doesn't reload it
+	reloadAfterFailures = 3
+	// the n-th reload of a failing storage waits reloadDelay * 2^(n-1), at most maxReloadDelay
+	reloadDelay    = time.Minute
+	maxReloadDelay = time.Hour
+)
+
+type HealthCheck struct {
+	Time     time.Time `json:"time"`
+	Latency  int64     `json:"latency"` // in milliseconds
+	Error    string    `json:"error"`
+	Reloaded bool      `json:"reloaded"` // whether the storage was reloaded after the check
+}
+
+type StorageHealth struct {
+	StorageID    uint          `json:"storage_id"`
+	MountPath    string        `json:"mount_path"`
+	Driver       string        `json:"driver"`
+	Healthy      bool          `json:"healthy"`
+	Checks       int64         `json:"checks"`
+	Failures     int64         `json:"failures"`
+	Reloads      int64         `json:"reloads"`
+	FailingSince *time.Time    `json:"failing_since"`
+	NextReloadAt *time.Time    `json:"next_reload_at"`
+	History      []HealthCheck `json:"history"` // the newest last
+	// consecutive is the number of failed checks in a row
+	consecutive int
+}
+
+var (
+	healthMu sync.RWMutex
+	healths  = map[uint]*StorageHealth{}
+)
+
+// GetStoragesHealth returns the health of the loaded storages ordered by mount path
+func GetStoragesHealth() []StorageHealth {
+	healthMu.RLock()
+	defer healthMu.RUnlock()
+	res := make([]StorageHealth, 0, len(healths))
+	for _, h := range healths {
+		c := *h
+		c.History = append([]HealthCheck(nil), h.History...)
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].MountPath < res[j].MountPath
+	})
+	return res
+}
+
+// probeStorage gets the root of the storage and lists it, bypassing the cache
+func probeStorage(ctx context.Context, storage driver.Driver) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("[panic] %v", r)
+		}
+	}()
+	if storage.GetStorage().Status != WORK {
+		return errors.New(storage.GetStorage().Status)
+	}
+	// drivers asserting the type of the dir need the unwrapped root
+	root, err := GetUnwrap(ctx, storage, "/")
+	if err != nil {
+		return err
+	}
+	if _, err = storage.List(ctx, root, model.ListArgs{ReqPath: storage.GetStorage().MountPath}); err != nil {
+		return errors.WithMessage(err, "failed list root")
+	}
+	return nil
+}
+
+func reloadStorage(ctx context.Context, storage driver.Driver) error {
+	s, err := db.GetStorageById(storage.GetStorage().ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err := storage.Drop(ctx); err != nil {
+		log.Warnf("failed drop storage [%s] before reload: %+v", s.MountPath, err)
+	}
+	return LoadStorage(ctx, *s)
+}
+
+func checkStorageHealth(storage driver.Driver) {
+	s := storage.GetStorage()
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	err := probeStorage(ctx, storage)
+	check := HealthCheck{Time: start, Latency: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = err.Error()
+	}
+
+	healthMu.Lock()
+	h, ok := healths[s.ID]
+	if !ok {
+		h = &StorageHealth{StorageID: s.ID}
+		healths[s.ID] = h
+	}
+	h.MountPath, h.Driver = s.MountPath, s.Driver
+	h.Checks++
+	h.Healthy = err == nil
+	reload := false
+	if err == nil {
+		h.consecutive = 0
+		h.FailingSince, h.NextReloadAt = nil, nil
+	} else {
+		h.Failures++
+		h.consecutive++
+		if h.FailingSince == nil {
+			h.FailingSince = &start
+		}
+		reload = h.consecutive >= reloadAfterFailures && (h.NextReloadAt == nil || !start.Before(*h.NextReloadAt))
+		if reload {
+			delay := reloadDelay
+			for i := reloadAfterFailures; i < h.consecutive && delay < maxReloadDelay; i++ {
+				delay *= 2
+			}
+			next := start.Add(min(delay, maxReloadDelay))
+			h.NextReloadAt = &next
+			h.Reloads++
+			check.Reloaded = true
+		}
+	}
+	h.History = append(h.History, check)
+	if len(h.History) > healthHistorySize {
+		h.History = h.History[len(h.History)-healthHistorySize:]
+	}
+	healthMu.Unlock()
+
+	if !reload {
+		return
+	}
+	log.Warnf("storage [%s] is unhealthy, reloading it: %s", s.MountPath, check.Error)
+	if err := reloadStorage(context.Background(), storage); err != nil {
+		log.Errorf("failed reload storage [%s]: %+v", s.MountPath, err)
+	} else {
+		log.Infof("storage [%s] is reloaded", s.MountPath)
+	}
+}
+
+// CheckStoragesHealth probes all the loaded storages at the same time and reloads the failing ones,
+// waiting longer between the reloads of a storage as long as it keeps failing.
+func CheckStoragesHealth() {
+	storages := GetAllStorages()
+	loaded := make(map[uint]struct{}, len(storages))
+	var wg sync.WaitGroup
+	for _, storage := range storages {
+		if storage.GetStorage().Disabled {
+			continue
+		}
+		loaded[storage.GetStorage().ID] = struct{}{}
+		wg.Add(1)
+		go func(storage driver.Driver) {
+			defer wg.Done()
+			checkStorageHealth(storage)
+		}(storage)
+	}
+	wg.Wait()
+	healthMu.Lock()
+	for id := range healths {
+		if _, ok := loaded[id]; !ok {
+			delete(healths, id)
+		}
+	}
+	healthMu.Unlock()
+}
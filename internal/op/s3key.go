@@ -0,0 +1,86 @@
+package op
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// CreateS3AccessKey generates a new access key pair for the user,
+// the secret can only be read from the returned key.
+func CreateS3AccessKey(userId uint, title string) (*model.S3AccessKey, error) {
+	if _, err := db.GetS3AccessKeyByUserTitle(userId, title); err == nil {
+		return nil, errors.New("key with the same title already exists")
+	}
+	k := &model.S3AccessKey{
+		UserId:          userId,
+		Title:           title,
+		AccessKeyId:     "AL" + strings.ToUpper(random.String(18)),
+		SecretAccessKey: random.String(40),
+		AddedTime:       time.Now(),
+	}
+	k.LastUsedTime = k.AddedTime
+	defer resetHasS3AccessKeys()
+	return k, db.CreateS3AccessKey(k)
+}
+
+func GetS3AccessKeysByUserId(userId uint, pageIndex, pageSize int) (keys []model.S3AccessKey, count int64, err error) {
+	return db.GetS3AccessKeysByUserId(userId, pageIndex, pageSize)
+}
+
+func GetS3AccessKeyByIdAndUserId(id uint, userId uint) (*model.S3AccessKey, error) {
+	key, err := db.GetS3AccessKeyById(id)
+	if err != nil {
+		return nil, err
+	}
+	if key.UserId != userId {
+		return nil, errors.New("the s3 key doesn't belong to the user")
+	}
+	return key, nil
+}
+
+func GetS3AccessKeyByAccessKeyId(accessKeyId string) (*model.S3AccessKey, error) {
+	return db.GetS3AccessKeyByAccessKeyId(accessKeyId)
+}
+
+// hasS3AccessKeys caches HasS3AccessKeys, which is checked by every anonymous s3 request,
+// it's reset after a key is created or deleted
+var hasS3AccessKeys struct {
+	sync.Mutex
+	has *bool
+}
+
+func HasS3AccessKeys() (bool, error) {
+	hasS3AccessKeys.Lock()
+	defer hasS3AccessKeys.Unlock()
+	if hasS3AccessKeys.has != nil {
+		return *hasS3AccessKeys.has, nil
+	}
+	count, err := db.CountS3AccessKeys()
+	if err != nil {
+		return false, err
+	}
+	has := count > 0
+	hasS3AccessKeys.has = &has
+	return has, nil
+}
+
+func resetHasS3AccessKeys() {
+	hasS3AccessKeys.Lock()
+	defer hasS3AccessKeys.Unlock()
+	hasS3AccessKeys.has = nil
+}
+
+func UpdateS3AccessKey(k *model.S3AccessKey) error {
+	return db.UpdateS3AccessKey(k)
+}
+
+func DeleteS3AccessKeyById(id uint) error {
+	defer resetHasS3AccessKeys()
+	return db.DeleteS3AccessKeyById(id)
+}
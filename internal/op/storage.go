@@ -11,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -71,6 +72,12 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 		return 0, errors.WithMessage(err, "failed get driver new")
 	}
 	storageDriver := driverNew()
+	if err = checkVersioning(storage, storageDriver); err != nil {
+		return 0, err
+	}
+	if err = checkEncryption(&storage); err != nil {
+		return 0, err
+	}
 	// insert storage to database
 	err = db.CreateStorage(&storage)
 	if err != nil {
@@ -118,6 +125,7 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 			errInfo := fmt.Sprintf("[panic] err: %v\nstack: %s\n", err, getCurrentGoroutineStack())
 			log.Errorf("panic init storage: %s", errInfo)
 			driverStorage.SetStatus(errInfo)
+			publishStorageError(driverStorage)
 			MustSaveDriverStorage(storageDriver)
 			storagesMap.Store(driverStorage.MountPath, storageDriver)
 		}
@@ -153,6 +161,7 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 	storagesMap.Store(driverStorage.MountPath, storageDriver)
 	if err != nil {
 		driverStorage.SetStatus(err.Error())
+		publishStorageError(driverStorage)
 		err = errors.Wrap(err, "failed init storage")
 	} else {
 		driverStorage.SetStatus(WORK)
@@ -161,6 +170,14 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 	return err
 }
 
+func publishStorageError(storage *model.Storage) {
+	event.Publish(event.StorageError, map[string]any{
+		"mount_path": storage.MountPath,
+		"driver":     storage.Driver,
+		"status":     storage.Status,
+	})
+}
+
 func EnableStorage(ctx context.Context, id uint) error {
 	storage, err := db.GetStorageById(id)
 	if err != nil {
@@ -220,6 +237,14 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if oldStorage.Driver != storage.Driver {
 		return errors.Errorf("driver cannot be changed")
 	}
+	if driverNew, err := GetDriver(storage.Driver); err == nil {
+		if err = checkVersioning(storage, driverNew()); err != nil {
+			return err
+		}
+	}
+	if err = checkEncryption(&storage); err != nil {
+		return err
+	}
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
 	//if storage.MountPath == "/" {
@@ -0,0 +1,80 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the capacity is asked for by every webdav PROPFIND, most drivers need a request to get it
+const detailsCacheExpiration = time.Minute
+
+// detailsRefreshTimeout bounds getting the capacity in the background, e.g. summing the objects of a large s3 bucket
+const detailsRefreshTimeout = 10 * time.Minute
+
+var detailsCache = cache.NewMemCache(cache.WithShards[*model.StorageDetails](2))
+var detailsG singleflight.Group[*model.StorageDetails]
+
+// lastDetails keeps the capacity last got of every storage after it expires from detailsCache
+var lastDetails = cache.NewMemCache(cache.WithShards[*model.StorageDetails](2))
+
+// GetStorageDetails returns the capacity of the storage,
+// errs.NotImplement if its driver can't report it
+func GetStorageDetails(ctx context.Context, storage driver.Driver) (*model.StorageDetails, error) {
+	d, ok := storage.(driver.StorageDetails)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	key := storage.GetStorage().MountPath
+	if details, ok := detailsCache.Get(key); ok {
+		return details, nil
+	}
+	details, err, _ := detailsG.Do(key, func() (*model.StorageDetails, error) {
+		details, err := d.GetDetails(ctx)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed get storage details")
+		}
+		if details.UsedSpace == 0 && details.TotalSpace > details.FreeSpace {
+			details.UsedSpace = details.TotalSpace - details.FreeSpace
+		}
+		if details.FreeSpace == 0 && details.TotalSpace > details.UsedSpace {
+			details.FreeSpace = details.TotalSpace - details.UsedSpace
+		}
+		detailsCache.Set(key, details, cache.WithEx[*model.StorageDetails](detailsCacheExpiration))
+		lastDetails.Set(key, details)
+		return details, nil
+	})
+	return details, err
+}
+
+// GetCachedStorageDetails returns the capacity last got of the storage, nil if there is none yet,
+// and gets it again in the background with its own context once it's expired, so the caller
+// never waits for a slow driver nor cuts its listing short.
+func GetCachedStorageDetails(storage driver.Driver) *model.StorageDetails {
+	if _, ok := storage.(driver.StorageDetails); !ok {
+		return nil
+	}
+	key := storage.GetStorage().MountPath
+	if details, ok := detailsCache.Get(key); ok {
+		return details
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), detailsRefreshTimeout)
+		defer cancel()
+		if _, err := GetStorageDetails(ctx, storage); err != nil {
+			log.Debugf("failed get details of storage %s: %+v", key, err)
+		}
+	}()
+	details, _ := lastDetails.Get(key)
+	return details
+}
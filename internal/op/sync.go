@@ -0,0 +1,68 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func checkSyncJob(j *model.SyncJob) error {
+	j.SrcPath = utils.FixAndCleanPath(j.SrcPath)
+	j.DstPath = utils.FixAndCleanPath(j.DstPath)
+	if utils.IsSubPath(j.SrcPath, j.DstPath) || utils.IsSubPath(j.DstPath, j.SrcPath) {
+		return errors.New("the source and destination must not contain each other")
+	}
+	switch j.Mode {
+	case "":
+		j.Mode = model.SyncOneWay
+	case model.SyncOneWay, model.SyncTwoWay:
+	default:
+		return errors.Errorf("invalid sync mode: %s", j.Mode)
+	}
+	if j.Cron != "" {
+		if _, err := cron.ParseSchedule(j.Cron); err != nil {
+			return errors.WithMessage(err, "invalid cron expression")
+		}
+	}
+	return nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	if err := checkSyncJob(j); err != nil {
+		return err
+	}
+	return db.CreateSyncJob(j)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	if err := checkSyncJob(j); err != nil {
+		return err
+	}
+	return db.UpdateSyncJob(j)
+}
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	return db.GetSyncJobById(id)
+}
+
+func GetSyncJobs(pageIndex, pageSize int) ([]model.SyncJob, int64, error) {
+	return db.GetSyncJobs(pageIndex, pageSize)
+}
+
+func GetScheduledSyncJobs() ([]model.SyncJob, error) {
+	return db.GetScheduledSyncJobs()
+}
+
+func DeleteSyncJobById(id uint) error {
+	return db.DeleteSyncJobById(id)
+}
+
+func GetSyncState(jobID uint) (*model.SyncState, error) {
+	return db.GetSyncState(jobID)
+}
+
+func SaveSyncState(state *model.SyncState) error {
+	return db.SaveSyncState(state)
+}
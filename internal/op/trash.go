@@ -0,0 +1,32 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func CreateTrashItem(t *model.TrashItem) error {
+	return db.CreateTrashItem(t)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	return db.GetTrashItemById(id)
+}
+
+func GetTrashItems(userId uint, pageIndex, pageSize int) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItems(userId, pageIndex, pageSize)
+}
+
+func GetInPlaceTrashItems() ([]model.TrashItem, error) {
+	return db.GetInPlaceTrashItems()
+}
+
+func GetTrashItemsRemovedBefore(t time.Time) ([]model.TrashItem, error) {
+	return db.GetTrashItemsRemovedBefore(t)
+}
+
+func DeleteTrashItemById(id uint) error {
+	return db.DeleteTrashItemById(id)
+}
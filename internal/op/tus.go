@@ -0,0 +1,32 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func CreateTusUpload(u *model.TusUpload) error {
+	return db.CreateTusUpload(u)
+}
+
+func GetTusUploadById(id string) (*model.TusUpload, error) {
+	return db.GetTusUploadById(id)
+}
+
+func UpdateTusUploadOffset(id string, offset int64) error {
+	return db.UpdateTusUploadOffset(id, offset)
+}
+
+func GetTusUploads() ([]model.TusUpload, error) {
+	return db.GetTusUploads()
+}
+
+func GetTusUploadsUpdatedBefore(t time.Time) ([]model.TusUpload, error) {
+	return db.GetTusUploadsUpdatedBefore(t)
+}
+
+func DeleteTusUploadById(id string) error {
+	return db.DeleteTusUploadById(id)
+}
@@ -142,6 +142,10 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	defer resetHasS3AccessKeys()
+	if err := db.DeleteS3AccessKeysByUserId(id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -0,0 +1,244 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// VersionsFolder is created under the root of the storages with versioning enabled,
+// the previous versions of a file are kept as VersionsFolder/<path of the file>/<version id>.
+const VersionsFolder = ".alist_versions"
+
+// versionIDFormat names the versions after the time they are replaced, so that
+// they sort by time
+const versionIDFormat = "20060102T150405.000000000Z"
+
+func versionsDir(path string) string {
+	return stdpath.Join("/", VersionsFolder, path)
+}
+
+func InVersionsFolder(path string) bool {
+	return utils.IsSubPath(stdpath.Join("/", VersionsFolder), path)
+}
+
+func nativeVersioner(storage driver.Driver) (driver.Versioner, bool) {
+	v, ok := storage.(driver.Versioner)
+	return v, ok && v.NativeVersioning()
+}
+
+// checkVersioning makes sure the versions of the storage can be kept
+func checkVersioning(storage model.Storage, d driver.Driver) error {
+	if !storage.EnableVersioning {
+		return nil
+	}
+	if _, ok := d.(driver.Versioner); ok {
+		return nil
+	}
+	_, move := d.(driver.Move)
+	_, moveResult := d.(driver.MoveResult)
+	_, rename := d.(driver.Rename)
+	_, renameResult := d.(driver.RenameResult)
+	if !(move || moveResult) || !(rename || renameResult) {
+		return errors.New("versioning needs a driver which can move and rename files")
+	}
+	return nil
+}
+
+// shouldKeepVersion reports whether obj must be moved to the versions folder before being overwritten
+func shouldKeepVersion(storage driver.Driver, path string, obj model.Obj) bool {
+	if !storage.GetStorage().EnableVersioning || obj.IsDir() || InVersionsFolder(path) {
+		return false
+	}
+	_, native := nativeVersioner(storage)
+	return !native
+}
+
+// saveVersion moves the file at path to the versions folder and returns its new path
+func saveVersion(ctx context.Context, storage driver.Driver, path string) (string, error) {
+	dir := versionsDir(path)
+	if err := MakeDir(ctx, storage, dir); err != nil {
+		return "", errors.WithMessage(err, "failed make versions dir")
+	}
+	if err := Move(ctx, storage, path, dir); err != nil {
+		return "", errors.WithMessage(err, "failed move to versions dir")
+	}
+	id := time.Now().UTC().Format(versionIDFormat)
+	if err := Rename(ctx, storage, stdpath.Join(dir, stdpath.Base(path)), id); err != nil {
+		if err := Move(ctx, storage, stdpath.Join(dir, stdpath.Base(path)), stdpath.Dir(path)); err != nil {
+			log.Errorf("failed move [%s] back from versions dir: %+v", path, err)
+		}
+		return "", errors.WithMessage(err, "failed rename version")
+	}
+	return stdpath.Join(dir, id), nil
+}
+
+// putBackVersion moves the version at versionPath to path
+func putBackVersion(ctx context.Context, storage driver.Driver, versionPath, path string) error {
+	name := stdpath.Base(path)
+	if err := Rename(ctx, storage, versionPath, name); err != nil {
+		return err
+	}
+	return Move(ctx, storage, stdpath.Join(stdpath.Dir(versionPath), name), stdpath.Dir(path))
+}
+
+// pruneVersions removes the versions of the file at path beyond the retention of the storage
+func pruneVersions(ctx context.Context, storage driver.Driver, path string) {
+	maxVersions, maxAge := storage.GetStorage().MaxVersions, storage.GetStorage().MaxVersionAge
+	if maxVersions <= 0 && maxAge <= 0 {
+		return
+	}
+	versions, err := listVersions(ctx, storage, path)
+	if err != nil {
+		log.Errorf("failed list versions of [%s]: %+v", path, err)
+		return
+	}
+	expire := time.Now().AddDate(0, 0, -maxAge)
+	for i, v := range versions {
+		replaced, err := time.Parse(versionIDFormat, v.ID)
+		if (maxVersions <= 0 || i < maxVersions) && (maxAge <= 0 || err != nil || replaced.After(expire)) {
+			continue
+		}
+		if err := Remove(ctx, storage, stdpath.Join(versionsDir(path), v.ID)); err != nil {
+			log.Errorf("failed remove version [%s] of [%s]: %+v", v.ID, path, err)
+		}
+	}
+}
+
+// PruneAllVersions applies the retention to all the versions kept in the versions folder of the storage
+func PruneAllVersions(ctx context.Context, storage driver.Driver) error {
+	var walk func(path string) error
+	walk = func(path string) error {
+		objs, err := List(ctx, storage, versionsDir(path), model.ListArgs{Refresh: true})
+		if err != nil {
+			if errs.IsObjectNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		hasVersion := false
+		for _, obj := range objs {
+			if obj.IsDir() {
+				if err = walk(stdpath.Join(path, obj.GetName())); err != nil {
+					return err
+				}
+			} else {
+				hasVersion = true
+			}
+		}
+		if hasVersion {
+			pruneVersions(ctx, storage, path)
+		}
+		return nil
+	}
+	return walk("/")
+}
+
+func listVersions(ctx context.Context, storage driver.Driver, path string) ([]model.FileVersion, error) {
+	objs, err := List(ctx, storage, versionsDir(path), model.ListArgs{Refresh: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return []model.FileVersion{}, nil
+		}
+		return nil, err
+	}
+	versions := make([]model.FileVersion, 0, len(objs))
+	for _, obj := range objs {
+		if obj.IsDir() {
+			continue
+		}
+		versions = append(versions, model.FileVersion{
+			ID:       obj.GetName(),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+func checkVersionID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.Contains(id, "/") {
+		return errors.Errorf("invalid version id: %s", id)
+	}
+	return nil
+}
+
+// ListVersions returns the previous versions of the file at path, the newest first
+func ListVersions(ctx context.Context, storage driver.Driver, path string) ([]model.FileVersion, error) {
+	path = utils.FixAndCleanPath(path)
+	if v, ok := nativeVersioner(storage); ok {
+		file, err := GetUnwrap(ctx, storage, path)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed get file")
+		}
+		return v.ListVersions(ctx, file)
+	}
+	return listVersions(ctx, storage, path)
+}
+
+func LinkVersion(ctx context.Context, storage driver.Driver, path, id string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	path = utils.FixAndCleanPath(path)
+	if err := checkVersionID(id); err != nil {
+		return nil, nil, err
+	}
+	if v, ok := nativeVersioner(storage); ok {
+		file, err := GetUnwrap(ctx, storage, path)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed get file")
+		}
+		link, err := v.LinkVersion(ctx, file, id, args)
+		return link, file, err
+	}
+	return Link(ctx, storage, stdpath.Join(versionsDir(path), id), args)
+}
+
+// RestoreVersion makes the version id the current content of the file at path,
+// the current content is kept as a version in turn.
+func RestoreVersion(ctx context.Context, storage driver.Driver, path, id string) error {
+	path = utils.FixAndCleanPath(path)
+	if err := checkVersionID(id); err != nil {
+		return err
+	}
+	if v, ok := nativeVersioner(storage); ok {
+		file, err := GetUnwrap(ctx, storage, path)
+		if err != nil {
+			return errors.WithMessage(err, "failed get file")
+		}
+		if err = v.RestoreVersion(ctx, file, id); err != nil {
+			return err
+		}
+		ClearCache(storage, stdpath.Dir(path))
+		return nil
+	}
+	versionPath := stdpath.Join(versionsDir(path), id)
+	if _, err := Get(ctx, storage, versionPath); err != nil {
+		return errors.WithMessage(err, "failed get version")
+	}
+	if cur, err := Get(ctx, storage, path); err == nil {
+		if cur.IsDir() {
+			return errors.WithStack(errs.NotFile)
+		}
+		if _, err = saveVersion(ctx, storage, path); err != nil {
+			return errors.WithMessage(err, "failed keep the current version")
+		}
+	} else if !errs.IsObjectNotFound(err) {
+		return err
+	}
+	if err := putBackVersion(ctx, storage, versionPath, path); err != nil {
+		return errors.WithMessage(err, "failed restore version")
+	}
+	pruneVersions(ctx, storage, path)
+	return nil
+}
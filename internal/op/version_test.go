@@ -0,0 +1,78 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+)
+
+func TestVersioning(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:     "Local",
+		MountPath:  "/versioned",
+		Addition:   fmt.Sprintf(`{"root_folder_path":%q}`, root),
+		Versioning: model.Versioning{EnableVersioning: true, MaxVersions: 2},
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(content string) {
+		err := op.Put(ctx, storage, "/dir", &stream.FileStream{
+			Obj: &model.Object{
+				Name:     "a.txt",
+				Size:     int64(len(content)),
+				Modified: time.Now(),
+			},
+			Reader: strings.NewReader(content),
+		}, nil)
+		if err != nil {
+			t.Fatalf("failed put: %+v", err)
+		}
+	}
+	for _, content := range []string{"one", "two!", "three", "four!!"} {
+		put(content)
+	}
+	versions, err := op.ListVersions(ctx, storage, "/dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "one" is pruned, the newest first
+	if len(versions) != 2 || versions[0].Size != 5 || versions[1].Size != 4 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if err = op.RestoreVersion(ctx, storage, "/dir/a.txt", versions[1].ID); err != nil {
+		t.Fatalf("failed restore: %+v", err)
+	}
+	f, err := os.Open(filepath.Join(root, "dir", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	content, _ := io.ReadAll(f)
+	if string(content) != "two!" {
+		t.Errorf("restored content is %q", content)
+	}
+	versions, err = op.ListVersions(ctx, storage, "/dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the overwritten "four!!" is kept, "three" stays as the second newest
+	if len(versions) != 2 || versions[0].Size != 6 || versions[1].Size != 5 {
+		t.Errorf("unexpected versions after restore: %+v", versions)
+	}
+}
@@ -0,0 +1,60 @@
+package op
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+var (
+	webdavPropsOnce sync.Once
+	// noWebdavProps saves a query per resource of PROPFIND until a dead prop is set,
+	// most clients never set any
+	noWebdavProps atomic.Bool
+)
+
+func hasWebdavProps() bool {
+	webdavPropsOnce.Do(func() {
+		count, err := db.CountWebdavProps()
+		noWebdavProps.Store(err == nil && count == 0)
+	})
+	return !noWebdavProps.Load()
+}
+
+func GetWebdavProps(path string) ([]model.WebdavProp, error) {
+	if !hasWebdavProps() {
+		return nil, nil
+	}
+	return db.GetWebdavProps(path)
+}
+
+func PatchWebdavProps(path string, set, remove []model.WebdavProp) error {
+	if len(set) > 0 {
+		hasWebdavProps()
+		noWebdavProps.Store(false)
+	}
+	return db.PatchWebdavProps(path, set, remove)
+}
+
+func DeleteWebdavProps(path string) error {
+	if !hasWebdavProps() {
+		return nil
+	}
+	return db.DeleteWebdavProps(path)
+}
+
+func MoveWebdavProps(src, dst string) error {
+	if !hasWebdavProps() {
+		return nil
+	}
+	return db.MoveWebdavProps(src, dst, false)
+}
+
+func CopyWebdavProps(src, dst string) error {
+	if !hasWebdavProps() {
+		return nil
+	}
+	return db.MoveWebdavProps(src, dst, true)
+}
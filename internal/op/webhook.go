@@ -0,0 +1,86 @@
+package op
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func checkWebhook(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid webhook url: %s", w.URL)
+	}
+	var types []string
+	for _, typ := range strings.Split(w.Events, ",") {
+		typ = strings.TrimSpace(typ)
+		if typ == "" {
+			continue
+		}
+		if !event.IsValidType(typ) {
+			return errors.Errorf("invalid event type: %s", typ)
+		}
+		types = append(types, typ)
+	}
+	w.Events = strings.Join(types, ",")
+	return nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	if err := checkWebhook(w); err != nil {
+		return err
+	}
+	return db.CreateWebhook(w)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	if err := checkWebhook(w); err != nil {
+		return err
+	}
+	return db.UpdateWebhook(w)
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	return db.GetWebhookById(id)
+}
+
+func GetWebhooks(pageIndex, pageSize int) ([]model.Webhook, int64, error) {
+	return db.GetWebhooks(pageIndex, pageSize)
+}
+
+func GetEnabledWebhooks() ([]model.Webhook, error) {
+	return db.GetEnabledWebhooks()
+}
+
+func DeleteWebhookById(id uint) error {
+	return db.DeleteWebhookById(id)
+}
+
+func CreateWebhookDelivery(d *model.WebhookDelivery) error {
+	return db.CreateWebhookDelivery(d)
+}
+
+func UpdateWebhookDelivery(d *model.WebhookDelivery) error {
+	return db.UpdateWebhookDelivery(d)
+}
+
+func GetWebhookDeliveryById(id uint) (*model.WebhookDelivery, error) {
+	return db.GetWebhookDeliveryById(id)
+}
+
+func GetWebhookDeliveries(webhookID uint, pageIndex, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	return db.GetWebhookDeliveries(webhookID, pageIndex, pageSize)
+}
+
+func GetDueWebhookDeliveries() ([]model.WebhookDelivery, error) {
+	return db.GetDueWebhookDeliveries(time.Now())
+}
+
+func DeleteWebhookDeliveriesUpdatedBefore(t time.Time) error {
+	return db.DeleteWebhookDeliveriesUpdatedBefore(t)
+}
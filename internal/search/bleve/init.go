@@ -8,7 +8,8 @@ import (
 )
 
 var config = searcher.Config{
-	Name: "bleve",
+	Name:         "bleve",
+	IndexContent: true,
 }
 
 func Init(indexPath *string) (bleve.Index, error) {
@@ -25,6 +26,14 @@ func Init(indexPath *string) (bleve.Index, error) {
 		// TODO: appoint analyzer
 		nameFieldMapping := bleve.NewKeywordFieldMapping()
 		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+		searchNodeMapping.AddFieldMappingsAt("size", bleve.NewNumericFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("modified", bleve.NewDateTimeFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("ext", bleve.NewKeywordFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("obj_type", bleve.NewNumericFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("hash", bleve.NewKeywordFieldMapping())
+		// stored with the term vectors for the snippets
+		searchNodeMapping.AddFieldMappingsAt("content", bleve.NewTextFieldMapping())
+		searchNodeMapping.AddSubDocumentMapping("snippet", bleve.NewDocumentDisabledMapping())
 		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
 		fileIndex, err = bleve.New(*indexPath, indexMapping)
 		if err != nil {
@@ -3,6 +3,8 @@ package bleve
 import (
 	"context"
 	"os"
+	"strings"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/blevesearch/bleve/v2"
 	search2 "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -27,36 +30,130 @@ func (b *Bleve) Config() searcher.Config {
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	if strings.TrimSpace(req.Keywords) == "" {
+		queries = append(queries, bleve.NewMatchAllQuery())
+	} else {
+		nameQuery := bleve.NewMatchQuery(req.Keywords)
+		nameQuery.SetField("name")
+		contentQuery := bleve.NewMatchQuery(req.Keywords)
+		contentQuery.SetField("content")
+		contentQuery.SetOperator(query2.MatchQueryOperatorAnd)
+		queries = append(queries, bleve.NewDisjunctionQuery(nameQuery, contentQuery))
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
+		isDirQuery.SetField("is_dir")
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(&req.SearchFilter)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	search.SortBy(sortOrder(req))
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
-	search.Fields = []string{"*"}
+	// the content is only needed for the snippets
+	search.Fields = []string{"parent", "name", "is_dir", "size", "modified", "ext", "obj_type", "hash"}
+	search.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	search.Highlight.AddField("content")
 	searchResults, err := b.BIndex.Search(search)
 	if err != nil {
 		log.Errorf("search error: %+v", err)
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
+		node := model.SearchNode{
 			Parent: src.Fields["parent"].(string),
 			Name:   src.Fields["name"].(string),
 			IsDir:  src.Fields["is_dir"].(bool),
 			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
+		}
+		// the fields are missing from the nodes indexed before they were added
+		if modified, ok := src.Fields["modified"].(string); ok {
+			node.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+		}
+		node.Ext, _ = src.Fields["ext"].(string)
+		if objType, ok := src.Fields["obj_type"].(float64); ok {
+			node.ObjType = int(objType)
+		}
+		node.Hash, _ = src.Fields["hash"].(string)
+		if fragments := src.Fragments["content"]; len(fragments) > 0 {
+			node.Snippet = fragments[0]
+		}
+		return node, nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
+func filterQueries(f *model.SearchFilter) []query2.Query {
+	var queries []query2.Query
+	inclusive, exclusive := true, false
+	if f.FilesOnly() {
+		isFile := bleve.NewBoolFieldQuery(false)
+		isFile.SetField("is_dir")
+		queries = append(queries, isFile)
+	}
+	if f.MinSize != nil || f.MaxSize != nil {
+		var min, max *float64
+		if f.MinSize != nil {
+			v := float64(*f.MinSize)
+			min = &v
+		}
+		if f.MaxSize != nil {
+			v := float64(*f.MaxSize)
+			max = &v
+		}
+		query := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		query.SetField("size")
+		queries = append(queries, query)
+	}
+	if f.ModifiedAfter != nil || f.ModifiedBefore != nil {
+		var start, end time.Time
+		if f.ModifiedAfter != nil {
+			start = *f.ModifiedAfter
+		}
+		if f.ModifiedBefore != nil {
+			end = *f.ModifiedBefore
+		}
+		query := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &exclusive)
+		query.SetField("modified")
+		queries = append(queries, query)
+	}
+	if len(f.Exts) > 0 {
+		var exts []query2.Query
+		for _, ext := range f.Exts {
+			query := bleve.NewTermQuery(ext)
+			query.SetField("ext")
+			exts = append(exts, query)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(exts...))
+	}
+	if len(f.Types) > 0 {
+		var types []query2.Query
+		for _, t := range f.Types {
+			v := float64(t)
+			query := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			query.SetField("obj_type")
+			types = append(types, query)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(types...))
+	}
+	return queries
+}
+
+func sortOrder(req model.SearchReq) []string {
+	prefix := ""
+	if req.OrderDirection == "desc" {
+		prefix = "-"
+	}
+	switch req.OrderBy {
+	case "size", "modified":
+		return []string{prefix + req.OrderBy, "name"}
+	default:
+		return []string{prefix + "name"}
+	}
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
 	return b.BIndex.Index(uuid.NewString(), node)
 }
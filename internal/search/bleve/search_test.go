@@ -0,0 +1,55 @@
+package bleve
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestSearchContent(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bleve")
+	index, err := Init(&dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Bleve{BIndex: index}
+	defer b.Release(context.Background())
+	err = b.BatchIndex(context.Background(), []model.SearchNode{
+		{Parent: "/docs", Name: "plan.md", Ext: "md", Content: "The <draft> budget for the next quarter"},
+		{Parent: "/docs", Name: "budget", IsDir: true},
+		{Parent: "/docs", Name: "notes.txt", Ext: "txt", Content: "nothing to see"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, total, err := b.Search(context.Background(), model.SearchReq{
+		Keywords: "budget",
+		PageReq:  model.PageReq{Page: 1, PerPage: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Fatalf("got %d results: %+v", total, nodes)
+	}
+	for _, node := range nodes {
+		switch node.Name {
+		case "plan.md":
+			if !strings.Contains(node.Snippet, "&lt;draft&gt; <mark>budget</mark>") {
+				t.Errorf("unexpected snippet %q", node.Snippet)
+			}
+		case "budget":
+			if node.Snippet != "" {
+				t.Errorf("unexpected snippet %q of the folder", node.Snippet)
+			}
+		default:
+			t.Errorf("unexpected result %s", node.Name)
+		}
+		if node.Content != "" {
+			t.Errorf("the content of %s is returned", node.Name)
+		}
+	}
+}
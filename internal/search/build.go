@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -188,11 +189,18 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 }
 
 func Del(ctx context.Context, prefix string) error {
-	return instance.Del(ctx, prefix)
+	if err := instance.Del(ctx, prefix); err != nil {
+		return err
+	}
+	return db.DeleteIndexedDirs(prefix)
 }
 
 func Clear(ctx context.Context) error {
-	return instance.Clear(ctx)
+	if err := instance.Clear(ctx); err != nil {
+		return err
+	}
+	// the folders have to be walked again by the next refresh
+	return db.ClearIndexState()
 }
 
 func Config(ctx context.Context) searcher.Config {
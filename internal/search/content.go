@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/search/extract"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// fillContent sets the content of the node if the searcher indexes the content and the file is
+// of the extensions configured, a file which can't be read is indexed by its name only
+func fillContent(ctx context.Context, node *model.SearchNode) {
+	if node.IsDir || !instance.Config().IndexContent ||
+		!utils.SliceContains(conf.SlicesMap[conf.SearchContentExts], node.Ext) {
+		return
+	}
+	maxSize := int64(setting.GetInt(conf.SearchContentMaxSize, 10)) * utils.MB
+	if node.Size > maxSize {
+		return
+	}
+	p := path.Join(node.Parent, node.Name)
+	content, err := readContent(ctx, p, node.Ext, maxSize)
+	if err != nil {
+		log.Warnf("failed extract the content of [%s]: %+v", p, err)
+		return
+	}
+	node.Content = content
+}
+
+func readContent(ctx context.Context, p, ext string, maxSize int64) (string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(p)
+	if err != nil {
+		return "", err
+	}
+	link, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	data, err := io.ReadAll(io.LimitReader(ss, maxSize))
+	if err != nil {
+		return "", errors.Wrap(err, "failed read the file")
+	}
+	return extract.Text(ext, data, int(maxSize))
+}
+
+func updateContentExts(value string) {
+	var exts []string
+	for _, ext := range strings.Split(value, ",") {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+	conf.SlicesMap[conf.SearchContentExts] = exts
+}
+
+func init() {
+	op.RegisterSettingItemHook(conf.SearchContentExts, func(item *model.SettingItem) error {
+		updateContentExts(item.Value)
+		return nil
+	})
+}
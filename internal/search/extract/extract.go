@@ -0,0 +1,93 @@
+package extract
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text extracts at most maxSize bytes of the text of a file from its content, ext is the extension of the file
+// in lower case without the dot. The content of the extensions which aren't documents is taken as plain text.
+func Text(ext string, data []byte, maxSize int) (string, error) {
+	switch ext {
+	case "pdf":
+		return pdfText(data, maxSize), nil
+	case "docx":
+		return officeText(data, maxSize, "word/document.xml")
+	case "xlsx":
+		return officeText(data, maxSize, "xl/sharedStrings.xml", "xl/worksheets/sheet*.xml")
+	case "pptx":
+		return officeText(data, maxSize, "ppt/slides/slide*.xml")
+	default:
+		return Truncate(plainText(data), maxSize), nil
+	}
+}
+
+// textBuilder is a strings.Builder dropping what is written beyond max bytes,
+// the documents are compressed so that a small file may hold much more text
+type textBuilder struct {
+	strings.Builder
+	max int
+}
+
+func (b *textBuilder) full() bool {
+	return b.Len() >= b.max
+}
+
+func (b *textBuilder) WriteString(s string) (int, error) {
+	return b.Builder.WriteString(Truncate(s, b.max-b.Len()))
+}
+
+func (b *textBuilder) Write(p []byte) (int, error) {
+	return b.WriteString(string(p))
+}
+
+func (b *textBuilder) WriteByte(c byte) error {
+	if b.full() {
+		return nil
+	}
+	return b.Builder.WriteByte(c)
+}
+
+// plainText decodes the text in utf-8 or in utf-16 with a BOM, the binary content gives no text
+func plainText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return decodeUTF16(data[2:], true)
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return decodeUTF16(data[2:], false)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return ""
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// Truncate cuts s to at most n bytes without splitting a character
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,81 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	for _, c := range []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("hello world"), "hello world"},
+		{[]byte("\xef\xbb\xbfhello"), "hello"},
+		{[]byte("\xff\xfeh\x00i\x00"), "hi"},
+		{[]byte("\x89PNG\x00\x00"), ""},
+	} {
+		if got, _ := Text("txt", c.data, 100); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.data, got, c.want)
+		}
+	}
+}
+
+func TestOfficeText(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	_, _ = w.Write([]byte(`<?xml version="1.0"?><w:document xmlns:w="w"><w:body>` +
+		`<w:p><w:r><w:t>Quarterly </w:t></w:r><w:r><w:t>report</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>&amp; budget</w:t></w:r></w:p></w:body></w:document>`))
+	_ = zw.Close()
+	got, err := Text("docx", buf.Bytes(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Quarterly report\n& budget\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPdfText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 712 Td (Hello \\(AList\\)) Tj 0 -14 Td [(Wor) -20 (ld) -300 (again)] TJ ET"
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(content))
+	_ = zw.Close()
+	pdf := fmt.Sprintf("%%PDF-1.4\n4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n"+
+		"5 0 obj\n<< /Length 3 /Filter /DCTDecode >>\nstream\nBT\nendstream\nendobj\n%%%%EOF",
+		compressed.Len(), compressed.String())
+	got, _ := Text("pdf", []byte(pdf), 100)
+	if fields := strings.Fields(got); strings.Join(fields, " ") != "Hello (AList) World again" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTextLimit(t *testing.T) {
+	// a small document expanding to a lot of text
+	text := strings.Repeat("(bomb) Tj ", 1<<20)
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write([]byte("BT " + text + "ET"))
+	_ = zw.Close()
+	pdf := fmt.Sprintf("%%PDF-1.4\n4 0 obj\n<< /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", compressed.String())
+	if got, _ := Text("pdf", []byte(pdf), 1000); len(got) == 0 || len(got) > 1000 {
+		t.Errorf("pdf: got %d bytes, want at most 1000", len(got))
+	}
+
+	var buf bytes.Buffer
+	zipW := zip.NewWriter(&buf)
+	w, _ := zipW.Create("word/document.xml")
+	_, _ = w.Write([]byte(`<w:document xmlns:w="w"><w:body>` + strings.Repeat(`<w:p><w:r><w:t>bomb</w:t></w:r></w:p>`, 1<<20)))
+	_ = zipW.Close()
+	got, err := Text("docx", buf.Bytes(), 1000)
+	if err != nil || len(got) == 0 || len(got) > 1000 {
+		t.Errorf("docx: got %d bytes, want at most 1000: %v", len(got), err)
+	}
+}
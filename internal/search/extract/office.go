@@ -0,0 +1,81 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	stdpath "path"
+
+	"github.com/pkg/errors"
+)
+
+// officeText extracts the text of an office open xml document (docx, xlsx, pptx) from its parts matching the patterns
+func officeText(data []byte, maxSize int, patterns ...string) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", errors.Wrap(err, "failed open the document")
+	}
+	sb := &textBuilder{max: maxSize}
+	for _, pattern := range patterns {
+		for _, f := range zr.File {
+			if sb.full() {
+				return sb.String(), nil
+			}
+			if ok, _ := stdpath.Match(pattern, f.Name); !ok {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return "", errors.Wrapf(err, "failed open %s", f.Name)
+			}
+			// the text is never longer than the xml holding it
+			err = xmlText(&io.LimitedReader{R: rc, N: int64(maxSize)}, sb)
+			_ = rc.Close()
+			if err != nil {
+				return "", errors.WithMessagef(err, "failed parse %s", f.Name)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// xmlText writes the text of the t elements (w:t, a:t, t of the shared strings...), which hold the text
+// in all the office documents, a paragraph or a string item ends with a new line.
+// The text decoded so far is kept if the xml is cut by the limit of r
+func xmlText(r *io.LimitedReader, sb *textBuilder) error {
+	decoder := xml.NewDecoder(r)
+	inText := false
+	for !sb.full() {
+		token, err := decoder.Token()
+		if err == io.EOF || (err != nil && r.N <= 0) {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "si":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				_, _ = sb.Write(t)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"strings"
+	"unicode"
+)
+
+var (
+	streamStart = []byte("stream")
+	streamEnd   = []byte("endstream")
+)
+
+// pdfText extracts the text layer of a pdf from the text showing operators of its content streams.
+// The strings are decoded as PDFDocEncoding or utf-16, the text of the fonts with a custom encoding
+// can't be decoded without parsing the fonts and is dropped.
+func pdfText(data []byte, maxSize int) string {
+	sb := &textBuilder{max: maxSize}
+	for len(data) > 0 && !sb.full() {
+		i := bytes.Index(data, streamStart)
+		if i < 0 {
+			break
+		}
+		// the dictionary of the stream is in front of the keyword
+		dict := data[max(0, i-512):i]
+		if j := bytes.LastIndex(dict, []byte("obj")); j >= 0 {
+			dict = dict[j:]
+		}
+		data = data[i+len(streamStart):]
+		data = bytes.TrimPrefix(data, []byte("\r"))
+		data = bytes.TrimPrefix(data, []byte("\n"))
+		end := bytes.Index(data, streamEnd)
+		if end < 0 {
+			break
+		}
+		content := data[:end]
+		data = data[end+len(streamEnd):]
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			r, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			// a truncated stream still gives the text decoded so far, the content is limited
+			// as the text shown is never longer than it
+			content, _ = io.ReadAll(io.LimitReader(r, int64(maxSize)))
+		case bytes.Contains(dict, []byte("/Filter")):
+			// images and the other filters
+			continue
+		}
+		if bytes.Contains(content, []byte("BT")) {
+			contentText(content, sb)
+		}
+	}
+	return sb.String()
+}
+
+// contentText writes the text shown by the Tj, TJ, ' and " operators of a content stream
+func contentText(content []byte, sb *textBuilder) {
+	var (
+		operands []string
+		inArray  bool
+		array    strings.Builder
+	)
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := literalString(content[i:])
+			if inArray {
+				array.WriteString(s)
+			} else {
+				operands = append(operands, s)
+			}
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			s := hexString(content[i+1 : i+end])
+			if inArray {
+				array.WriteString(s)
+			} else {
+				operands = append(operands, s)
+			}
+			i += end + 1
+		case c == '[':
+			inArray = true
+			array.Reset()
+			i++
+		case c == ']':
+			inArray = false
+			operands = append(operands, array.String())
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isRegular(c):
+			start := i
+			for i < len(content) && isRegular(content[i]) {
+				i++
+			}
+			word := string(content[start:i])
+			if inArray {
+				// a large negative offset in a TJ array is a space between words
+				if n, ok := parseNumber(word); ok && n < -200 {
+					array.WriteByte(' ')
+				}
+				continue
+			}
+			switch word {
+			case "Tj", "TJ", "'", "\"":
+				if len(operands) > 0 {
+					if word != "Tj" && word != "TJ" {
+						sb.WriteByte('\n')
+					}
+					sb.WriteString(operands[len(operands)-1])
+				}
+			case "T*", "Td", "TD":
+				sb.WriteByte('\n')
+			case "ET":
+				sb.WriteByte('\n')
+			}
+			if _, ok := parseNumber(word); !ok && !strings.HasPrefix(word, "/") {
+				operands = operands[:0]
+			}
+		default:
+			i++
+		}
+	}
+}
+
+func isRegular(c byte) bool {
+	return !strings.ContainsRune(" \t\r\n\f\x00()<>[]{}%", rune(c))
+}
+
+func parseNumber(s string) (float64, bool) {
+	var (
+		n       float64
+		div     float64 = 1
+		neg     bool
+		decimal bool
+		digits  bool
+	)
+	for i, c := range s {
+		switch {
+		case i == 0 && (c == '-' || c == '+'):
+			neg = c == '-'
+		case c == '.' && !decimal:
+			decimal = true
+		case c >= '0' && c <= '9':
+			digits = true
+			n = n*10 + float64(c-'0')
+			if decimal {
+				div *= 10
+			}
+		default:
+			return 0, false
+		}
+	}
+	if neg {
+		n = -n
+	}
+	return n / div, digits
+}
+
+// literalString decodes the string at the start of b, and returns it with the number of bytes it takes
+func literalString(b []byte) (string, int) {
+	var buf []byte
+	depth := 0
+	i := 0
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return decodeString(buf), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(b) {
+				return decodeString(buf), i
+			}
+			switch e := b[i]; e {
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// a line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for j := 0; j < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; j++ {
+						v = v*8 + int(b[i]-'0')
+						i++
+					}
+					i--
+					buf = append(buf, byte(v))
+				} else {
+					buf = append(buf, e)
+				}
+			}
+			continue
+		}
+		buf = append(buf, c)
+	}
+	return decodeString(buf), i
+}
+
+func hexString(b []byte) string {
+	s := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, string(b))
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return ""
+	}
+	return decodeString(decoded)
+}
+
+// decodeString decodes a string in utf-16 with a BOM or in PDFDocEncoding, which mostly matches latin-1,
+// the strings of glyph ids with control characters are dropped
+func decodeString(b []byte) string {
+	if bytes.HasPrefix(b, []byte{0xfe, 0xff}) {
+		return decodeUTF16(b[2:], true)
+	}
+	runes := make([]rune, 0, len(b))
+	for _, c := range b {
+		if c < 0x20 && c != '\n' && c != '\r' && c != '\t' {
+			return ""
+		}
+		runes = append(runes, rune(c))
+	}
+	return string(runes)
+}
@@ -0,0 +1,231 @@
+package search
+
+import (
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+var objTypes = map[string]int{
+	"folder":  conf.FOLDER,
+	"dir":     conf.FOLDER,
+	"video":   conf.VIDEO,
+	"audio":   conf.AUDIO,
+	"text":    conf.TEXT,
+	"image":   conf.IMAGE,
+	"unknown": conf.UNKNOWN,
+	"other":   conf.UNKNOWN,
+}
+
+// ParseFilters moves the filters written in the keywords into the fields of the request, e.g.
+//
+//	size>1G size:100M..2G modified:2025-01..2025-06 modified>7d ext:mkv,mp4 type:video path:/movies sort:-size
+//
+// A relative time like 7d (also h and w) is the time as long ago. A date stands for the whole period,
+// e.g. modified>2025-01 matches the files modified after January. path is relative to the parent unless absolute.
+// The words which aren't filters are left as keywords.
+func ParseFilters(req *model.SearchReq) error {
+	var keywords []string
+	for _, word := range strings.Fields(req.Keywords) {
+		key, op, value := splitFilter(word)
+		var err error
+		switch key {
+		case "size":
+			err = parseSizeFilter(&req.SearchFilter, op, value)
+		case "modified":
+			err = parseModifiedFilter(&req.SearchFilter, op, value)
+		case "ext":
+			if op != ":" {
+				return errors.Errorf("invalid filter %s", word)
+			}
+			for _, ext := range strings.Split(value, ",") {
+				if ext = strings.ToLower(strings.TrimPrefix(ext, ".")); ext != "" {
+					req.Exts = append(req.Exts, ext)
+				}
+			}
+		case "type":
+			if op != ":" {
+				return errors.Errorf("invalid filter %s", word)
+			}
+			for _, name := range strings.Split(value, ",") {
+				t, ok := objTypes[strings.ToLower(name)]
+				if !ok {
+					return errors.Errorf("unknown type %s", name)
+				}
+				req.Types = append(req.Types, t)
+			}
+		case "path":
+			if op != ":" || value == "" {
+				return errors.Errorf("invalid filter %s", word)
+			}
+			if strings.HasPrefix(value, "/") {
+				req.Parent = value
+			} else {
+				req.Parent = stdpath.Join(req.Parent, value)
+			}
+		case "sort":
+			if op != ":" {
+				return errors.Errorf("invalid filter %s", word)
+			}
+			req.OrderBy, req.OrderDirection = strings.TrimPrefix(value, "-"), "asc"
+			if strings.HasPrefix(value, "-") {
+				req.OrderDirection = "desc"
+			}
+		default:
+			keywords = append(keywords, word)
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "invalid filter %s", word)
+		}
+	}
+	req.Keywords = strings.Join(keywords, " ")
+	return nil
+}
+
+// splitFilter splits key>value, key:value... the key is empty if the word isn't a filter
+func splitFilter(word string) (key, op, value string) {
+	i := strings.IndexAny(word, ":<>=")
+	if i <= 0 {
+		return "", "", ""
+	}
+	key, rest := strings.ToLower(word[:i]), word[i:]
+	for _, op := range []string{">=", "<=", ":", ">", "<", "="} {
+		if strings.HasPrefix(rest, op) {
+			return key, op, rest[len(op):]
+		}
+	}
+	return "", "", ""
+}
+
+// parseRange parses a value or a range of values, start..end with either side optional
+func parseRange(op, value string) (start, end string, isRange bool) {
+	if op == ":" {
+		if start, end, ok := strings.Cut(value, ".."); ok {
+			return start, end, true
+		}
+	}
+	return value, value, false
+}
+
+func parseSizeFilter(f *model.SearchFilter, op, value string) error {
+	start, end, isRange := parseRange(op, value)
+	if isRange {
+		if start != "" {
+			min, err := parseSize(start)
+			if err != nil {
+				return err
+			}
+			f.MinSize = &min
+		}
+		if end != "" {
+			max, err := parseSize(end)
+			if err != nil {
+				return err
+			}
+			f.MaxSize = &max
+		}
+		return nil
+	}
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">":
+		size++
+		f.MinSize = &size
+	case ">=":
+		f.MinSize = &size
+	case "<":
+		size--
+		f.MaxSize = &size
+	case "<=":
+		f.MaxSize = &size
+	default:
+		f.MinSize, f.MaxSize = &size, &size
+	}
+	return nil
+}
+
+// parseSize parses a size like 1024, 1.5G, 100MB or 10KiB, the units are powers of 1024
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+	unit := int64(1)
+	if i := strings.IndexAny(s, "KMGTP"); i >= 0 && i == len(s)-1 {
+		unit = 1 << (10 * (strings.IndexByte("KMGTP", s[i]) + 1))
+		s = s[:i]
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid size %s", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func parseModifiedFilter(f *model.SearchFilter, op, value string) error {
+	start, end, isRange := parseRange(op, value)
+	if isRange {
+		if start != "" {
+			from, _, err := parsePeriod(start)
+			if err != nil {
+				return err
+			}
+			f.ModifiedAfter = &from
+		}
+		if end != "" {
+			_, to, err := parsePeriod(end)
+			if err != nil {
+				return err
+			}
+			f.ModifiedBefore = &to
+		}
+		return nil
+	}
+	from, to, err := parsePeriod(value)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">":
+		f.ModifiedAfter = &to
+	case ">=":
+		f.ModifiedAfter = &from
+	case "<":
+		f.ModifiedBefore = &from
+	case "<=":
+		f.ModifiedBefore = &to
+	default:
+		f.ModifiedAfter, f.ModifiedBefore = &from, &to
+	}
+	return nil
+}
+
+// parsePeriod parses a date, a month, a year or a time as long ago as 7d, 12h or 2w, and returns the period [from, to)
+func parsePeriod(s string) (from, to time.Time, err error) {
+	if n, unit := strings.TrimRight(s, "hdw"), strings.TrimLeft(s, "0123456789"); n != "" && len(unit) == 1 {
+		count, err := strconv.Atoi(n)
+		if err == nil {
+			d := map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[unit]
+			t := time.Now().Add(-time.Duration(count) * d)
+			return t, t, nil
+		}
+	}
+	for _, layout := range []struct {
+		layout string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if t, err := time.ParseInLocation(layout.layout, s, time.Local); err == nil {
+			return t, layout.next(t), nil
+		}
+	}
+	return time.Time{}, time.Time{}, errors.Errorf("invalid time %s", s)
+}
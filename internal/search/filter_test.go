@@ -0,0 +1,72 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestParseFilters(t *testing.T) {
+	req := model.SearchReq{
+		Parent:   "/media",
+		Keywords: "holiday size>4G modified:2025-01..2025-06 ext:MKV,.mp4 type:video path:movies sort:-size a:b",
+	}
+	if err := ParseFilters(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Keywords != "holiday a:b" {
+		t.Errorf("the keywords are %q", req.Keywords)
+	}
+	if req.MinSize == nil || *req.MinSize != 4<<30+1 || req.MaxSize != nil {
+		t.Errorf("unexpected size filter %v %v", req.MinSize, req.MaxSize)
+	}
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local)
+	if req.ModifiedAfter == nil || !req.ModifiedAfter.Equal(from) || req.ModifiedBefore == nil || !req.ModifiedBefore.Equal(to) {
+		t.Errorf("unexpected modified filter %v %v", req.ModifiedAfter, req.ModifiedBefore)
+	}
+	if len(req.Exts) != 2 || req.Exts[0] != "mkv" || req.Exts[1] != "mp4" {
+		t.Errorf("unexpected exts %v", req.Exts)
+	}
+	if len(req.Types) != 1 || req.Types[0] != conf.VIDEO {
+		t.Errorf("unexpected types %v", req.Types)
+	}
+	if req.Parent != "/media/movies" || req.OrderBy != "size" || req.OrderDirection != "desc" {
+		t.Errorf("unexpected parent %s or order %s %s", req.Parent, req.OrderBy, req.OrderDirection)
+	}
+
+	match := func(node model.SearchNode) bool {
+		return req.Match(&node)
+	}
+	modified := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	if !match(model.SearchNode{Size: 5 << 30, Modified: modified, Ext: "mkv", ObjType: conf.VIDEO}) {
+		t.Errorf("the video should match")
+	}
+	if match(model.SearchNode{Size: 4 << 30, Modified: modified, Ext: "mkv", ObjType: conf.VIDEO}) {
+		t.Errorf("the size filter is exclusive")
+	}
+	if match(model.SearchNode{Size: 5 << 30, Modified: to, Ext: "mkv", ObjType: conf.VIDEO}) {
+		t.Errorf("the files modified in July shouldn't match")
+	}
+	if match(model.SearchNode{IsDir: true, Size: 5 << 30, Modified: modified, ObjType: conf.VIDEO}) {
+		t.Errorf("the folders shouldn't match a size filter")
+	}
+
+	for _, keywords := range []string{"size>big", "modified:yesterday", "type:movie", "ext>mkv"} {
+		if err := ParseFilters(&model.SearchReq{Keywords: keywords}); err == nil {
+			t.Errorf("%s should be invalid", keywords)
+		}
+	}
+	req = model.SearchReq{Keywords: "modified>7d size<=1.5MiB"}
+	if err := ParseFilters(&req); err != nil {
+		t.Fatal(err)
+	}
+	if since := time.Since(*req.ModifiedAfter); since < 7*24*time.Hour || since > 7*24*time.Hour+time.Minute {
+		t.Errorf("modified>7d is after %v", req.ModifiedAfter)
+	}
+	if *req.MaxSize != 3<<19 {
+		t.Errorf("size<=1.5MiB is %d", *req.MaxSize)
+	}
+}
@@ -6,4 +6,5 @@ import (
 	_ "github.com/alist-org/alist/v3/internal/search/db_non_full_text"
 	_ "github.com/alist-org/alist/v3/internal/search/meilisearch"
 	_ "github.com/alist-org/alist/v3/internal/search/noindex"
+	_ "github.com/alist-org/alist/v3/internal/search/sqlite_fts"
 )
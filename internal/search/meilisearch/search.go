@@ -8,7 +8,9 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/meilisearch/meilisearch-go"
+	"html"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +18,96 @@ import (
 type searchDocument struct {
 	ID string `json:"id"`
 	model.SearchNode
+	// the times can't be compared in the filters
+	ModifiedUnix int64 `json:"modified_unix"`
+}
+
+func toSearchNode(src map[string]any) model.SearchNode {
+	node := model.SearchNode{
+		Parent: src["parent"].(string),
+		Name:   src["name"].(string),
+		IsDir:  src["is_dir"].(bool),
+		Size:   int64(src["size"].(float64)),
+	}
+	// the fields are missing from the documents indexed before they were added
+	if modified, ok := src["modified"].(string); ok {
+		node.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+	}
+	node.Ext, _ = src["ext"].(string)
+	if objType, ok := src["obj_type"].(float64); ok {
+		node.ObjType = int(objType)
+	}
+	node.Hash, _ = src["hash"].(string)
+	return node
+}
+
+// the highlight tags are replaced with <mark> after the snippet is escaped
+const (
+	highlightPreTag  = "\ue000"
+	highlightPostTag = "\ue001"
+)
+
+// snippet returns the cropped content of the hit in html if the content matches
+func snippet(hit map[string]any) string {
+	formatted, _ := hit["_formatted"].(map[string]any)
+	content, _ := formatted["content"].(string)
+	if !strings.Contains(content, highlightPreTag) {
+		return ""
+	}
+	return strings.NewReplacer(highlightPreTag, "<mark>", highlightPostTag, "</mark>").Replace(html.EscapeString(content))
+}
+
+func searchFilter(req model.SearchReq) []string {
+	var filters []string
+	if req.Scope != 0 {
+		filters = append(filters, fmt.Sprintf("is_dir = %v", req.Scope == 1))
+	}
+	if req.FilesOnly() {
+		filters = append(filters, "is_dir = false")
+	}
+	if req.MinSize != nil {
+		filters = append(filters, fmt.Sprintf("size >= %d", *req.MinSize))
+	}
+	if req.MaxSize != nil {
+		filters = append(filters, fmt.Sprintf("size <= %d", *req.MaxSize))
+	}
+	if req.ModifiedAfter != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix >= %d", req.ModifiedAfter.Unix()))
+	}
+	if req.ModifiedBefore != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix < %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.Exts) > 0 {
+		exts, _ := utils.SliceConvert(req.Exts, func(ext string) (string, error) {
+			return "'" + strings.ReplaceAll(ext, "'", "\\'") + "'", nil
+		})
+		filters = append(filters, fmt.Sprintf("ext IN [%s]", strings.Join(exts, ",")))
+	}
+	if len(req.Types) > 0 {
+		types, _ := utils.SliceConvert(req.Types, func(t int) (string, error) {
+			return strconv.Itoa(t), nil
+		})
+		filters = append(filters, fmt.Sprintf("obj_type IN [%s]", strings.Join(types, ",")))
+	}
+	return filters
+}
+
+func searchSort(req model.SearchReq) []string {
+	direction := "asc"
+	if req.OrderDirection == "desc" {
+		direction = "desc"
+	}
+	switch req.OrderBy {
+	case "size":
+		return []string{"size:" + direction}
+	case "modified":
+		return []string{"modified_unix:" + direction}
+	case "name":
+		return []string{"name:" + direction}
+	default:
+		// by relevancy
+		return nil
+	}
 }
 
 type Meilisearch struct {
@@ -23,6 +115,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 }
 
 func (m *Meilisearch) Config() searcher.Config {
@@ -31,25 +124,28 @@ func (m *Meilisearch) Config() searcher.Config {
 
 func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	mReq := &meilisearch.SearchRequest{
-		AttributesToSearchOn: m.SearchableAttributes,
-		Page:                 int64(req.Page),
-		HitsPerPage:          int64(req.PerPage),
+		AttributesToSearchOn:  m.SearchableAttributes,
+		AttributesToCrop:      []string{"content"},
+		CropLength:            30,
+		AttributesToHighlight: []string{"content"},
+		HighlightPreTag:       highlightPreTag,
+		HighlightPostTag:      highlightPostTag,
+		Page:                  int64(req.Page),
+		HitsPerPage:           int64(req.PerPage),
 	}
-	if req.Scope != 0 {
-		mReq.Filter = fmt.Sprintf("is_dir = %v", req.Scope == 1)
+	if filters := searchFilter(req); len(filters) > 0 {
+		mReq.Filter = strings.Join(filters, " AND ")
 	}
+	mReq.Sort = searchSort(req)
 	search, err := m.Client.Index(m.IndexUid).Search(req.Keywords, mReq)
 	if err != nil {
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		hit := src.(map[string]any)
+		node := toSearchNode(hit)
+		node.Snippet = snippet(hit)
+		return node, nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -65,8 +161,9 @@ func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode)
 	documents, _ := utils.SliceConvert(nodes, func(src model.SearchNode) (*searchDocument, error) {
 
 		return &searchDocument{
-			ID:         uuid.NewString(),
-			SearchNode: src,
+			ID:           uuid.NewString(),
+			SearchNode:   src,
+			ModifiedUnix: src.Modified.Unix(),
 		}, nil
 	})
 
@@ -99,14 +196,11 @@ func (m *Meilisearch) getDocumentsByParent(ctx context.Context, parent string) (
 		return nil, err
 	}
 	return utils.SliceConvert(result.Results, func(src map[string]any) (*searchDocument, error) {
+		node := toSearchNode(src)
 		return &searchDocument{
-			ID: src["id"].(string),
-			SearchNode: model.SearchNode{
-				Parent: src["parent"].(string),
-				Name:   src["name"].(string),
-				IsDir:  src["is_dir"].(bool),
-				Size:   int64(src["size"].(float64)),
-			},
+			ID:           src["id"].(string),
+			SearchNode:   node,
+			ModifiedUnix: node.Modified.Unix(),
 		}, nil
 	})
 }
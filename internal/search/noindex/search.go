@@ -1,6 +1,7 @@
 package noindex
 
 import (
+	"cmp"
 	"context"
 	"path"
 	"path/filepath"
@@ -60,13 +61,11 @@ func (NoIndex) Search(ctx context.Context, req model.SearchReq) ([]model.SearchN
 		if (req.Scope == 1 && !info.IsDir()) || (req.Scope == 2 && info.IsDir()) {
 			return nil
 		}
-		if matchKeywords(info.GetName(), keywords) {
-			nodes = append(nodes, model.SearchNode{
-				Parent: path.Dir(reqPath),
-				Name:   info.GetName(),
-				IsDir:  info.IsDir(),
-				Size:   info.GetSize(),
-			})
+		if !matchKeywords(info.GetName(), keywords) {
+			return nil
+		}
+		if node := model.NewSearchNode(path.Dir(reqPath), info); req.Match(&node) {
+			nodes = append(nodes, node)
 			if len(nodes) >= maxResults {
 				return errStop
 			}
@@ -79,8 +78,8 @@ func (NoIndex) Search(ctx context.Context, req model.SearchReq) ([]model.SearchN
 
 	// stable ordering so pagination is consistent across repeated calls
 	sort.Slice(nodes, func(i, j int) bool {
-		if nodes[i].Name != nodes[j].Name {
-			return nodes[i].Name < nodes[j].Name
+		if c := compareNodes(&nodes[i], &nodes[j], req.OrderBy); c != 0 {
+			return (c < 0) != (req.OrderDirection == "desc")
 		}
 		return nodes[i].Parent < nodes[j].Parent
 	})
@@ -97,6 +96,21 @@ func (NoIndex) Search(ctx context.Context, req model.SearchReq) ([]model.SearchN
 	return nodes[start:end], total, nil
 }
 
+// compareNodes compares the nodes by the field orderBy then by name
+func compareNodes(a, b *model.SearchNode, orderBy string) int {
+	var c int
+	switch orderBy {
+	case "size":
+		c = cmp.Compare(a.Size, b.Size)
+	case "modified":
+		c = a.Modified.Compare(b.Modified)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
 // matchKeywords reports whether name contains every keyword (case-insensitive),
 // matching the AND semantics of the indexed searchers. Empty keywords match all.
 func matchKeywords(name string, keywords []string) bool {
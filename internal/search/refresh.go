@@ -0,0 +1,254 @@
+package search
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+// CanRefresh reports whether the current searcher can be updated incrementally
+func CanRefresh() bool {
+	return instance != nil && instance.Config().AutoUpdate
+}
+
+// Refresh updates the index incrementally. The storages with a change feed only sync the folders changed
+// since their cursor, the other ones are walked skipping the folders whose modified time and hash haven't
+// changed since they were indexed. A folder which isn't modified when a sub folder of it changes hides
+// the change from the walk, so such storages still need a rebuild now and then.
+func Refresh(ctx context.Context) error {
+	if instance == nil {
+		return errs.SearchNotAvailable
+	}
+	if !instance.Config().AutoUpdate {
+		return errs.UpdateNotSupported
+	}
+	quit := make(chan struct{}, 1)
+	if !Quit.CompareAndSwap(nil, &quit) {
+		return errs.BuildIndexIsRunning
+	}
+	defer Quit.Store(nil)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// stopped by StopIndex
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	r := &refresher{maxDepth: setting.GetInt(conf.MaxIndexDepth, 20)}
+	for _, storage := range op.GetAllStorages() {
+		s := storage.GetStorage()
+		if s.DisableIndex || s.Status != op.WORK || isIgnorePath(s.MountPath) {
+			continue
+		}
+		if err := r.refreshStorage(ctx, storage); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Errorf("failed refresh the index of [%s]: %+v", s.MountPath, err)
+		}
+	}
+	log.Infof("index refreshed, %d folders synced, %d objs indexed, %d deleted", r.synced, r.indexed, r.deleted)
+	return nil
+}
+
+// recentlyModified is how long after a folder is modified its version isn't saved, so that it's synced again
+const recentlyModified = 2 * time.Second
+
+type refresher struct {
+	maxDepth int
+	synced   int
+	indexed  int
+	deleted  int
+}
+
+func (r *refresher) refreshStorage(ctx context.Context, storage driver.Driver) error {
+	s := storage.GetStorage()
+	feed, ok := storage.(driver.ChangeFeed)
+	if !ok || !feed.ChangeFeedEnabled() {
+		return r.syncDir(ctx, s.MountPath)
+	}
+	cursor, err := db.GetIndexCursor(s.ID)
+	if err != nil {
+		return err
+	}
+	if cursor.Cursor != "" {
+		dirs, next, err := feed.Changes(ctx, cursor.Cursor)
+		if err == nil {
+			for _, dir := range dirs {
+				if err := r.syncChanged(ctx, s.MountPath, path.Join(s.MountPath, dir)); err != nil {
+					return err
+				}
+			}
+			cursor.Cursor = next
+			return db.SaveIndexCursor(cursor)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// e.g. the cursor has expired
+		log.Warnf("failed get the changes of [%s], walk it instead: %+v", s.MountPath, err)
+	}
+	// the cursor is taken before the walk so that the changes made during the walk aren't missed
+	_, next, err := feed.Changes(ctx, "")
+	if err != nil {
+		return err
+	}
+	if err = r.syncDir(ctx, s.MountPath); err != nil {
+		return err
+	}
+	cursor.Cursor = next
+	return db.SaveIndexCursor(cursor)
+}
+
+// syncChanged syncs the folder reported by a change feed, or the nearest parent of it which still exists
+func (r *refresher) syncChanged(ctx context.Context, mountPath, dir string) error {
+	for {
+		err := r.syncDir(ctx, dir)
+		if !errs.IsObjectNotFound(err) || dir == mountPath {
+			return err
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// syncDir updates the index of the entries of the folder, the entries which are gone are deleted and the
+// files which changed are indexed again. The sub folders which changed are synced in turn.
+func (r *refresher) syncDir(ctx context.Context, dir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(dir)
+	if err != nil {
+		return err
+	}
+	objs, err := op.List(ctx, storage, actualPath, model.ListArgs{Refresh: true, NoUpdateIndex: true})
+	if err != nil {
+		return err
+	}
+	nodes, err := instance.Get(ctx, dir)
+	if err != nil {
+		return err
+	}
+	r.synced++
+	indexed := make(map[string]model.SearchNode, len(nodes))
+	for _, node := range nodes {
+		indexed[node.Name] = node
+	}
+	var (
+		toIndex []ObjWithParent
+		subDirs []string
+		subObjs = make(map[string]model.Obj)
+	)
+	for _, obj := range objs {
+		p := path.Join(dir, obj.GetName())
+		if isIgnorePath(p) {
+			continue
+		}
+		node, ok := indexed[obj.GetName()]
+		delete(indexed, obj.GetName())
+		if !ok || node.IsDir != obj.IsDir() || (!obj.IsDir() && fileChanged(node, obj)) {
+			if ok {
+				if err := Del(ctx, p); err != nil {
+					return err
+				}
+				r.deleted++
+			}
+			toIndex = append(toIndex, ObjWithParent{Parent: dir, Obj: obj})
+		}
+		if obj.IsDir() && strings.Count(p, "/") <= r.maxDepth {
+			subDirs = append(subDirs, p)
+			subObjs[p] = obj
+		}
+	}
+	// the entries left are gone, or are ignored now
+	for name := range indexed {
+		p := path.Join(dir, name)
+		if op.HasStorage(p) {
+			continue
+		}
+		if err := Del(ctx, p); err != nil {
+			return err
+		}
+		r.deleted++
+	}
+	if err := BatchIndex(ctx, toIndex); err != nil {
+		return err
+	}
+	r.indexed += len(toIndex)
+
+	states, err := db.GetIndexedDirs(subDirs)
+	if err != nil {
+		return err
+	}
+	versions := make(map[string]model.IndexedDir, len(states))
+	for _, state := range states {
+		versions[state.Path] = state
+	}
+	for _, sub := range subDirs {
+		obj := subObjs[sub]
+		version := model.IndexedDir{Path: sub, Modified: obj.ModTime(), Hash: hashOf(obj)}
+		// the folders without a modified time are always synced
+		if state, ok := versions[sub]; ok && !version.Modified.IsZero() &&
+			state.Modified.Unix() == version.Modified.Unix() && state.Hash == version.Hash {
+			continue
+		}
+		if err := r.syncDir(ctx, sub); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// the folder is synced again next time
+			log.Warnf("failed sync the index of [%s]: %+v", sub, err)
+			continue
+		}
+		// the folder may be changed again within the same second, which can't be told apart
+		if time.Since(version.Modified) < recentlyModified {
+			continue
+		}
+		if err := db.SaveIndexedDir(&version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileChanged reports whether the file differs from the node it was indexed as,
+// the times are compared in seconds as some databases don't keep the rest
+func fileChanged(node model.SearchNode, obj model.Obj) bool {
+	if node.Size != obj.GetSize() || node.Modified.Unix() != obj.ModTime().Unix() {
+		return true
+	}
+	hash := hashOf(obj)
+	return node.Hash != "" && hash != "" && node.Hash != hash
+}
+
+func hashOf(obj model.Obj) string {
+	if hash := obj.GetHash(); len(hash.Export()) > 0 {
+		return hash.String()
+	}
+	return ""
+}
+
+func init() {
+	op.RegisterStorageHook(func(typ string, storage driver.Driver) {
+		if typ != "del" {
+			return
+		}
+		// the storage is walked again once it's back
+		if err := db.DeleteIndexCursor(storage.GetStorage().ID); err != nil {
+			log.Errorf("failed delete the index cursor of [%s]: %+v", storage.GetStorage().MountPath, err)
+		}
+	})
+}
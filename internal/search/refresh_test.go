@@ -0,0 +1,100 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	searchdb "github.com/alist-org/alist/v3/internal/search/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRefresh(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	instance = &searchdb.DB{}
+	defer func() { instance = nil }()
+	ctx := context.Background()
+
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the folders look unchanged since an hour ago
+	past := time.Now().Add(-time.Hour)
+	age := func(dirs ...string) {
+		t.Helper()
+		for _, dir := range dirs {
+			if err := os.Chtimes(filepath.Join(root, dir), past, past); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	names := func(parent string) []string {
+		t.Helper()
+		nodes, err := db.GetSearchNodesByParent(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, node := range nodes {
+			res = append(res, fmt.Sprintf("%s:%d", node.Name, node.Size))
+		}
+		sort.Strings(res)
+		return res
+	}
+	write("a.txt", "a")
+	write("sub/b.txt", "b")
+	write("sub/deep/c.txt", "c")
+	age("sub", "sub/deep")
+	_, err = op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	if err = Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(names("/local"), names("/local/sub"), names("/local/sub/deep")); got != "[a.txt:1 sub:0] [b.txt:1 deep:0] [c.txt:1]" {
+		t.Fatalf("unexpected index after the first refresh: %s", got)
+	}
+
+	write("a.txt", "aa")
+	write("sub/d.txt", "d")
+	if err = os.Remove(filepath.Join(root, "sub/b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	// a change the folder doesn't show is left to the rebuild
+	write("sub/deep/e.txt", "e")
+	age("sub/deep")
+	if err = Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(names("/local"), names("/local/sub"), names("/local/sub/deep")); got != "[a.txt:2 sub:0] [d.txt:1 deep:0] [c.txt:1]" {
+		t.Errorf("unexpected index after the second refresh: %s", got)
+	}
+}
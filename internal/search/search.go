@@ -48,6 +48,9 @@ func Init(mode string) error {
 }
 
 func Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	if instance == nil {
+		return nil, 0, errs.SearchNotAvailable
+	}
 	return instance.Search(ctx, req)
 }
 
@@ -55,12 +58,9 @@ func Index(ctx context.Context, parent string, obj model.Obj) error {
 	if instance == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
-	})
+	node := model.NewSearchNode(parent, obj)
+	fillContent(ctx, &node)
+	return instance.Index(ctx, node)
 }
 
 type ObjWithParent struct {
@@ -77,12 +77,9 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 	}
 	var searchNodes []model.SearchNode
 	for i := range objs {
-		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
-		})
+		node := model.NewSearchNode(objs[i].Parent, objs[i].Obj)
+		fillContent(ctx, &node)
+		searchNodes = append(searchNodes, node)
 	}
 	return instance.BatchIndex(ctx, searchNodes)
 }
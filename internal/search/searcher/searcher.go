@@ -9,6 +9,8 @@ import (
 type Config struct {
 	Name       string
 	AutoUpdate bool
+	// the content of the files is indexed and searched as well as the names
+	IndexContent bool
 }
 
 type Searcher interface {
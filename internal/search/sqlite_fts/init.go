@@ -0,0 +1,75 @@
+package sqlite_fts
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var config = searcher.Config{
+	Name:       "sqlite_fts",
+	AutoUpdate: true,
+}
+
+// the names are indexed by the tokens returned by tokens, and the fts table only stores the index,
+// the nodes are stored in their own table with the id as the rowid in the fts table
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS nodes (
+		id INTEGER PRIMARY KEY,
+		parent TEXT NOT NULL,
+		name TEXT NOT NULL,
+		is_dir INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		modified INTEGER NOT NULL,
+		ext TEXT NOT NULL,
+		obj_type INTEGER NOT NULL,
+		hash TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_nodes_parent ON nodes (parent, name)`,
+	// the prefix indexes speed up the short prefix queries
+	`CREATE VIRTUAL TABLE IF NOT EXISTS nodes_fts USING fts5 (
+		name, content='', contentless_delete=1, prefix='2 3', tokenize='unicode61 remove_diacritics 2'
+	)`,
+}
+
+func Init(path string) (*sql.DB, error) {
+	log.Debugf("sqlite fts path: %s", path)
+	// a small page cache, the index is mostly read from the disk
+	dB, err := gorm.Open(sqlite.Open(fmt.Sprintf("%s?_journal=WAL&_sync=NORMAL&_busy_timeout=5000&_cache_size=-2000", path)),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sqlDB, err := dB.DB()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, stmt := range schema {
+		if _, err = sqlDB.Exec(stmt); err != nil {
+			_ = sqlDB.Close()
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				return nil, errors.New("sqlite fts5 isn't available, alist must be built with -tags sqlite_fts5")
+			}
+			return nil, errors.WithStack(err)
+		}
+	}
+	return sqlDB, nil
+}
+
+func init() {
+	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
+		sqlDB, err := Init(conf.Conf.SQLiteFTSFile)
+		if err != nil {
+			return nil, err
+		}
+		return &SQLiteFTS{DB: sqlDB}, nil
+	})
+}
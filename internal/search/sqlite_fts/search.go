@@ -0,0 +1,237 @@
+package sqlite_fts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type SQLiteFTS struct {
+	DB *sql.DB
+}
+
+const nodeColumns = "n.parent, n.name, n.is_dir, n.size, n.modified, n.ext, n.obj_type, n.hash"
+
+func (s *SQLiteFTS) Config() searcher.Config {
+	return config
+}
+
+// where collects the conditions of a query and their args
+type where struct {
+	conds []string
+	args  []any
+}
+
+func (w *where) add(cond string, args ...any) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+// in adds the condition that the column is one of the values
+func in[T any](w *where, column string, values []T) {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	w.add(fmt.Sprintf("%s IN (%s)", column, strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")), args...)
+}
+
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// inParent adds the condition that the node is under the parent, as a range so that the index on parent is used
+func (w *where) inParent(column, parent string) {
+	if parent == "/" || parent == "" {
+		return
+	}
+	// '0' is the character after '/'
+	w.add(fmt.Sprintf("(%[1]s = ? OR (%[1]s >= ? AND %[1]s < ?))", column), parent, parent+"/", parent+"0")
+}
+
+func (w *where) filter(f *model.SearchFilter) {
+	if f.FilesOnly() {
+		w.add("n.is_dir = ?", false)
+	}
+	if f.MinSize != nil {
+		w.add("n.size >= ?", *f.MinSize)
+	}
+	if f.MaxSize != nil {
+		w.add("n.size <= ?", *f.MaxSize)
+	}
+	if f.ModifiedAfter != nil {
+		w.add("n.modified >= ?", f.ModifiedAfter.Unix())
+	}
+	if f.ModifiedBefore != nil {
+		w.add("n.modified < ?", f.ModifiedBefore.Unix())
+	}
+	if len(f.Exts) > 0 {
+		in(w, "n.ext", f.Exts)
+	}
+	if len(f.Types) > 0 {
+		in(w, "n.obj_type", f.Types)
+	}
+}
+
+func (s *SQLiteFTS) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	from, order := "nodes n", searchOrder(req, false)
+	var w where
+	if strings.TrimSpace(req.Keywords) != "" {
+		query := match(req.Keywords)
+		if query == "" {
+			return []model.SearchNode{}, 0, nil
+		}
+		from, order = "nodes_fts f JOIN nodes n ON n.id = f.rowid", searchOrder(req, true)
+		w.add("f.nodes_fts MATCH ?", query)
+	}
+	w.inParent("n.parent", req.Parent)
+	if req.Scope != 0 {
+		w.add("n.is_dir = ?", req.Scope == 1)
+	}
+	w.filter(&req.SearchFilter)
+
+	var count int64
+	err := s.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from+w.String(), w.args...).Scan(&count)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get search items count")
+	}
+	args := append(w.args, req.PerPage, (req.Page-1)*req.PerPage)
+	nodes, err := s.query(ctx, "SELECT "+nodeColumns+" FROM "+from+w.String()+" ORDER BY "+order+" LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return nodes, count, nil
+}
+
+// searchOrder orders by the rank of the match unless another order is asked for
+func searchOrder(req model.SearchReq, ranked bool) string {
+	direction := "ASC"
+	if req.OrderDirection == "desc" {
+		direction = "DESC"
+	}
+	switch req.OrderBy {
+	case "size", "modified":
+		return fmt.Sprintf("n.%s %s, n.name ASC", req.OrderBy, direction)
+	case "":
+		if ranked {
+			return "f.rank, n.name ASC"
+		}
+	}
+	return "n.name " + direction
+}
+
+func (s *SQLiteFTS) query(ctx context.Context, query string, args ...any) ([]model.SearchNode, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close()
+	nodes := []model.SearchNode{}
+	for rows.Next() {
+		var (
+			node     model.SearchNode
+			modified int64
+		)
+		err = rows.Scan(&node.Parent, &node.Name, &node.IsDir, &node.Size, &modified, &node.Ext, &node.ObjType, &node.Hash)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		node.Modified = time.Unix(modified, 0)
+		nodes = append(nodes, node)
+	}
+	return nodes, errors.WithStack(rows.Err())
+}
+
+func (s *SQLiteFTS) Index(ctx context.Context, node model.SearchNode) error {
+	return s.BatchIndex(ctx, []model.SearchNode{node})
+}
+
+func (s *SQLiteFTS) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer tx.Rollback()
+	insertNode, err := tx.PrepareContext(ctx, "INSERT INTO nodes (parent, name, is_dir, size, modified, ext, obj_type, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer insertNode.Close()
+	insertName, err := tx.PrepareContext(ctx, "INSERT INTO nodes_fts (rowid, name) VALUES (?, ?)")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer insertName.Close()
+	for _, node := range nodes {
+		res, err := insertNode.ExecContext(ctx, node.Parent, node.Name, node.IsDir, node.Size, node.Modified.Unix(), node.Ext, node.ObjType, node.Hash)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err = insertName.ExecContext(ctx, id, tokens(node.Name)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+func (s *SQLiteFTS) Get(ctx context.Context, parent string) ([]model.SearchNode, error) {
+	return s.query(ctx, "SELECT "+nodeColumns+" FROM nodes n WHERE n.parent = ?", parent)
+}
+
+// Del deletes the node of the path and the nodes under it
+func (s *SQLiteFTS) Del(ctx context.Context, path string) error {
+	path = utils.FixAndCleanPath(path)
+	if path == "/" {
+		return s.Clear(ctx)
+	}
+	var w where
+	w.add("(parent = ? OR (parent >= ? AND parent < ?) OR (parent = ? AND name = ?))",
+		path, path+"/", path+"0", stdpath.Dir(path), stdpath.Base(path))
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM nodes_fts WHERE rowid IN (SELECT id FROM nodes"+w.String()+")", w.args...); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM nodes"+w.String(), w.args...); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+func (s *SQLiteFTS) Release(ctx context.Context) error {
+	return s.DB.Close()
+}
+
+func (s *SQLiteFTS) Clear(ctx context.Context) error {
+	for _, stmt := range []string{
+		"INSERT INTO nodes_fts (nodes_fts) VALUES ('delete-all')",
+		"DELETE FROM nodes",
+		// gives the space back to the disk
+		"VACUUM",
+	} {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+var _ searcher.Searcher = (*SQLiteFTS)(nil)
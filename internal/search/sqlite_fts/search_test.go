@@ -0,0 +1,104 @@
+package sqlite_fts
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestTokens(t *testing.T) {
+	cases := map[string]string{
+		"MyVacation2024.mkv": "MyVacation2024 My Vacation 2024 mkv",
+		"HTMLParser_v2.go":   "HTMLParser HTML Parser v2 v 2 go",
+		"千与千寻.1080p.mp4":     "千 与 千 寻 1080p 1080 p mp4 mp 4",
+		"となりのトトロ (1988).avi": "と な り の ト ト ロ 1988 avi",
+	}
+	for name, want := range cases {
+		if got := tokens(name); got != want {
+			t.Errorf("tokens(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got, want := match(`vac 千寻 "x`), `"vac"* AND "千 寻" AND "x"*`; got != want {
+		t.Errorf("match = %q, want %q", got, want)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	sqlDB, err := Init(filepath.Join(t.TempDir(), "fts.db"))
+	if err != nil {
+		if strings.Contains(err.Error(), "fts5") {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	s := &SQLiteFTS{DB: sqlDB}
+	defer s.Release(context.Background())
+	ctx := context.Background()
+	modified := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	err = s.BatchIndex(ctx, []model.SearchNode{
+		{Parent: "/movies", Name: "千与千寻.1080p.mp4", Size: 3 << 30, Modified: modified, Ext: "mp4"},
+		{Parent: "/movies", Name: "MyVacation2024.mkv", Size: 1 << 30, Modified: modified, Ext: "mkv"},
+		{Parent: "/movies/vacation", Name: "beach.jpg", Size: 1 << 20, Modified: modified, Ext: "jpg"},
+		{Parent: "/movies", Name: "vacation", IsDir: true, Modified: modified},
+		{Parent: "/movies-old", Name: "Vacation.avi", Size: 1 << 30, Modified: modified, Ext: "avi"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	search := func(req model.SearchReq) []string {
+		t.Helper()
+		req.PageReq = model.PageReq{Page: 1, PerPage: 10}
+		nodes, total, err := s.Search(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(total) != len(nodes) {
+			t.Errorf("total %d of %d results", total, len(nodes))
+		}
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+	for _, c := range []struct {
+		req  model.SearchReq
+		want []string
+	}{
+		{model.SearchReq{Parent: "/", Keywords: "千寻"}, []string{"千与千寻.1080p.mp4"}},
+		{model.SearchReq{Parent: "/", Keywords: "寻千"}, nil},
+		{model.SearchReq{Parent: "/", Keywords: "vaca"}, []string{"vacation", "MyVacation2024.mkv", "Vacation.avi"}},
+		{model.SearchReq{Parent: "/movies", Keywords: "vaca", Scope: 2}, []string{"MyVacation2024.mkv"}},
+		{model.SearchReq{Parent: "/movies", Keywords: "myvac 2024"}, []string{"MyVacation2024.mkv"}},
+		{model.SearchReq{Parent: "/movies", OrderBy: "size", OrderDirection: "desc"},
+			[]string{"千与千寻.1080p.mp4", "MyVacation2024.mkv", "beach.jpg", "vacation"}},
+		{model.SearchReq{Parent: "/", SearchFilter: model.SearchFilter{Exts: []string{"jpg", "avi"}}},
+			[]string{"Vacation.avi", "beach.jpg"}},
+	} {
+		got := search(c.req)
+		// the ranks of the equal matches aren't defined
+		if c.req.Keywords != "" {
+			slices.Sort(got)
+			slices.Sort(c.want)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("search %+v: got %v, want %v", c.req, got, c.want)
+		}
+	}
+
+	if err = s.Del(ctx, "/movies/vacation"); err != nil {
+		t.Fatal(err)
+	}
+	if got := search(model.SearchReq{Parent: "/", Keywords: "vaca", OrderBy: "name"}); !slices.Equal(got, []string{"MyVacation2024.mkv", "Vacation.avi"}) {
+		t.Errorf("unexpected results after deleting: %v", got)
+	}
+	nodes, err := s.Get(ctx, "/movies")
+	if err != nil || len(nodes) != 2 || !nodes[0].Modified.Equal(modified) {
+		t.Errorf("unexpected nodes %+v: %+v", nodes, err)
+	}
+}
@@ -0,0 +1,91 @@
+package sqlite_fts
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isCJK reports whether r is written without spaces between the words, every such character is a token
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWordChar(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)) && !isCJK(r)
+}
+
+// scan calls word for the words and cjk for the runs of CJK characters in s, the other characters are separators
+func scan(s string, word func(string), cjk func([]rune)) {
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isCJK(runes[i]):
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			cjk(runes[i:j])
+		case isWordChar(runes[i]):
+			for j < len(runes) && isWordChar(runes[j]) {
+				j++
+			}
+			word(string(runes[i:j]))
+		}
+		i = j
+	}
+}
+
+// splitWord splits a word where the case changes or the letters and digits meet, e.g. HTMLParser2 into HTML Parser 2
+func splitWord(word string) []string {
+	runes := []rune(word)
+	var parts []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		if unicode.IsDigit(prev) != unicode.IsDigit(cur) ||
+			(unicode.IsLower(prev) && unicode.IsUpper(cur)) ||
+			(unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// tokens returns the text indexed for the name, the CJK characters are separated so that every one is a token,
+// and a word is indexed as a whole and by its parts, e.g. MyVacation2024 as MyVacation2024 My Vacation 2024
+func tokens(name string) string {
+	var toks []string
+	scan(name, func(word string) {
+		toks = append(toks, word)
+		if parts := splitWord(word); len(parts) > 1 {
+			toks = append(toks, parts...)
+		}
+	}, func(run []rune) {
+		for _, r := range run {
+			toks = append(toks, string(r))
+		}
+	})
+	return strings.Join(toks, " ")
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// match returns the fts5 query matching the names containing all the keywords, the words match the tokens
+// they are a prefix of, and the CJK characters match where they are next to each other in the name.
+// It's empty if the keywords have nothing to match
+func match(keywords string) string {
+	var terms []string
+	scan(keywords, func(word string) {
+		terms = append(terms, quote(word)+"*")
+	}, func(run []rune) {
+		chars := make([]string, len(run))
+		for i, r := range run {
+			chars[i] = string(r)
+		}
+		terms = append(terms, quote(strings.Join(chars, " ")))
+	})
+	return strings.Join(terms, " AND ")
+}
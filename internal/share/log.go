@@ -0,0 +1,49 @@
+package share
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// LogAccess records an access to the share, the ip is masked before being saved
+func LogAccess(share *model.Share, action, path, ip, userAgent string, bytes int64) {
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	err := db.CreateShareAccessLog(&model.ShareAccessLog{
+		ShareID:   share.ShareID,
+		Action:    action,
+		Path:      path,
+		IP:        utils.MaskIP(ip),
+		UserAgent: userAgent,
+		Bytes:     bytes,
+	})
+	if err != nil {
+		log.Errorf("failed log access of share %s: %+v", share.ShareID, err)
+	}
+}
+
+// RollupAccessLogs updates the daily statistics of yesterday and today, then removes
+// the logs older than the retention. It's run hourly, so today is never far behind.
+func RollupAccessLogs() {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		if err := db.RollupShareAccess(day); err != nil {
+			log.Errorf("failed roll up share access logs of %s: %+v", day.Format("2006-01-02"), err)
+		}
+	}
+	days := setting.GetInt(conf.ShareAccessLogRetentionDays, 180)
+	if days <= 0 {
+		return
+	}
+	if err := db.DeleteShareAccessLogsBefore(today.AddDate(0, 0, -days)); err != nil {
+		log.Errorf("failed purge share access logs: %+v", err)
+	}
+}
@@ -2,7 +2,9 @@ package task
 
 import (
 	"context"
+	"errors"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/xhofe/tache"
 	"sync"
@@ -67,6 +69,36 @@ func (t *TaskExtension) Ctx() context.Context {
 	return t.ctx
 }
 
+// SetState publishes the end of the task, which is succeeded, canceled or failed after all the retries.
+// tache ends a canceled task as failed too, it's told apart by its error.
+func (t *TaskExtension) SetState(state tache.State) {
+	t.Base.SetState(state)
+	var typ string
+	switch state {
+	case tache.StateSucceeded:
+		typ = event.TaskSucceeded
+	case tache.StateFailed:
+		typ = event.TaskFailed
+		if errors.Is(t.GetErr(), context.Canceled) {
+			typ = event.TaskCanceled
+		}
+	default:
+		return
+	}
+	data := map[string]any{"id": t.GetID()}
+	if typ, tsk, ok := lookup(t.GetID()); ok {
+		data["type"] = typ
+		data["name"] = tsk.GetName()
+	}
+	if t.Creator != nil {
+		data["creator"] = t.Creator.Username
+	}
+	if err := t.GetErr(); err != nil {
+		data["error"] = err.Error()
+	}
+	event.Publish(typ, data)
+}
+
 func (t *TaskExtension) ReinitCtx() {
 	if !conf.Conf.Tasks.AllowRetryCanceled {
 		return
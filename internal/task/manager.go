@@ -1,6 +1,10 @@
 package task
 
-import "github.com/xhofe/tache"
+import (
+	"sync"
+
+	"github.com/xhofe/tache"
+)
 
 type Manager[T tache.Task] interface {
 	Add(task T)
@@ -18,3 +22,35 @@ type Manager[T tache.Task] interface {
 	Retry(id string)
 	RetryAllFailed()
 }
+
+type managerOfType struct {
+	typ string
+	get func(id string) (tache.TaskWithInfo, bool)
+}
+
+// managers are looked up by the task events, which tell the name and the type of the task
+var (
+	managersMu sync.RWMutex
+	managers   []managerOfType
+)
+
+// RegisterManager names the type of the tasks of the manager
+func RegisterManager[T tache.TaskWithInfo](typ string, m Manager[T]) {
+	managersMu.Lock()
+	defer managersMu.Unlock()
+	managers = append(managers, managerOfType{typ: typ, get: func(id string) (tache.TaskWithInfo, bool) {
+		return m.GetByID(id)
+	}})
+}
+
+// lookup finds the task of id and the type of its manager
+func lookup(id string) (string, tache.TaskWithInfo, bool) {
+	managersMu.RLock()
+	defer managersMu.RUnlock()
+	for _, m := range managers {
+		if t, ok := m.get(id); ok {
+			return m.typ, t, true
+		}
+	}
+	return "", nil, false
+}
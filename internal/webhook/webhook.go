@@ -0,0 +1,206 @@
+// Package webhook sends the events of the event bus to the webhooks registered by the admins
+package webhook
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxAttempts is the number of attempts before a delivery is failed
+	MaxAttempts = 8
+	// the delay before the n-th retry is retryDelay * 2^(n-1), at most maxRetryDelay
+	retryDelay    = 30 * time.Second
+	maxRetryDelay = time.Hour
+	timeout       = 15 * time.Second
+	// the response body kept in the delivery log is truncated to maxResponseLen
+	maxResponseLen = 1024
+	// signExpire is how long the signature of a delivery stays valid
+	signExpire = 5 * time.Minute
+)
+
+var (
+	mu       sync.RWMutex
+	webhooks []model.Webhook
+	// inflight holds the ids of the deliveries being sent, so that the poller
+	// doesn't send them a second time
+	inflight sync.Map
+)
+
+// Init loads the webhooks and subscribes them to the event bus
+func Init() {
+	Reload()
+	event.Subscribe(handle)
+}
+
+// Reload refreshes the webhooks in memory, it must be called after changing them
+func Reload() {
+	hooks, err := op.GetEnabledWebhooks()
+	if err != nil {
+		log.Errorf("failed load webhooks: %+v", err)
+		return
+	}
+	mu.Lock()
+	webhooks = hooks
+	mu.Unlock()
+}
+
+func subscribed(w *model.Webhook, typ string) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, t := range strings.Split(w.Events, ",") {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func handle(e *event.Event) {
+	mu.RLock()
+	hooks := webhooks
+	mu.RUnlock()
+	var payload string
+	for i := range hooks {
+		if !subscribed(&hooks[i], e.Type) {
+			continue
+		}
+		if payload == "" {
+			var err error
+			if payload, err = utils.Json.MarshalToString(e); err != nil {
+				log.Errorf("failed marshal event %s: %+v", e.Type, err)
+				return
+			}
+		}
+		d := &model.WebhookDelivery{
+			WebhookID:     hooks[i].ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       payload,
+			State:         model.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := op.CreateWebhookDelivery(d); err != nil {
+			log.Errorf("failed create webhook delivery: %+v", err)
+			continue
+		}
+		go Deliver(d)
+	}
+}
+
+func backoff(attempts int) time.Duration {
+	delay := retryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// Deliver sends the delivery to its webhook and records the result,
+// it's scheduled for a retry on failure until MaxAttempts.
+func Deliver(d *model.WebhookDelivery) {
+	if _, loaded := inflight.LoadOrStore(d.ID, struct{}{}); loaded {
+		return
+	}
+	defer inflight.Delete(d.ID)
+	w, err := op.GetWebhookById(d.WebhookID)
+	if err != nil {
+		log.Errorf("failed get webhook of delivery %d: %+v", d.ID, err)
+		return
+	}
+	d.Attempts++
+	if w.Disabled {
+		d.State, d.Error = model.DeliveryFailed, "the webhook is disabled"
+	} else if d.StatusCode, d.Response, err = send(w, d); err == nil {
+		d.State, d.Error = model.DeliverySucceeded, ""
+	} else {
+		d.Error = err.Error()
+		if d.Attempts >= MaxAttempts {
+			d.State = model.DeliveryFailed
+		} else {
+			d.NextAttemptAt = time.Now().Add(backoff(d.Attempts))
+		}
+	}
+	if err := op.UpdateWebhookDelivery(d); err != nil {
+		log.Errorf("failed update webhook delivery %d: %+v", d.ID, err)
+	}
+}
+
+func send(w *model.Webhook, d *model.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req := base.NoRedirectClient.R().SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-Alist-Event", d.EventType).
+		SetHeader("X-Alist-Delivery", strconv.FormatUint(uint64(d.ID), 10)).
+		SetBody(d.Payload)
+	if w.Secret != "" {
+		signature := sign.NewHMACSign([]byte(w.Secret)).Sign(d.Payload, time.Now().Add(signExpire).Unix())
+		req.SetHeader("X-Alist-Signature", signature)
+	}
+	res, err := req.Post(w.URL)
+	if err != nil {
+		return 0, "", err
+	}
+	body := res.String()
+	if len(body) > maxResponseLen {
+		body = body[:maxResponseLen]
+	}
+	if res.StatusCode() < 200 || res.StatusCode() >= 300 {
+		return res.StatusCode(), body, errUnexpectedStatus(res.StatusCode())
+	}
+	return res.StatusCode(), body, nil
+}
+
+type errUnexpectedStatus int
+
+func (e errUnexpectedStatus) Error() string {
+	return "unexpected status code " + strconv.Itoa(int(e))
+}
+
+// Redeliver sends the delivery again whatever its state is
+func Redeliver(d *model.WebhookDelivery) {
+	d.State = model.DeliveryPending
+	d.NextAttemptAt = time.Now()
+	go Deliver(d)
+}
+
+// RetryDue sends the pending deliveries whose next attempt is due
+func RetryDue() {
+	deliveries, err := op.GetDueWebhookDeliveries()
+	if err != nil {
+		log.Errorf("failed get due webhook deliveries: %+v", err)
+		return
+	}
+	for i := range deliveries {
+		Deliver(&deliveries[i])
+	}
+}
+
+// PurgeDeliveries removes the finished deliveries older than the retention from the log
+func PurgeDeliveries() {
+	days := setting.GetInt(conf.WebhookDeliveryRetentionDays, 30)
+	if days <= 0 {
+		return
+	}
+	if err := op.DeleteWebhookDeliveriesUpdatedBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		log.Errorf("failed purge webhook deliveries: %+v", err)
+	}
+}
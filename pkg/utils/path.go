@@ -43,6 +43,15 @@ func IsSubPath(path string, subPath string) bool {
 	return path == subPath || strings.HasPrefix(subPath, PathAddSeparatorSuffix(path))
 }
 
+// RelativePath returns the path relative to base, e.g. /a/b/c relative to /a is /b/c,
+// ok is false if the path isn't under base
+func RelativePath(base, path string) (string, bool) {
+	if !IsSubPath(base, path) {
+		return "", false
+	}
+	return FixAndCleanPath(strings.TrimPrefix(FixAndCleanPath(path), FixAndCleanPath(base))), true
+}
+
 func Ext(path string) string {
 	ext := stdpath.Ext(path)
 	if len(ext) > 0 && ext[0] == '.' {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pkg/errors"
@@ -76,3 +77,8 @@ func IsTokenInvalidated(tokenString string) bool {
 	_, ok := validTokenCache.Get(tokenString)
 	return !ok
 }
+
+// PublishLoginFailed publishes the failed login of username, from any of the login paths
+func PublishLoginFailed(username, ip, reason string) {
+	event.Publish(event.LoginFailed, map[string]any{"username": username, "ip": ip, "reason": reason})
+}
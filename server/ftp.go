@@ -122,12 +122,15 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 			return nil, err
 		}
 	} else {
+		ip, _, _ := net.SplitHostPort(cc.RemoteAddr().String())
 		userObj, err = op.GetUserByName(user)
 		if err != nil {
+			common.PublishLoginFailed(user, ip, "user not found")
 			return nil, err
 		}
 		passHash := model.StaticHash(pass)
 		if err = userObj.ValidatePwdStaticHash(passHash); err != nil {
+			common.PublishLoginFailed(user, ip, "wrong password")
 			return nil, err
 		}
 	}
@@ -106,16 +106,20 @@ func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserve
 		if offset != 0 {
 			return nil, errs.NotSupport
 		}
-		trunc := (flags & os.O_TRUNC) != 0
+		// the upload always replaces the whole file, O_TRUNC makes no difference
 		if fileSize > 0 {
-			return OpenUploadWithLength(a.ctx, path, trunc, fileSize)
+			return OpenUploadWithLength(a.ctx, path, fileSize)
 		} else {
-			return OpenUpload(a.ctx, path, trunc)
+			return OpenUpload(a.ctx, path)
 		}
 	}
 	return OpenDownload(a.ctx, path, offset)
 }
 
+func (a *AferoAdapter) GetAvailableSpace(dirName string) (int64, error) {
+	return AvailableSpace(a.ctx, dirName)
+}
+
 func (a *AferoAdapter) SetNextFileSize(size int64) {
 	a.nextFileSize = size
 }
@@ -135,6 +135,32 @@ func Stat(ctx context.Context, path string) (os.FileInfo, error) {
 	return &OsFileInfoAdapter{obj: obj}, nil
 }
 
+// AvailableSpace returns the free space of the storage the path is in, for the AVBL command
+func AvailableSpace(ctx context.Context, path string) (int64, error) {
+	user := ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return 0, err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return 0, err
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, ctx.Value("meta_pass").(string)) {
+		return 0, errs.PermissionDenied
+	}
+	details, err := fs.GetStorageDetails(ctx, reqPath)
+	if err != nil {
+		return 0, err
+	}
+	if details.TotalSpace <= 0 {
+		return 0, errors.New("the capacity of the storage is unknown")
+	}
+	return details.FreeSpace, nil
+}
+
 func List(ctx context.Context, path string) ([]os.FileInfo, error) {
 	user := ctx.Value("user").(*model.User)
 	reqPath, err := user.JoinPath(path)
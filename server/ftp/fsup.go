@@ -24,7 +24,6 @@ type FileUploadProxy struct {
 	buffer *os.File
 	path   string
 	ctx    context.Context
-	trunc  bool
 }
 
 func uploadAuth(ctx context.Context, path string) error {
@@ -44,7 +43,7 @@ func uploadAuth(ctx context.Context, path string) error {
 	return nil
 }
 
-func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy, error) {
+func OpenUpload(ctx context.Context, path string) (*FileUploadProxy, error) {
 	err := uploadAuth(ctx, path)
 	if err != nil {
 		return nil, err
@@ -53,7 +52,7 @@ func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy,
 	if err != nil {
 		return nil, err
 	}
-	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx, trunc: trunc}, nil
+	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx}, nil
 }
 
 func (f *FileUploadProxy) Read(p []byte) (n int, err error) {
@@ -90,9 +89,6 @@ func (f *FileUploadProxy) Close() error {
 	if _, err := f.buffer.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
-	if f.trunc {
-		_ = fs.Remove(f.ctx, f.path)
-	}
 	s := &stream.FileStream{
 		Obj: &model.Object{
 			Name:     name,
@@ -118,14 +114,11 @@ type FileUploadWithLengthProxy struct {
 	errChan       chan error
 }
 
-func OpenUploadWithLength(ctx context.Context, path string, trunc bool, length int64) (*FileUploadWithLengthProxy, error) {
+func OpenUploadWithLength(ctx context.Context, path string, length int64) (*FileUploadWithLengthProxy, error) {
 	err := uploadAuth(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	if trunc {
-		_ = fs.Remove(ctx, path)
-	}
 	return &FileUploadWithLengthProxy{ctx: ctx, path: path, length: length}, nil
 }
 
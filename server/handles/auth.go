@@ -72,12 +72,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	if err != nil {
 		common.ErrorStrResp(c, invalidLoginCredentialsMsg, 400)
 		loginCache.Set(ip, count+1)
+		common.PublishLoginFailed(req.Username, ip, "user not found")
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorStrResp(c, invalidLoginCredentialsMsg, 400)
 		loginCache.Set(ip, count+1)
+		common.PublishLoginFailed(req.Username, ip, "wrong password")
 		return
 	}
 	// check 2FA
@@ -85,6 +87,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
 			loginCache.Set(ip, count+1)
+			common.PublishLoginFailed(req.Username, ip, "wrong 2fa code")
 			return
 		}
 	}
@@ -0,0 +1,62 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type EncryptionKeyCreateReq struct {
+	Name string `json:"name" binding:"required"`
+	// Password is generated if it's empty
+	Password string `json:"password"`
+}
+
+type EncryptionKeyCreateResp struct {
+	model.EncryptionKey
+	// Password is only returned once, when the key is created
+	Password string `json:"password"`
+}
+
+func ListEncryptionKeys(c *gin.Context) {
+	keys, err := op.GetEncryptionKeys()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, keys)
+}
+
+func CreateEncryptionKey(c *gin.Context) {
+	var req EncryptionKeyCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	key, password, err := op.CreateEncryptionKey(req.Name, req.Password)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, EncryptionKeyCreateResp{
+		EncryptionKey: *key,
+		Password:      password,
+	})
+}
+
+func DeleteEncryptionKey(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteEncryptionKeyById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
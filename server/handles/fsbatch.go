@@ -1,10 +1,12 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"slices"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -28,6 +30,10 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if !fs.IsValidConflictPolicy(req.ConflictPolicy) {
+		common.ErrorStrResp(c, "Invalid conflict policy", 400)
+		return
+	}
 
 	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
@@ -69,8 +75,10 @@ func FsRecursiveMove(c *gin.Context) {
 		return
 	}
 
+	// cancel and skip are decided on the names up front, the other
+	// policies are applied by fs.Move when each file gets moved
 	var existingFileNames []string
-	if req.ConflictPolicy != OVERWRITE {
+	if req.ConflictPolicy == CANCEL || req.ConflictPolicy == SKIP {
 		dstFiles, err := fs.List(c, dstDir, &fs.ListArgs{})
 		if err != nil {
 			common.ErrorResp(c, err, 500)
@@ -121,7 +129,7 @@ func FsRecursiveMove(c *gin.Context) {
 				} else if req.ConflictPolicy == SKIP {
 					continue
 				}
-			} else if req.ConflictPolicy != OVERWRITE {
+			} else if req.ConflictPolicy == CANCEL || req.ConflictPolicy == SKIP {
 				existingFileNames = append(existingFileNames, movingFile.GetName())
 			}
 			movingFileNames = append(movingFileNames, movingFileName)
@@ -130,10 +138,11 @@ func FsRecursiveMove(c *gin.Context) {
 
 	}
 
+	ctx := context.WithValue(c, conf.ConflictPolicyKey, req.ConflictPolicy)
 	var count = 0
 	for i, fileName := range movingFileNames {
 		// move
-		err := fs.Move(c, fileName, dstDir, len(movingFileNames) > i+1)
+		err := fs.Move(ctx, fileName, dstDir, len(movingFileNames) > i+1)
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -71,6 +71,21 @@ type MoveCopyReq struct {
 	// SkipExisting only takes effect on copy: existing destination files
 	// with the same size are skipped instead of failing the request
 	SkipExisting bool `json:"skip_existing"`
+	// ConflictPolicy takes precedence over Overwrite and SkipExisting, see fs.ConflictOverwrite
+	ConflictPolicy string `json:"conflict_policy"`
+}
+
+// conflictPolicy merges the legacy overwrite and skip_existing flags into the conflict policy
+func (r *MoveCopyReq) conflictPolicy() string {
+	switch {
+	case r.ConflictPolicy != "":
+		return r.ConflictPolicy
+	case r.Overwrite:
+		return fs.ConflictOverwrite
+	case r.SkipExisting:
+		return fs.ConflictSkipSameSize
+	}
+	return fs.ConflictCancel
 }
 
 func FsMove(c *gin.Context) {
@@ -83,6 +98,11 @@ func FsMove(c *gin.Context) {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
 	}
+	policy := req.conflictPolicy()
+	if !fs.IsValidConflictPolicy(policy) {
+		common.ErrorStrResp(c, "Invalid conflict policy", 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
@@ -107,7 +127,7 @@ func FsMove(c *gin.Context) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		return
 	}
-	if !req.Overwrite {
+	if policy == fs.ConflictCancel {
 		for _, name := range req.Names {
 			dstPath, err := utils.JoinUnderBase(dstDir, name)
 			if err != nil {
@@ -120,6 +140,7 @@ func FsMove(c *gin.Context) {
 			}
 		}
 	}
+	ctx := context.WithValue(c, conf.ConflictPolicyKey, policy)
 	for i, name := range req.Names {
 		srcPath, err := utils.JoinUnderBase(srcDir, name)
 		if err != nil {
@@ -131,7 +152,7 @@ func FsMove(c *gin.Context) {
 			common.ErrorResp(c, err, 400)
 			return
 		}
-		err = fs.Move(c, srcPath, dstDir, len(req.Names) > i+1)
+		err = fs.Move(ctx, srcPath, dstDir, len(req.Names) > i+1)
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -150,6 +171,11 @@ func FsCopy(c *gin.Context) {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
 	}
+	policy := req.conflictPolicy()
+	if !fs.IsValidConflictPolicy(policy) {
+		common.ErrorStrResp(c, "Invalid conflict policy", 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
@@ -174,7 +200,7 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		return
 	}
-	if !req.Overwrite && !req.SkipExisting {
+	if policy == fs.ConflictCancel {
 		for _, name := range req.Names {
 			dstPath, err := utils.JoinUnderBase(dstDir, name)
 			if err != nil {
@@ -187,10 +213,7 @@ func FsCopy(c *gin.Context) {
 			}
 		}
 	}
-	var ctx context.Context = c
-	if req.SkipExisting {
-		ctx = context.WithValue(ctx, conf.SkipExistingKey, struct{}{})
-	}
+	ctx := context.WithValue(c, conf.ConflictPolicyKey, policy)
 	var addedTasks []task.TaskExtensionInfo
 	for i, name := range req.Names {
 		srcPath, err := utils.JoinUnderBase(srcDir, name)
@@ -61,6 +61,8 @@ type FsListResp struct {
 	Header        string         `json:"header"`
 	Write         bool           `json:"write"`
 	Provider      string         `json:"provider"`
+	// MountDetails is the capacity of the storage, only set for its mount root
+	MountDetails *model.StorageDetails `json:"mount_details,omitempty"`
 }
 
 type ObjLabelResp struct {
@@ -120,9 +122,13 @@ func FsList(c *gin.Context) {
 		return
 	}
 	provider := "unknown"
+	var mountDetails *model.StorageDetails
 	storage, storageErr := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	if storageErr == nil {
 		provider = storage.GetStorage().Driver
+		if utils.PathEqual(reqPath, utils.GetActualMountPath(storage.GetStorage().MountPath)) {
+			mountDetails, _ = op.GetStorageDetails(c, storage)
+		}
 	}
 	objs, err := fs.List(c, reqPath, &fs.ListArgs{Refresh: req.Refresh})
 	if err != nil {
@@ -153,6 +159,7 @@ func FsList(c *gin.Context) {
 		Header:        getHeader(meta, reqPath),
 		Write:         common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, reqPath),
 		Provider:      provider,
+		MountDetails:  mountDetails,
 	})
 }
 
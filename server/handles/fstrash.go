@@ -0,0 +1,99 @@
+package handles
+
+import (
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func FsTrashList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	var userId uint
+	if !user.IsAdmin() {
+		userId = user.ID
+	}
+	items, total, err := op.GetTrashItems(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type TrashReq struct {
+	Ids []uint `json:"ids"`
+}
+
+// getTrashItems returns the requested items if the user is allowed to manage them
+func getTrashItems(c *gin.Context) ([]*model.TrashItem, bool) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	if len(req.Ids) == 0 {
+		common.ErrorStrResp(c, "Empty trash ids", 400)
+		return nil, false
+	}
+	user := c.MustGet("user").(*model.User)
+	items := make([]*model.TrashItem, 0, len(req.Ids))
+	for _, id := range req.Ids {
+		item, err := op.GetTrashItemById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return nil, false
+		}
+		if !user.IsAdmin() {
+			dir := stdpath.Dir(item.Path)
+			if item.UserID != user.ID || !common.CheckPathLimitWithRoles(user, dir) ||
+				!common.HasPermission(common.MergeRolePermissions(user, dir), common.PermRemove) {
+				common.ErrorResp(c, errs.PermissionDenied, 403)
+				return nil, false
+			}
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+func FsTrashRestore(c *gin.Context) {
+	items, ok := getTrashItems(c)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := fs.RestoreTrash(c, item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func FsTrashPurge(c *gin.Context) {
+	items, ok := getTrashItems(c)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := fs.PurgeTrash(c, item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,212 @@
+package handles
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// tus 1.0 resumable uploads with the creation and termination extensions, see https://tus.io/protocols/resumable-upload.
+// The responses are plain http statuses as tus clients expect, instead of the usual json.
+
+const tusVersion = "1.0.0"
+
+func tusError(c *gin.Context, code int, msg string) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.String(code, msg)
+	c.Abort()
+}
+
+func checkTusResumable(c *gin.Context) bool {
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		tusError(c, http.StatusPreconditionFailed, "unsupported tus version")
+		return false
+	}
+	return true
+}
+
+// parseTusMetadata decodes the Upload-Metadata header, comma separated pairs of a key and a base64 value
+func parseTusMetadata(header string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		kv := strings.Fields(pair)
+		if len(kv) == 0 {
+			continue
+		}
+		var value string
+		if len(kv) > 1 {
+			if v, err := base64.StdEncoding.DecodeString(kv[1]); err == nil {
+				value = string(v)
+			}
+		}
+		meta[kv[0]] = value
+	}
+	return meta
+}
+
+func FsTusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,termination")
+	c.Status(http.StatusNoContent)
+}
+
+// FsTusCreate creates an upload of the File-Path header, checked by middlewares.FsUp
+// like the other uploads. As-Task, Overwrite, Last-Modified and the hash headers
+// are the same as the ones of FsStream.
+func FsTusCreate(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	path, err = user.JoinPath(path)
+	if err != nil {
+		tusError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		tusError(c, http.StatusBadRequest, "invalid Upload-Length")
+		return
+	}
+	if c.GetHeader("Overwrite") == "false" {
+		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
+			tusError(c, http.StatusConflict, "file exists")
+			return
+		}
+	}
+	storage, err := fs.GetStorage(path, &fs.GetStoragesArgs{})
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if storage.Config().NoUpload {
+		tusError(c, http.StatusMethodNotAllowed, "Current storage doesn't support upload")
+		return
+	}
+	h := make(map[*utils.HashType]string)
+	if md5 := c.GetHeader("X-File-Md5"); md5 != "" {
+		h[utils.MD5] = md5
+	}
+	if sha1 := c.GetHeader("X-File-Sha1"); sha1 != "" {
+		h[utils.SHA1] = sha1
+	}
+	if sha256 := c.GetHeader("X-File-Sha256"); sha256 != "" {
+		h[utils.SHA256] = sha256
+	}
+	mimetype := parseTusMetadata(c.GetHeader("Upload-Metadata"))["filetype"]
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(stdpath.Base(path))
+	}
+	u := &model.TusUpload{
+		Path:     path,
+		Size:     size,
+		Mimetype: mimetype,
+		Hash:     utils.NewHashInfoByMap(h).String(),
+		Modified: getLastModified(c),
+		AsTask:   c.GetHeader("As-Task") == "true",
+		UserID:   user.ID,
+	}
+	if err = fs.CreateTusUpload(u); err != nil {
+		tusError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if size == 0 {
+		// nothing to wait for
+		if _, err = fs.WriteTusUpload(c, u, 0, http.NoBody); err != nil {
+			tusError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	c.Header("Location", common.GetApiUrl(c.Request)+"/api/fs/tus/"+u.ID)
+	c.Header("Tus-Resumable", tusVersion)
+	c.Status(http.StatusCreated)
+}
+
+// getTusUpload returns the upload of the id param if it's the user's
+func getTusUpload(c *gin.Context) (*model.TusUpload, bool) {
+	if !checkTusResumable(c) {
+		return nil, false
+	}
+	u, err := op.GetTusUploadById(c.Param("id"))
+	if err != nil || u.UserID != c.MustGet("user").(*model.User).ID {
+		tusError(c, http.StatusNotFound, "upload not found")
+		return nil, false
+	}
+	return u, true
+}
+
+func FsTusHead(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Size, 10))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+func FsTusPatch(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	defer c.Request.Body.Close()
+	if c.ContentType() != "application/offset+octet-stream" {
+		tusError(c, http.StatusUnsupportedMediaType, "invalid Content-Type")
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		tusError(c, http.StatusBadRequest, "invalid Upload-Offset")
+		return
+	}
+	_, err = fs.WriteTusUpload(c, u, offset, c.Request.Body)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errs.UploadOffsetMismatch):
+			code = http.StatusConflict
+		case errors.Is(err, errs.UploadLocked):
+			code = http.StatusLocked
+		}
+		tusError(c, code, err.Error())
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Status(http.StatusNoContent)
+}
+
+func FsTusDelete(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if err := fs.DeleteTusUpload(u); err != nil {
+		tusError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsVersionReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	ID       string `json:"id" form:"id"`
+}
+
+// getVersionPath returns the path of the request if the user can read it
+func getVersionPath(c *gin.Context, req *FsVersionReq) (string, bool) {
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return "", false
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return "", false
+	}
+	return reqPath, true
+}
+
+func FsVersionList(c *gin.Context) {
+	var req FsVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, ok := getVersionPath(c, &req)
+	if !ok {
+		return
+	}
+	versions, err := fs.ListVersions(c, reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, versions)
+}
+
+func FsVersionDownload(c *gin.Context) {
+	var req FsVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, ok := getVersionPath(c, &req)
+	if !ok {
+		return
+	}
+	link, file, err := fs.LinkVersion(c, reqPath, req.ID, model.LinkArgs{
+		Header:  c.Request.Header,
+		HttpReq: c.Request,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err = common.Proxy(c.Writer, c.Request, link, file); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	}
+}
+
+func FsVersionRestore(c *gin.Context) {
+	var req FsVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, ok := getVersionPath(c, &req)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !common.CheckPathLimitWithRoles(user, reqPath) ||
+		!common.HasPermission(common.MergeRolePermissions(user, reqPath), common.PermWrite) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err := fs.RestoreVersion(c, reqPath, req.ID); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
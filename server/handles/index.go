@@ -71,6 +71,24 @@ func UpdateIndex(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+// RefreshIndex updates the index incrementally in the background, see search.Refresh
+func RefreshIndex(c *gin.Context) {
+	if search.Running() {
+		common.ErrorStrResp(c, "index is running", 400)
+		return
+	}
+	if !search.Config(c).AutoUpdate {
+		common.ErrorStrResp(c, "update is not supported for current index", 400)
+		return
+	}
+	go func() {
+		if err := search.Refresh(context.Background()); err != nil {
+			log.Errorf("refresh index error: %+v", err)
+		}
+	}()
+	common.SuccessResp(c)
+}
+
 func StopIndex(c *gin.Context) {
 	quit := search.Quit.Load()
 	if quit == nil {
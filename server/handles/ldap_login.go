@@ -85,6 +85,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	if len(sr.Entries) != 1 {
 		utils.Log.Errorf("User does not exist or too many entries returned")
 		common.ErrorResp(c, err, 500)
+		common.PublishLoginFailed(req.Username, ip, "ldap user not found")
 		return
 	}
 	userDN := sr.Entries[0].DN
@@ -95,6 +96,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		utils.Log.Errorf("Failed to auth. %v", err)
 		common.ErrorResp(c, err, 400)
 		loginCache.Set(ip, count+1)
+		common.PublishLoginFailed(req.Username, ip, "wrong ldap password")
 		return
 	} else {
 		utils.Log.Infof("Auth successful username:%s", req.Username)
@@ -107,6 +109,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 			loginCache.Set(ip, count+1)
+			common.PublishLoginFailed(req.Username, ip, "ldap user not registered")
 			return
 		}
 	}
@@ -33,6 +33,10 @@ func Search(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if err = search.ParseFilters(&req.SearchReq); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	req.Parent, err = user.JoinPath(req.Parent)
 	if err != nil {
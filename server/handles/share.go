@@ -12,10 +12,12 @@ import (
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/event"
 	shareauth "github.com/alist-org/alist/v3/internal/share"
 
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/alist-org/alist/v3/server/common"
@@ -42,6 +44,7 @@ type CreateShareReq struct {
 	BurnAfterRead *bool  `json:"burn_after_read"`
 	AllowPreview  *bool  `json:"allow_preview"`
 	AllowDownload *bool  `json:"allow_download"`
+	ShareUploadOptions
 }
 
 type UpdateShareReq struct {
@@ -53,6 +56,16 @@ type UpdateShareReq struct {
 	AccessLimit   *int64  `json:"access_limit"`
 	AllowPreview  *bool   `json:"allow_preview"`
 	AllowDownload *bool   `json:"allow_download"`
+	ShareUploadOptions
+}
+
+// ShareUploadOptions are the settings of the uploads to a share, nil keeps the current value
+type ShareUploadOptions struct {
+	AllowUpload       *bool   `json:"allow_upload"`
+	UploadMaxSize     *int64  `json:"upload_max_size"`
+	UploadAllowedExts *string `json:"upload_allowed_exts"`
+	UploadQuota       *int64  `json:"upload_quota"`
+	DropBox           *bool   `json:"drop_box"`
 }
 
 type ShareDeleteReq struct {
@@ -90,6 +103,13 @@ type ShareResp struct {
 	RemainingAccesses int64      `json:"remaining_accesses"`
 	AllowPreview      bool       `json:"allow_preview"`
 	AllowDownload     bool       `json:"allow_download"`
+	AllowUpload       bool       `json:"allow_upload"`
+	UploadMaxSize     int64      `json:"upload_max_size"`
+	UploadAllowedExts string     `json:"upload_allowed_exts"`
+	UploadQuota       int64      `json:"upload_quota"`
+	UploadedSize      int64      `json:"uploaded_size"`
+	UploadCount       int64      `json:"upload_count"`
+	DropBox           bool       `json:"drop_box"`
 	Enabled           bool       `json:"enabled"`
 	ViewCount         int64      `json:"view_count"`
 	DownloadCount     int64      `json:"download_count"`
@@ -112,6 +132,11 @@ type PublicShareInfoResp struct {
 	RemainingAccesses int64      `json:"remaining_accesses"`
 	AllowPreview      bool       `json:"allow_preview"`
 	AllowDownload     bool       `json:"allow_download"`
+	AllowUpload       bool       `json:"allow_upload"`
+	UploadMaxSize     int64      `json:"upload_max_size"`
+	UploadAllowedExts string     `json:"upload_allowed_exts"`
+	UploadRemaining   int64      `json:"upload_remaining"` // -1 for no quota
+	DropBox           bool       `json:"drop_box"`
 	Authed            bool       `json:"authed"`
 	ConsumedAt        *time.Time `json:"consumed_at"`
 	ExpiresAt         *time.Time `json:"expires_at"`
@@ -165,6 +190,13 @@ func toShareResp(c *gin.Context, share *model.Share) ShareResp {
 		RemainingAccesses: share.RemainingAccesses(),
 		AllowPreview:      share.AllowPreview,
 		AllowDownload:     share.AllowDownload,
+		AllowUpload:       share.AllowUpload,
+		UploadMaxSize:     share.UploadMaxSize,
+		UploadAllowedExts: share.UploadAllowedExts,
+		UploadQuota:       share.UploadQuota,
+		UploadedSize:      share.UploadedSize,
+		UploadCount:       share.UploadCount,
+		DropBox:           share.DropBox,
 		Enabled:           share.Enabled,
 		ViewCount:         share.ViewCount,
 		DownloadCount:     share.DownloadCount,
@@ -292,6 +324,41 @@ func resolveShareExpireAt(expireAt string, expireHours int64) (*time.Time, error
 	return &expires, nil
 }
 
+// applyShareUploadOptions sets the upload options of req to the share
+func applyShareUploadOptions(share *model.Share, req ShareUploadOptions) error {
+	if req.AllowUpload != nil {
+		share.AllowUpload = *req.AllowUpload
+	}
+	if req.UploadMaxSize != nil {
+		share.UploadMaxSize = *req.UploadMaxSize
+	}
+	if req.UploadAllowedExts != nil {
+		exts := make([]string, 0)
+		for _, ext := range strings.Split(*req.UploadAllowedExts, ",") {
+			if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+				exts = append(exts, ext)
+			}
+		}
+		share.UploadAllowedExts = strings.Join(exts, ",")
+	}
+	if req.UploadQuota != nil {
+		share.UploadQuota = *req.UploadQuota
+	}
+	if req.DropBox != nil {
+		share.DropBox = *req.DropBox
+	}
+	if share.UploadMaxSize < 0 || share.UploadQuota < 0 {
+		return fmt.Errorf("upload_max_size and upload_quota must be 0 or greater")
+	}
+	if share.AllowUpload && !share.IsDir {
+		return fmt.Errorf("only folder shares can allow upload")
+	}
+	if share.DropBox && !share.AllowUpload {
+		return fmt.Errorf("drop box mode needs allow_upload")
+	}
+	return nil
+}
+
 func sharePasswordMatched(share *model.Share, password string) bool {
 	if !share.HasPassword() {
 		return true
@@ -335,9 +402,34 @@ func recordShareAccess(share *model.Share) error {
 	if updated != nil {
 		*share = *updated
 	}
+	event.Publish(event.ShareAccessed, map[string]any{
+		"share_id": share.ShareID,
+		"path":     share.RootPath,
+		"count":    share.AccessCount,
+	})
 	return nil
 }
 
+func logShareAccess(c *gin.Context, share *model.Share, action, relPath string, bytes int64) {
+	shareauth.LogAccess(share, action, relPath, c.ClientIP(), c.Request.UserAgent(), bytes)
+}
+
+// requestedBytes returns the bytes requested in the Range header, or size without it
+func requestedBytes(c *gin.Context, size int64) int64 {
+	ranges, err := http_range.ParseRange(c.GetHeader("Range"), size)
+	if err != nil || len(ranges) == 0 {
+		return size
+	}
+	var n int64
+	for _, r := range ranges {
+		if r.Length < 0 {
+			return size
+		}
+		n += r.Length
+	}
+	return n
+}
+
 func ensureShareAccess(c *gin.Context, share *model.Share, token string) bool {
 	if !share.HasPassword() {
 		return true
@@ -353,6 +445,22 @@ func ensureShareAccess(c *gin.Context, share *model.Share, token string) bool {
 	return true
 }
 
+// ensureShareNotDropBox refuses to show the content of drop box shares
+func ensureShareNotDropBox(c *gin.Context, share *model.Share) bool {
+	if share.DropBox {
+		common.ErrorStrResp(c, "the content of this share is hidden", 403)
+		return false
+	}
+	return true
+}
+
+func shareUploadRemaining(share *model.Share) int64 {
+	if share.UploadQuota <= 0 {
+		return -1
+	}
+	return max(share.UploadQuota-share.UploadedSize, 0)
+}
+
 func shouldTrackShareContentAccess(c *gin.Context) bool {
 	return c.Request.Method != http.MethodHead
 }
@@ -492,6 +600,10 @@ func CreateShare(c *gin.Context) {
 		Enabled:       true,
 		ExpiresAt:     expiresAt,
 	}
+	if err := applyShareUploadOptions(share, req.ShareUploadOptions); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if req.Password != "" {
 		share.PasswordSalt = random.String(16)
 		share.PasswordHash = sharePasswordHash(req.Password, share.PasswordSalt)
@@ -554,6 +666,7 @@ func UpdateShare(c *gin.Context) {
 		}
 	}
 
+	oldShareID := share.ShareID
 	share.ShareID = shareID
 	share.Name = normalizeOptionalShareName(req.Name, share.Name)
 	share.BurnAfterRead = burnAfterRead
@@ -561,6 +674,10 @@ func UpdateShare(c *gin.Context) {
 	share.AllowPreview = allowPreview
 	share.AllowDownload = allowDownload
 	share.ExpiresAt = expiresAt
+	if err := applyShareUploadOptions(share, req.ShareUploadOptions); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if req.Password != "" {
 		share.PasswordSalt = random.String(16)
 		share.PasswordHash = sharePasswordHash(req.Password, share.PasswordSalt)
@@ -576,6 +693,12 @@ func UpdateShare(c *gin.Context) {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	if oldShareID != share.ShareID {
+		if err := db.RenameShareAccessLogs(oldShareID, share.ShareID); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
 	common.SuccessResp(c, toShareResp(c, share))
 }
 
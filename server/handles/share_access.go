@@ -0,0 +1,116 @@
+package handles
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ShareAccessLogReq struct {
+	model.PageReq
+	ShareID string `json:"share_id" form:"share_id" binding:"required"`
+	// From and To are dates like 2006-01-02, both included
+	From string `json:"from" form:"from"`
+	To   string `json:"to" form:"to"`
+}
+
+// parseShareAccessRange turns the dates of req into the times [from, to)
+func parseShareAccessRange(req *ShareAccessLogReq) (from, to time.Time, err error) {
+	if req.From != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, req.From, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid from date")
+		}
+	}
+	if req.To != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, req.To, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid to date")
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+// getOwnShareAccessReq binds the request and makes sure the share is the user's
+func getOwnShareAccessReq(c *gin.Context) (*ShareAccessLogReq, time.Time, time.Time, bool) {
+	var req ShareAccessLogReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, time.Time{}, time.Time{}, false
+	}
+	from, to, err := parseShareAccessRange(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, from, to, false
+	}
+	user := c.MustGet("user").(*model.User)
+	if _, err := db.GetShareByCreatorAndShareID(user.ID, req.ShareID); err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil, from, to, false
+	}
+	return &req, from, to, true
+}
+
+func ListShareAccessLogs(c *gin.Context) {
+	req, from, to, ok := getOwnShareAccessReq(c)
+	if !ok {
+		return
+	}
+	req.Validate()
+	logs, total, err := db.GetShareAccessLogs(req.ShareID, from, to, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+// ExportShareAccessLogs writes the access logs of the share as a csv file
+func ExportShareAccessLogs(c *gin.Context) {
+	req, from, to, ok := getOwnShareAccessReq(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="share_%s_access_log.csv"`, req.ShareID))
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"time", "action", "path", "ip", "user_agent", "bytes"})
+	err := db.EachShareAccessLog(req.ShareID, from, to, func(logs []model.ShareAccessLog) error {
+		for _, l := range logs {
+			if err := w.Write([]string{
+				l.CreatedAt.Format(time.RFC3339), l.Action, l.Path, l.IP, l.UserAgent, strconv.FormatInt(l.Bytes, 10),
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		// the header is sent already, the truncated file is all we can do
+		_ = c.Error(err)
+	}
+	w.Flush()
+}
+
+// GetShareAccessStats returns the daily statistics of the share
+func GetShareAccessStats(c *gin.Context) {
+	req, _, _, ok := getOwnShareAccessReq(c)
+	if !ok {
+		return
+	}
+	dailies, err := db.GetShareAccessDailies(req.ShareID, req.From, req.To)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, dailies)
+}
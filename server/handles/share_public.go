@@ -8,6 +8,7 @@ import (
 	shareauth "github.com/alist-org/alist/v3/internal/share"
 
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +34,7 @@ func GetPublicShareInfo(c *gin.Context) {
 	}
 	if authed {
 		_ = db.TouchShareView(share.ShareID)
+		logShareAccess(c, share, model.ShareActionView, "/", 0)
 	}
 	common.SuccessResp(c, PublicShareInfoResp{
 		ShareID:           share.ShareID,
@@ -45,6 +47,11 @@ func GetPublicShareInfo(c *gin.Context) {
 		RemainingAccesses: share.RemainingAccesses(),
 		AllowPreview:      share.AllowPreview,
 		AllowDownload:     share.AllowDownload,
+		AllowUpload:       share.AllowUpload,
+		UploadMaxSize:     share.UploadMaxSize,
+		UploadAllowedExts: share.UploadAllowedExts,
+		UploadRemaining:   shareUploadRemaining(share),
+		DropBox:           share.DropBox,
 		Authed:            authed,
 		ConsumedAt:        share.ConsumedAt,
 		ExpiresAt:         share.ExpiresAt,
@@ -86,6 +93,7 @@ func AuthPublicShare(c *gin.Context) {
 		token = shareauth.SignAccess(share, ttl)
 	}
 	_ = db.TouchShareView(share.ShareID)
+	logShareAccess(c, share, model.ShareActionView, "/", 0)
 	common.SuccessResp(c, gin.H{"token": token})
 }
 
@@ -101,7 +109,7 @@ func ListPublicShare(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareNotDropBox(c, share) {
 		return
 	}
 	token := getShareAccessToken(c, req.Token)
@@ -163,7 +171,7 @@ func GetPublicShare(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareNotDropBox(c, share) {
 		return
 	}
 	token := getShareAccessToken(c, req.Token)
@@ -197,7 +205,7 @@ func ShareDown(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareNotDropBox(c, share) {
 		return
 	}
 	if !share.AllowDownload {
@@ -208,7 +216,7 @@ func ShareDown(c *gin.Context) {
 	if !ensureShareAccess(c, share, token) {
 		return
 	}
-	targetPath, _, err := resolveShareWildcardTarget(share, c.Param("path"))
+	targetPath, relPath, err := resolveShareWildcardTarget(share, c.Param("path"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -228,6 +236,7 @@ func ShareDown(c *gin.Context) {
 			common.ErrorResp(c, err, 500, true)
 			return
 		}
+		logShareAccess(c, share, model.ShareActionDownload, relPath, requestedBytes(c, obj.GetSize()))
 	}
 	c.Set("path", targetPath)
 	Down(c)
@@ -239,7 +248,7 @@ func ShareProxy(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareNotDropBox(c, share) {
 		return
 	}
 	if !share.AllowPreview {
@@ -250,7 +259,7 @@ func ShareProxy(c *gin.Context) {
 	if !ensureShareAccess(c, share, token) {
 		return
 	}
-	targetPath, _, err := resolveShareWildcardTarget(share, c.Param("path"))
+	targetPath, relPath, err := resolveShareWildcardTarget(share, c.Param("path"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -269,6 +278,7 @@ func ShareProxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500, true)
 			return
 		}
+		logShareAccess(c, share, model.ShareActionPreview, relPath, requestedBytes(c, obj.GetSize()))
 	}
 	c.Set("path", targetPath)
 	Proxy(c)
@@ -0,0 +1,137 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// ShareUpload receives a file from a recipient of a share allowing upload.
+// The file is put with the permissions of the creator of the share,
+// it's renamed if the name is already used, as the recipients can't overwrite anything.
+func ShareUpload(c *gin.Context) {
+	defer c.Request.Body.Close()
+	share, err := db.GetShareByShareID(c.Query("share_id"))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if !ensureShareAvailable(c, share) {
+		return
+	}
+	if !share.AllowUpload {
+		common.ErrorStrResp(c, "upload is not allowed", 403)
+		return
+	}
+	token := getShareAccessToken(c, "")
+	if !ensureShareAccess(c, share, token) {
+		return
+	}
+	rawPath, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	targetPath, relPath, err := resolveShareTarget(share, rawPath)
+	if err != nil || targetPath == share.RootPath {
+		common.ErrorStrResp(c, "invalid file path", 400)
+		return
+	}
+	dir, name := stdpath.Split(targetPath)
+	if !share.UploadExtAllowed(name) {
+		common.ErrorStrResp(c, "file type is not allowed", 403)
+		return
+	}
+	size := c.Request.ContentLength
+	if size < 0 {
+		common.ErrorStrResp(c, "Content-Length is required", 411)
+		return
+	}
+	if share.UploadMaxSize > 0 && size > share.UploadMaxSize {
+		common.ErrorStrResp(c, fmt.Sprintf("file is larger than %d bytes", share.UploadMaxSize), 413)
+		return
+	}
+	creator, err := op.GetUserById(share.CreatorID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !canShareCreatorWrite(creator, dir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	reserved, err := db.ReserveShareUpload(share.ShareID, size)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !reserved {
+		common.ErrorStrResp(c, "upload quota of the share is exceeded", 413)
+		return
+	}
+	ctx := context.WithValue(c.Request.Context(), "user", creator)
+	if err = putShareUpload(ctx, c, dir, name, size); err != nil {
+		if err := db.ReleaseShareUpload(share.ShareID, size); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// only the views and downloads count towards the access limit, uploads are limited by the quota
+	_ = db.TouchShareUpload(share.ShareID)
+	logShareAccess(c, share, model.ShareActionUpload, relPath, size)
+	common.SuccessResp(c)
+}
+
+func canShareCreatorWrite(creator *model.User, dir string) bool {
+	if creator.Disabled {
+		return false
+	}
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	perm := common.MergeRolePermissions(creator, dir)
+	return common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, dir)
+}
+
+func putShareUpload(ctx context.Context, c *gin.Context, dir, name string, size int64) error {
+	name, err := fs.FreeName(ctx, dir, name)
+	if err != nil {
+		return err
+	}
+	mimetype := c.GetHeader("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:   c.Request.Body,
+		Mimetype: mimetype,
+	}
+	if err = fs.PutDirectly(ctx, dir, s, true); err != nil {
+		return err
+	}
+	if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+		_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+	}
+	return nil
+}
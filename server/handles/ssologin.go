@@ -204,12 +204,14 @@ func OIDCLoginCallback(c *gin.Context) {
 	}
 	if !verifyState(clientId, c.ClientIP(), c.Query("state")) {
 		common.ErrorStrResp(c, "incorrect or expired state parameter", 400)
+		common.PublishLoginFailed("", c.ClientIP(), "invalid sso state")
 		return
 	}
 
 	oauth2Token, err := oauth2Config.Exchange(c, c.Query("code"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		common.PublishLoginFailed("", c.ClientIP(), "invalid sso code")
 		return
 	}
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
@@ -223,6 +225,7 @@ func OIDCLoginCallback(c *gin.Context) {
 	_, err = verifier.Verify(c, rawIDToken)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		common.PublishLoginFailed("", c.ClientIP(), "invalid sso id token")
 		return
 	}
 	payload, err := parseJWT(rawIDToken)
@@ -257,6 +260,7 @@ func OIDCLoginCallback(c *gin.Context) {
 			user, err = autoRegister(userID, userID, err)
 			if err != nil {
 				common.ErrorResp(c, err, 400)
+				common.PublishLoginFailed(userID, c.ClientIP(), "sso user not registered")
 				return
 			}
 		}
@@ -423,6 +427,7 @@ func SSOLoginCallback(c *gin.Context) {
 		user, err = autoRegister(username, userID, err)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
+			common.PublishLoginFailed(username, c.ClientIP(), "sso user not registered")
 			return
 		}
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -13,6 +14,11 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+type StorageResp struct {
+	model.Storage
+	MountDetails *model.StorageDetails `json:"mount_details,omitempty"`
+}
+
 func ListStorages(c *gin.Context) {
 	var req model.PageReq
 	if err := c.ShouldBind(&req); err != nil {
@@ -26,8 +32,16 @@ func ListStorages(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	resp := make([]StorageResp, len(storages))
+	for i, storage := range storages {
+		resp[i].Storage = storage
+		// the capacity is got in the background, the list shows the one last got
+		if d, err := op.GetStorageByMountPath(storage.MountPath); err == nil {
+			resp[i].MountDetails = op.GetCachedStorageDetails(d)
+		}
+	}
 	common.SuccessResp(c, common.PageResp{
-		Content: storages,
+		Content: resp,
 		Total:   total,
 	})
 }
@@ -150,3 +164,72 @@ func LoadAllStorages(c *gin.Context) {
 	}(storages)
 	common.SuccessResp(c)
 }
+
+// ReencryptStorage starts a task bringing the files of the storage to its current encryption settings
+func ReencryptStorage(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, err := db.GetStorageById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	t, err := fs.Reencrypt(c, storage.MountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+// CollectStorageGarbage starts a task removing the data no file of the storage refers to anymore
+func CollectStorageGarbage(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, err := db.GetStorageById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	t, err := fs.CollectGarbage(c, storage.MountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+// ScrubStorage starts a task checking the redundancy of the files of the storage, repairing them if repair is true
+func ScrubStorage(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, err := db.GetStorageById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	t, err := fs.Scrub(c, storage.MountPath, c.Query("repair") == "true")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
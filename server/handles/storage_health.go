@@ -0,0 +1,52 @@
+package handles
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListStoragesHealth(c *gin.Context) {
+	common.SuccessResp(c, op.GetStoragesHealth())
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// StoragesHealthMetrics exposes the health of the storages in the Prometheus text format
+func StoragesHealthMetrics(c *gin.Context) {
+	healths := op.GetStoragesHealth()
+	var b strings.Builder
+	metric := func(name, typ, help string, value func(h *op.StorageHealth) float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for i := range healths {
+			h := &healths[i]
+			fmt.Fprintf(&b, "%s{mount_path=\"%s\",driver=\"%s\"} %g\n", name,
+				metricLabelEscaper.Replace(h.MountPath), metricLabelEscaper.Replace(h.Driver), value(h))
+		}
+	}
+	metric("alist_storage_up", "gauge", "Whether the last health check of the storage succeeded.", func(h *op.StorageHealth) float64 {
+		if h.Healthy {
+			return 1
+		}
+		return 0
+	})
+	metric("alist_storage_check_latency_seconds", "gauge", "Duration of the last health check of the storage.", func(h *op.StorageHealth) float64 {
+		if len(h.History) == 0 {
+			return 0
+		}
+		return float64(h.History[len(h.History)-1].Latency) / 1000
+	})
+	metric("alist_storage_checks_total", "counter", "Health checks of the storage.", func(h *op.StorageHealth) float64 {
+		return float64(h.Checks)
+	})
+	metric("alist_storage_check_failures_total", "counter", "Failed health checks of the storage.", func(h *op.StorageHealth) float64 {
+		return float64(h.Failures)
+	})
+	metric("alist_storage_reloads_total", "counter", "Automatic reloads of the storage.", func(h *op.StorageHealth) float64 {
+		return float64(h.Reloads)
+	})
+	c.Data(200, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -223,4 +223,12 @@ func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/s3_transition"), fs.S3TransitionTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
+	taskRoute(g.Group("/reencrypt"), fs.ReencryptTaskManager)
+	taskRoute(g.Group("/gc"), fs.GCTaskManager)
+	taskRoute(g.Group("/scrub"), fs.ScrubTaskManager)
+	sync := g.Group("/sync")
+	taskRoute(sync, fs.SyncTaskManager)
+	sync.POST("/report", getTargetedHandler(fs.SyncTaskManager, func(c *gin.Context, task *fs.SyncTask) {
+		common.SuccessResp(c, task.GetReport())
+	}))
 }
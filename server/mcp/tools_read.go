@@ -36,7 +36,7 @@ func registerReadTools(s *mcpserver.MCPServer) {
 	s.AddTool(mcp.NewTool("fs_search",
 		mcp.WithDescription("Search for files by keywords"),
 		mcp.WithString("path", mcp.Required(), mcp.Description("Parent directory to search within")),
-		mcp.WithString("keywords", mcp.Required(), mcp.Description("Search keywords")),
+		mcp.WithString("keywords", mcp.Required(), mcp.Description("Search keywords, may contain filters like size>1G modified:2025-01..2025-06 ext:mkv,mp4 type:video path:/movies sort:-size")),
 		mcp.WithNumber("scope", mcp.Description("0=all, 1=dir only, 2=file only (default: 0)")),
 		mcp.WithNumber("page", mcp.Description("Page number (default: 1)")),
 		mcp.WithNumber("per_page", mcp.Description("Items per page (default: 20)")),
@@ -134,17 +134,19 @@ func handleFsSearch(ctx context.Context, user *model.User, req mcp.CallToolReque
 	page := intParam(req, "page", 1)
 	perPage := intParam(req, "per_page", 20)
 
-	parent, err := user.JoinPath(pathStr)
-	if err != nil {
-		return wrapError(err)
-	}
-
 	searchReq := model.SearchReq{
-		Parent:   parent,
+		Parent:   pathStr,
 		Keywords: keywords,
 		Scope:    scope,
 		PageReq:  model.PageReq{Page: page, PerPage: perPage},
 	}
+	if err := search.ParseFilters(&searchReq); err != nil {
+		return toolErrorf("invalid search request: %s", err.Error())
+	}
+	searchReq.Parent, err = user.JoinPath(searchReq.Parent)
+	if err != nil {
+		return wrapError(err)
+	}
 	if err := searchReq.Validate(); err != nil {
 		return toolErrorf("invalid search request: %s", err.Error())
 	}
@@ -78,6 +78,9 @@ func Init(e *gin.Engine) {
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
 	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
 	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
+	auth.GET("/me/s3key/list", handles.ListMyS3Keys)
+	auth.POST("/me/s3key/add", handles.AddMyS3Key)
+	auth.POST("/me/s3key/delete", handles.DeleteMyS3Key)
 	auth.POST("/auth/2fa/generate", handles.Generate2FA)
 	auth.POST("/auth/2fa/verify", handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
@@ -107,6 +110,7 @@ func Init(e *gin.Engine) {
 	public.POST("/share/auth", handles.AuthPublicShare)
 	public.POST("/share/list", handles.ListPublicShare)
 	public.POST("/share/get", handles.GetPublicShare)
+	public.PUT("/share/upload", middlewares.UploadRateLimiter(stream.ClientUploadLimit), handles.ShareUpload)
 
 	_fs(auth.Group("/fs"))
 	share := auth.Group("/share", middlewares.AuthNotGuest)
@@ -115,6 +119,9 @@ func Init(e *gin.Engine) {
 	share.POST("/disable", handles.DisableShare)
 	share.GET("/list", handles.ListShares)
 	share.POST("/delete", handles.DeleteShare)
+	share.GET("/access_log", handles.ListShareAccessLogs)
+	share.GET("/access_log/export", handles.ExportShareAccessLogs)
+	share.GET("/access_stats", handles.GetShareAccessStats)
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_label(auth.Group("/label"))
 	_labelFileBinding(auth.Group("/label_file_binding"))
@@ -145,6 +152,8 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/del_cache", handles.DelUserCache)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/s3key/list", handles.ListS3Keys)
+	user.POST("/s3key/delete", handles.DeleteS3Key)
 
 	role := g.Group("/role")
 	role.GET("/list", handles.ListRoles)
@@ -162,6 +171,16 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/health", handles.ListStoragesHealth)
+	storage.GET("/health/metrics", handles.StoragesHealthMetrics)
+	storage.POST("/reencrypt", handles.ReencryptStorage)
+	storage.POST("/gc", handles.CollectStorageGarbage)
+	storage.POST("/scrub", handles.ScrubStorage)
+
+	encryptionKey := g.Group("/encryption_key")
+	encryptionKey.GET("/list", handles.ListEncryptionKeys)
+	encryptionKey.POST("/create", handles.CreateEncryptionKey)
+	encryptionKey.POST("/delete", handles.DeleteEncryptionKey)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -197,6 +216,7 @@ func admin(g *gin.RouterGroup) {
 	index := g.Group("/index")
 	index.POST("/build", middlewares.SearchIndex, handles.BuildIndex)
 	index.POST("/update", middlewares.SearchIndex, handles.UpdateIndex)
+	index.POST("/refresh", middlewares.SearchIndex, handles.RefreshIndex)
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
@@ -217,6 +237,23 @@ func admin(g *gin.RouterGroup) {
 	session.GET("/list", handles.ListSessions)
 	session.POST("/evict", handles.EvictSession)
 
+	sync := g.Group("/sync")
+	sync.GET("/list", handles.ListSyncJobs)
+	sync.GET("/get", handles.GetSyncJob)
+	sync.POST("/create", handles.CreateSyncJob)
+	sync.POST("/update", handles.UpdateSyncJob)
+	sync.POST("/delete", handles.DeleteSyncJob)
+	sync.POST("/run", handles.RunSyncJob)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/get", handles.GetWebhook)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.GET("/deliveries", handles.ListWebhookDeliveries)
+	webhook.POST("/redeliver", handles.RedeliverWebhook)
+
 }
 
 func _fs(g *gin.RouterGroup) {
@@ -238,6 +275,12 @@ func _fs(g *gin.RouterGroup) {
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
+	tus := g.Group("/tus")
+	tus.OPTIONS("/", handles.FsTusOptions)
+	tus.POST("/", middlewares.FsUp, handles.FsTusCreate)
+	tus.HEAD("/:id", handles.FsTusHead)
+	tus.PATCH("/:id", uploadLimiter, handles.FsTusPatch)
+	tus.DELETE("/:id", handles.FsTusDelete)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
 	// g.POST("/add_aria2", handles.AddOfflineDownload)
 	// g.POST("/add_qbit", handles.AddQbittorrent)
@@ -247,6 +290,14 @@ func _fs(g *gin.RouterGroup) {
 	a.Any("/meta", handles.FsArchiveMeta)
 	a.Any("/list", handles.FsArchiveList)
 	a.POST("/decompress", handles.FsArchiveDecompress)
+	t := g.Group("/trash")
+	t.Any("/list", handles.FsTrashList)
+	t.POST("/restore", handles.FsTrashRestore)
+	t.POST("/purge", handles.FsTrashPurge)
+	v := g.Group("/versions")
+	v.Any("/list", handles.FsVersionList)
+	v.GET("/download", handles.FsVersionDownload)
+	v.POST("/restore", handles.FsVersionRestore)
 }
 
 func _task(g *gin.RouterGroup) {
@@ -269,6 +320,8 @@ func Cors(r *gin.Engine) {
 	config.AllowOrigins = conf.Conf.Cors.AllowOrigins
 	config.AllowHeaders = conf.Conf.Cors.AllowHeaders
 	config.AllowMethods = conf.Conf.Cors.AllowMethods
+	// let the browsers read the headers of resumable uploads
+	config.ExposeHeaders = []string{"Location", "Upload-Offset", "Upload-Length", "Tus-Resumable", "Tus-Version", "Tus-Extension"}
 	r.Use(cors.New(config))
 }
 
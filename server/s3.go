@@ -27,6 +27,8 @@ func S3(g *gin.RouterGroup) {
 	h, _ := s3.NewServer(context.Background())
 
 	g.Any("/*path", func(c *gin.Context) {
+		// the signature of the request covers the path with the prefix
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), conf.S3SignedPathKey, c.Request.URL.Path))
 		adjustedPath := strings.TrimPrefix(c.Request.URL.Path, path.Join(conf.URL.Path, "/s3"))
 		c.Request.URL.Path = adjustedPath
 		gin.WrapH(h)(c)
@@ -0,0 +1,309 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/alist-org/gofakes3"
+	"github.com/alist-org/gofakes3/signature"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the error codes gofakes3 doesn't know
+const (
+	errAccessDenied                 gofakes3.ErrorCode = "AccessDenied"
+	errInvalidAccessKeyId           gofakes3.ErrorCode = "InvalidAccessKeyId"
+	errAuthorizationQueryParameters gofakes3.ErrorCode = "AuthorizationQueryParametersError"
+)
+
+// maxPresignExpires is the longest validity of a presigned url, as on AWS
+const maxPresignExpires = 7 * 24 * time.Hour
+
+func errorStatus(code gofakes3.ErrorCode) int {
+	switch code {
+	case errAccessDenied, errInvalidAccessKeyId:
+		return http.StatusForbidden
+	case errAuthorizationQueryParameters:
+		return http.StatusBadRequest
+	}
+	return code.Status()
+}
+
+// authHandler verifies the signature of the requests before passing them to next.
+// The requests signed with the global key pair have full access to the buckets, the ones
+// signed with the key pair of a user are limited to what the user may do and carry
+// the user in their context.
+type authHandler struct {
+	next http.Handler
+}
+
+func newAuthHandler(next http.Handler) http.Handler {
+	return &authHandler{next: next}
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the preflight requests of the browsers carry no credentials
+	if r.Method == http.MethodOptions {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	global := authlistResolver()
+	accessKey := requestAccessKey(r)
+	if accessKey != "" {
+		if err := checkPresigned(r); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	if _, ok := global[accessKey]; ok && accessKey != "" {
+		// the key pair may have been changed since the server started
+		signature.StoreKeys(global)
+		if verifySignature(w, r) {
+			h.next.ServeHTTP(w, r)
+		}
+		return
+	}
+	if accessKey == "" {
+		has, err := op.HasS3AccessKeys()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		// the server is open to anyone until a key pair is set
+		if len(global) == 0 && !has {
+			h.next.ServeHTTP(w, r)
+			return
+		}
+		writeError(w, r, gofakes3.ErrorMessage(errAccessDenied, "Access Denied"))
+		return
+	}
+	key, err := op.GetS3AccessKeyByAccessKeyId(accessKey)
+	if err != nil {
+		writeError(w, r, gofakes3.ErrorMessage(errInvalidAccessKeyId,
+			"The AWS Access Key Id you provided does not exist in our records."))
+		return
+	}
+	user, err := op.GetUserById(key.UserId)
+	if err != nil || user.Disabled {
+		writeError(w, r, gofakes3.ErrorMessage(errAccessDenied, "Access Denied"))
+		return
+	}
+	signature.StoreKeys(map[string]string{key.AccessKeyId: key.SecretAccessKey})
+	if !verifySignature(w, r) {
+		return
+	}
+	if time.Since(key.LastUsedTime) > time.Minute {
+		key.UpdateLastUsedTime()
+		if err := op.UpdateS3AccessKey(key); err != nil {
+			log.Warnf("failed update last used time of s3 key %s: %+v", key.AccessKeyId, err)
+		}
+	}
+	if err := authorize(r, user); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+}
+
+// requestAccessKey returns the access key id the request is signed with,
+// from the authorization header or the query of a presigned url
+func requestAccessKey(r *http.Request) string {
+	q := r.URL.Query()
+	if cred := q.Get("X-Amz-Credential"); cred != "" {
+		accessKey, _, _ := strings.Cut(cred, "/")
+		return accessKey
+	}
+	if accessKey := q.Get("AWSAccessKeyId"); accessKey != "" {
+		return accessKey
+	}
+	auth := r.Header.Get("Authorization")
+	if v, ok := strings.CutPrefix(auth, "AWS4-HMAC-SHA256 "); ok {
+		for _, field := range strings.Split(v, ",") {
+			if cred, ok := strings.CutPrefix(strings.TrimSpace(field), "Credential="); ok {
+				accessKey, _, _ := strings.Cut(cred, "/")
+				return accessKey
+			}
+		}
+	} else if v, ok := strings.CutPrefix(auth, "AWS "); ok {
+		accessKey, _, _ := strings.Cut(v, ":")
+		return accessKey
+	}
+	return ""
+}
+
+// checkPresigned checks the validity period of a presigned url,
+// the signature itself is checked by verifySignature
+func checkPresigned(r *http.Request) error {
+	q := r.URL.Query()
+	now := time.Now()
+	switch {
+	case q.Get("X-Amz-Signature") != "":
+		date, err := time.Parse("20060102T150405Z", q.Get("X-Amz-Date"))
+		if err != nil {
+			return gofakes3.ErrorMessage(errAuthorizationQueryParameters,
+				`X-Amz-Date must be in the ISO8601 Long Format "yyyyMMdd'T'HHmmss'Z'"`)
+		}
+		seconds, err := strconv.ParseInt(q.Get("X-Amz-Expires"), 10, 64)
+		if err != nil || seconds < 0 {
+			return gofakes3.ErrorMessage(errAuthorizationQueryParameters,
+				"X-Amz-Expires should be a number")
+		}
+		expires := time.Duration(seconds) * time.Second
+		if expires > maxPresignExpires {
+			return gofakes3.ErrorMessage(errAuthorizationQueryParameters,
+				"X-Amz-Expires must be less than a week (in seconds) that is 604800")
+		}
+		if date.After(now.Add(gofakes3.DefaultSkewLimit)) {
+			return gofakes3.ErrorMessage(errAccessDenied, "Request is not valid yet")
+		}
+		if now.After(date.Add(expires)) {
+			return gofakes3.ErrorMessage(errAccessDenied, "Request has expired")
+		}
+	case q.Get("Signature") != "" && q.Get("AWSAccessKeyId") != "":
+		// signature v2, Expires is the unix time the url expires at
+		expires, err := strconv.ParseInt(q.Get("Expires"), 10, 64)
+		if err != nil {
+			return gofakes3.ErrorMessage(errAccessDenied,
+				"Query-string authentication requires the Signature, Expires and AWSAccessKeyId parameters")
+		}
+		if now.Unix() > expires {
+			return gofakes3.ErrorMessage(errAccessDenied, "Request has expired")
+		}
+	}
+	return nil
+}
+
+// verifySignature verifies the signature of the request the same way as gofakes3,
+// against the path the client signed if the mount prefix was stripped from it
+func verifySignature(w http.ResponseWriter, r *http.Request) bool {
+	if signedPath, ok := r.Context().Value(conf.S3SignedPathKey).(string); ok {
+		signed := *r
+		u := *r.URL
+		u.Path, u.RawPath = signedPath, ""
+		signed.URL = &u
+		r = &signed
+	}
+	result := signature.V4SignVerify(r)
+	if result == signature.ErrUnsupportAlgorithm {
+		result = signature.V2SignVerify(r)
+	}
+	if result == signature.ErrNone {
+		return true
+	}
+	resp := signature.GetAPIError(result)
+	w.Header().Add("Content-Type", "application/xml")
+	w.WriteHeader(resp.HTTPStatusCode)
+	_, _ = w.Write(signature.EncodeAPIErrorToResponse(resp))
+	return false
+}
+
+// resolveBucketPath returns the path of the bucket, the buckets are relative to
+// the base path of the user of the request if any
+func resolveBucketPath(ctx context.Context, bucket Bucket) (string, error) {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok {
+		return bucket.Path, nil
+	}
+	p, err := user.JoinPath(bucket.Path)
+	if err != nil {
+		return "", gofakes3.ErrorMessage(errAccessDenied, "Access Denied")
+	}
+	return p, nil
+}
+
+func canRead(user *model.User, p string) bool {
+	meta, err := op.GetNearestMeta(p)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	return common.CanAccessWithRoles(user, meta, p, "")
+}
+
+func canWrite(user *model.User, p string) bool {
+	if common.HasPermission(common.MergeRolePermissions(user, p), common.PermWrite) {
+		return true
+	}
+	meta, _ := op.GetNearestMeta(path.Dir(p))
+	return common.CanWrite(meta, path.Dir(p))
+}
+
+func canRemove(user *model.User, p string) bool {
+	return common.HasPermission(common.MergeRolePermissions(user, p), common.PermRemove)
+}
+
+// authorize checks the user may do what the request asks for, following the permissions
+// of its roles on the path the request is about
+func authorize(r *http.Request, user *model.User) error {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucketName == "" {
+		// the buckets are filtered by ListBuckets
+		return nil
+	}
+	bucket, err := getBucketByName(bucketName)
+	if err != nil {
+		// let gofakes3 report it
+		return nil
+	}
+	ctx := context.WithValue(r.Context(), "user", user)
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	p := path.Join(bucketPath, key)
+	q := r.URL.Query()
+	var allowed bool
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		allowed = canRead(user, p)
+	case http.MethodPut:
+		allowed = canWrite(user, p)
+		if src := r.Header.Get("X-Amz-Copy-Source"); allowed && src != "" && !q.Has("uploadId") {
+			allowed = authorizeCopySource(ctx, user, src)
+		}
+	case http.MethodPost:
+		if key == "" && q.Has("delete") {
+			allowed = canRemove(user, p)
+		} else {
+			allowed = canWrite(user, p)
+		}
+	case http.MethodDelete:
+		if q.Has("uploadId") {
+			allowed = canWrite(user, p)
+		} else {
+			allowed = canRemove(user, p)
+		}
+	default:
+		allowed = canRead(user, p)
+	}
+	if !allowed {
+		return gofakes3.ErrorMessage(errAccessDenied, "Access Denied")
+	}
+	return nil
+}
+
+func authorizeCopySource(ctx context.Context, user *model.User, src string) bool {
+	if unescaped, err := url.PathUnescape(src); err == nil {
+		src = unescaped
+	}
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(src, "/"), "/")
+	bucket, err := getBucketByName(bucketName)
+	if err != nil {
+		return true
+	}
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return false
+	}
+	return canRead(user, path.Join(bucketPath, key))
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -50,9 +51,17 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 	if err != nil {
 		return nil, err
 	}
+	user, _ := ctx.Value("user").(*model.User)
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
-		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		bucketPath, err := resolveBucketPath(ctx, b)
+		if err != nil || (user != nil && !canRead(user, bucketPath)) {
+			continue
+		}
+		node, err := fs.Get(ctx, bucketPath, &fs.GetArgs{})
+		if err != nil && user != nil {
+			continue
+		}
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
 			Name:         b.Name,
@@ -68,7 +77,10 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
 
 	if prefix == nil {
 		prefix = emptyPrefix
@@ -83,9 +95,13 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 	}
 
 	response := gofakes3.NewObjectList()
-	path, remaining := prefixParser(prefix)
+	fdPath, remaining := prefixParser(prefix)
 
-	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
+	user, _ := ctx.Value("user").(*model.User)
+	if user != nil && !canRead(user, path.Join(bucketPath, fdPath)) {
+		return nil, gofakes3.ErrorMessage(errAccessDenied, "Access Denied")
+	}
+	err = b.entryListR(user, bucketPath, fdPath, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
 		// AWS just returns an empty list
 		response = gofakes3.NewObjectList()
@@ -104,7 +120,10 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
 
 	fp := path.Join(bucketPath, objectName)
 	fmeta, _ := op.GetNearestMeta(fp)
@@ -147,7 +166,10 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
 
 	fp := path.Join(bucketPath, objectName)
 	fmeta, _ := op.GetNearestMeta(fp)
@@ -251,7 +273,10 @@ func (b *s3Backend) PutObject(
 	if err != nil {
 		return result, err
 	}
-	bucketPath := bucket.Path
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return result, err
+	}
 
 	isDir := strings.HasSuffix(objectName, "/")
 	log.Debugf("isDir: %v", isDir)
@@ -315,7 +340,7 @@ func (b *s3Backend) PutObject(
 
 	if err := stream.Close(); err != nil {
 		// remove file when close error occurred (FsPutErr)
-		_ = fs.Remove(ctx, fp)
+		_ = fs.Remove(context.WithValue(ctx, conf.NoTrashKey, struct{}{}), fp)
 		return result, err
 	}
 
@@ -355,7 +380,10 @@ func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName str
 	if err != nil {
 		return err
 	}
-	bucketPath := bucket.Path
+	bucketPath, err := resolveBucketPath(ctx, bucket)
+	if err != nil {
+		return err
+	}
 
 	fp := path.Join(bucketPath, objectName)
 	fmeta, _ := op.GetNearestMeta(fp)
@@ -404,7 +432,10 @@ func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket
 	if err != nil {
 		return result, err
 	}
-	srcBucketPath := srcB.Path
+	srcBucketPath, err := resolveBucketPath(ctx, srcB)
+	if err != nil {
+		return result, err
+	}
 
 	srcFp := path.Join(srcBucketPath, srcKey)
 	fmeta, _ := op.GetNearestMeta(srcFp)
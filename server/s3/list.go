@@ -6,10 +6,13 @@ import (
 	"path"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/gofakes3"
 )
 
-func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
+// entryListR lists the objects under fdPath recursively, leaving out the ones the user
+// may not read if the request is made by a user
+func (b *s3Backend) entryListR(user *model.User, bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
 	fp := path.Join(bucket, fdPath)
 
 	dirEntries, err := getDirEntries(fp)
@@ -26,6 +29,9 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 		if !strings.HasPrefix(object, name) {
 			continue
 		}
+		if user != nil && !canRead(user, path.Join(fp, object)) {
+			continue
+		}
 
 		if entry.IsDir() {
 			if addPrefix {
@@ -33,7 +39,7 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 				response.AddPrefix(objectPath)
 				continue
 			}
-			err := b.entryListR(bucket, path.Join(fdPath, object), "", false, response)
+			err := b.entryListR(user, bucket, path.Join(fdPath, object), "", false, response)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,570 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/gofakes3"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// The multipart uploads are staged in TempDir/conf.S3MultipartDirName/<upload id>, each part in
+// its own file, so that large objects are never held in memory. They survive restarts,
+// the ones not completed within MultipartUploadExpiration are removed.
+const (
+	MultipartUploadExpiration = 7 * 24 * time.Hour
+	multipartInfoFile         = "upload.json"
+	maxUploadPartNumber       = 10000
+	defaultMaxUploads         = 1000
+	defaultMaxParts           = 1000
+)
+
+type multipartUpload struct {
+	ID     string `json:"id"`
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	// UserID is the user whose key signed the initiation, 0 for the global key pair
+	UserID    uint              `json:"user_id,omitempty"`
+	Meta      map[string]string `json:"meta"`
+	Initiated time.Time         `json:"initiated"`
+}
+
+type uploadPart struct {
+	Number   int
+	ETag     string
+	Size     int64
+	Modified time.Time
+}
+
+// uploadLocks keeps completing or aborting an upload from racing with its parts
+var (
+	uploadLocks sync.Map
+	purgeOnce   sync.Once
+)
+
+func uploadLock(id string) *sync.RWMutex {
+	l, _ := uploadLocks.LoadOrStore(id, &sync.RWMutex{})
+	return l.(*sync.RWMutex)
+}
+
+func multipartDir() string {
+	return filepath.Join(conf.Conf.TempDir, conf.S3MultipartDirName)
+}
+
+func uploadDir(id string) string {
+	return filepath.Join(multipartDir(), id)
+}
+
+func partFile(id string, number int) string {
+	return filepath.Join(uploadDir(id), fmt.Sprintf("%05d", number))
+}
+
+// multipartHandler serves the multipart upload requests and passes the others to next,
+// gofakes3 would keep all the parts in memory.
+type multipartHandler struct {
+	next    http.Handler
+	backend gofakes3.Backend
+}
+
+func newMultipartHandler(next http.Handler, backend gofakes3.Backend) http.Handler {
+	purgeOnce.Do(func() {
+		cron.NewCron(time.Hour).Do(purgeExpiredMultipartUploads)
+	})
+	return &multipartHandler{next: next, backend: backend}
+}
+
+func (h *multipartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+	var handle func(w http.ResponseWriter, r *http.Request, bucket, key string) error
+	switch {
+	case bucket == "":
+	case key == "":
+		if r.Method == http.MethodGet && q.Has("uploads") {
+			handle = h.listUploads
+		}
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		handle = h.initiate
+	case !q.Has("uploadId"):
+	case r.Method == http.MethodPut:
+		handle = h.putPart
+	case r.Method == http.MethodPost:
+		handle = h.complete
+	case r.Method == http.MethodDelete:
+		handle = h.abort
+	case r.Method == http.MethodGet:
+		handle = h.listParts
+	}
+	if handle == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if _, err := getBucketByName(bucket); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := handle(w, r, bucket, key); err != nil {
+		writeError(w, r, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	resp := &gofakes3.ErrorResponse{Code: gofakes3.ErrInternal, Message: gofakes3.ErrInternal.Message()}
+	if e, ok := err.(*gofakes3.ErrorResponse); ok {
+		resp.Code, resp.Message = e.Code, e.Message
+	} else if e, ok := err.(gofakes3.Error); ok {
+		resp.Code, resp.Message = e.ErrorCode(), e.Error()
+		if code, ok := err.(gofakes3.ErrorCode); ok {
+			resp.Message = code.Message()
+		}
+	} else {
+		log.Errorf("failed serve s3 request: %+v", err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(errorStatus(resp.Code))
+	if r.Method != http.MethodHead {
+		writeXML(w, resp)
+	}
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/xml")
+	}
+	_, _ = w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("failed encode s3 response: %+v", err)
+	}
+}
+
+// uploadMeta keeps the headers which PutObject reads the metadata from
+func uploadMeta(header http.Header) map[string]string {
+	meta := make(map[string]string)
+	for k, v := range header {
+		k = textproto.CanonicalMIMEHeaderKey(k)
+		if (strings.HasPrefix(k, "X-Amz-Meta-") || k == "Content-Type") && len(v) > 0 {
+			meta[k] = v[0]
+		}
+	}
+	return meta
+}
+
+// requestUserID returns the id of the user whose key signed the request, 0 for the global key pair
+func requestUserID(ctx context.Context) uint {
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		return user.ID
+	}
+	return 0
+}
+
+// loadUpload loads the upload, it's only found by the user who initiated it
+func loadUpload(ctx context.Context, bucket, key, id string) (*multipartUpload, error) {
+	// the ids are uuids, anything else could escape the staging dir
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	data, err := os.ReadFile(filepath.Join(uploadDir(id), multipartInfoFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, gofakes3.ErrNoSuchUpload
+		}
+		return nil, err
+	}
+	var u multipartUpload
+	if err = json.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	if u.Bucket != bucket || (key != "" && u.Key != key) || u.UserID != requestUserID(ctx) {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	return &u, nil
+}
+
+func listUploadParts(id string) ([]uploadPart, error) {
+	entries, err := os.ReadDir(uploadDir(id))
+	if err != nil {
+		return nil, err
+	}
+	var parts []uploadPart
+	for _, e := range entries {
+		number, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		etag, err := os.ReadFile(partFile(id, number) + ".etag")
+		if err != nil {
+			// the part is being written
+			continue
+		}
+		parts = append(parts, uploadPart{Number: number, ETag: string(etag), Size: info.Size(), Modified: info.ModTime()})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+	return parts, nil
+}
+
+func (h *multipartHandler) initiate(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	u := multipartUpload{
+		ID:        uuid.NewString(),
+		Bucket:    bucket,
+		Key:       key,
+		UserID:    requestUserID(r.Context()),
+		Meta:      uploadMeta(r.Header),
+		Initiated: time.Now(),
+	}
+	if err := utils.CreateNestedDirectory(uploadDir(u.ID)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(uploadDir(u.ID), multipartInfoFile), data, 0o644); err != nil {
+		_ = os.RemoveAll(uploadDir(u.ID))
+		return err
+	}
+	writeXML(w, gofakes3.InitiateMultipartUpload{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: gofakes3.UploadID(u.ID),
+	})
+	return nil
+}
+
+func (h *multipartHandler) putPart(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	defer r.Body.Close()
+	id := r.URL.Query().Get("uploadId")
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number <= 0 || number > maxUploadPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return gofakes3.ErrNotImplemented
+	}
+	var body io.Reader = r.Body
+	size := r.ContentLength
+	if sha := r.Header.Get("X-Amz-Content-Sha256"); strings.HasPrefix(sha, "STREAMING-") {
+		body = newChunkedReader(r.Body)
+		if size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err != nil {
+			return gofakes3.ErrMissingContentLength
+		}
+	}
+	if size < 0 {
+		return gofakes3.ErrMissingContentLength
+	}
+
+	lock := uploadLock(id)
+	lock.RLock()
+	defer lock.RUnlock()
+	if _, err = loadUpload(r.Context(), bucket, key, id); err != nil {
+		return err
+	}
+	// write to a temporary name, so that a part being written is never listed or assembled
+	tmp := fmt.Sprintf("%s.%s", partFile(id, number), uuid.NewString())
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	hash := md5.New()
+	n, err := utils.CopyWithBuffer(io.MultiWriter(f, hash), io.LimitReader(body, size))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	sum := hash.Sum(nil)
+	if digest := r.Header.Get("Content-MD5"); digest != "" && digest != base64.StdEncoding.EncodeToString(sum) {
+		return gofakes3.ErrBadDigest
+	}
+	etag := `"` + hex.EncodeToString(sum) + `"`
+	if err = os.Rename(tmp, partFile(id, number)); err != nil {
+		return err
+	}
+	if err = os.WriteFile(partFile(id, number)+".etag", []byte(etag), 0o644); err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag)
+	return nil
+}
+
+func (h *multipartHandler) complete(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	defer r.Body.Close()
+	id := r.URL.Query().Get("uploadId")
+	var req gofakes3.CompleteMultipartUploadRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		return gofakes3.ErrMalformedXML
+	}
+	lock := uploadLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+	u, err := loadUpload(r.Context(), bucket, key, id)
+	if err != nil {
+		return err
+	}
+	parts, err := listUploadParts(id)
+	if err != nil {
+		return err
+	}
+	uploaded := make(map[int]uploadPart, len(parts))
+	for _, p := range parts {
+		uploaded[p.Number] = p
+	}
+
+	var (
+		files   []*os.File
+		readers []io.Reader
+		size    int64
+		sums    []byte
+	)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	last := 0
+	for _, p := range req.Parts {
+		part, ok := uploaded[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != strings.Trim(part.ETag, `"`) {
+			return gofakes3.ErrInvalidPart
+		}
+		if p.PartNumber <= last {
+			return gofakes3.ErrInvalidPartOrder
+		}
+		last = p.PartNumber
+		f, err := os.Open(partFile(id, p.PartNumber))
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+		size += part.Size
+		sum, _ := hex.DecodeString(strings.Trim(part.ETag, `"`))
+		sums = append(sums, sum...)
+	}
+	if len(readers) == 0 {
+		return gofakes3.ErrInvalidPart
+	}
+	if _, err = h.backend.PutObject(r.Context(), bucket, key, u.Meta, io.MultiReader(readers...), size); err != nil {
+		return err
+	}
+	removeUpload(id)
+	total := md5.Sum(sums)
+	writeXML(w, gofakes3.CompleteMultipartUploadResult{
+		Bucket: bucket,
+		Key:    key,
+		ETag:   fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(total[:]), len(readers)),
+	})
+	return nil
+}
+
+func (h *multipartHandler) abort(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	id := r.URL.Query().Get("uploadId")
+	lock := uploadLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+	if _, err := loadUpload(r.Context(), bucket, key, id); err != nil {
+		return err
+	}
+	removeUpload(id)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *multipartHandler) listParts(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	q := r.URL.Query()
+	id := q.Get("uploadId")
+	if _, err := loadUpload(r.Context(), bucket, key, id); err != nil {
+		return err
+	}
+	marker, _ := strconv.Atoi(q.Get("part-number-marker"))
+	maxParts, err := strconv.Atoi(q.Get("max-parts"))
+	if err != nil || maxParts <= 0 || maxParts > defaultMaxParts {
+		maxParts = defaultMaxParts
+	}
+	parts, err := listUploadParts(id)
+	if err != nil {
+		return err
+	}
+	out := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           bucket,
+		Key:              key,
+		UploadID:         gofakes3.UploadID(id),
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	for _, p := range parts {
+		if p.Number <= marker {
+			continue
+		}
+		if len(out.Parts) == maxParts {
+			out.IsTruncated = true
+			break
+		}
+		out.Parts = append(out.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   p.Number,
+			LastModified: gofakes3.NewContentTime(p.Modified),
+			ETag:         p.ETag,
+			Size:         p.Size,
+		})
+		out.NextPartNumberMarker = p.Number
+	}
+	writeXML(w, out)
+	return nil
+}
+
+func (h *multipartHandler) listUploads(w http.ResponseWriter, r *http.Request, bucket, _ string) error {
+	q := r.URL.Query()
+	prefix, keyMarker, idMarker := q.Get("prefix"), q.Get("key-marker"), q.Get("upload-id-marker")
+	maxUploads, err := strconv.Atoi(q.Get("max-uploads"))
+	if err != nil || maxUploads <= 0 || maxUploads > defaultMaxUploads {
+		maxUploads = defaultMaxUploads
+	}
+	entries, err := os.ReadDir(multipartDir())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var uploads []*multipartUpload
+	for _, e := range entries {
+		u, err := loadUpload(r.Context(), bucket, "", e.Name())
+		if err != nil || !strings.HasPrefix(u.Key, prefix) {
+			continue
+		}
+		if keyMarker != "" && (u.Key < keyMarker || (u.Key == keyMarker && (idMarker == "" || u.ID <= idMarker))) {
+			continue
+		}
+		uploads = append(uploads, u)
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].Key != uploads[j].Key {
+			return uploads[i].Key < uploads[j].Key
+		}
+		return uploads[i].ID < uploads[j].ID
+	})
+	out := gofakes3.ListMultipartUploadsResult{
+		Bucket:         bucket,
+		KeyMarker:      keyMarker,
+		UploadIDMarker: gofakes3.UploadID(idMarker),
+		MaxUploads:     int64(maxUploads),
+		Prefix:         prefix,
+	}
+	for _, u := range uploads {
+		if len(out.Uploads) == maxUploads {
+			out.IsTruncated = true
+			break
+		}
+		out.Uploads = append(out.Uploads, gofakes3.ListMultipartUploadItem{
+			Key:          u.Key,
+			UploadID:     gofakes3.UploadID(u.ID),
+			StorageClass: "STANDARD",
+			Initiated:    gofakes3.NewContentTime(u.Initiated),
+		})
+		out.NextKeyMarker, out.NextUploadIDMarker = u.Key, gofakes3.UploadID(u.ID)
+	}
+	writeXML(w, out)
+	return nil
+}
+
+func removeUpload(id string) {
+	if err := os.RemoveAll(uploadDir(id)); err != nil {
+		log.Errorf("failed remove multipart upload %s: %+v", id, err)
+	}
+	uploadLocks.Delete(id)
+}
+
+// purgeExpiredMultipartUploads removes the uploads neither completed nor aborted in time
+func purgeExpiredMultipartUploads() {
+	entries, err := os.ReadDir(multipartDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < MultipartUploadExpiration {
+			continue
+		}
+		// the dir is modified when a part is added
+		lock := uploadLock(e.Name())
+		lock.Lock()
+		removeUpload(e.Name())
+		lock.Unlock()
+	}
+}
+
+// chunkedReader decodes the aws-chunked bodies of the streaming signatures,
+// the signatures of the chunks and the trailers are not checked.
+type chunkedReader struct {
+	r      *bufio.Reader
+	remain int64
+	eof    bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// the CRLF after the data of the previous chunk
+			continue
+		}
+		sizeStr, _, _ := strings.Cut(line, ";")
+		size, err := strconv.ParseInt(sizeStr, 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid chunk size %q", sizeStr)
+		}
+		if size == 0 {
+			c.eof = true
+			return 0, io.EOF
+		}
+		c.remain = size
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	if err == io.EOF && c.remain > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
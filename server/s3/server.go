@@ -13,15 +13,16 @@ import (
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
 	var newLogger logger
+	backend := newBackend()
 	faker := gofakes3.New(
-		newBackend(),
+		backend,
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
+		// the signatures are verified by authHandler
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
 
-	return faker.Server(), nil
+	return newAuthHandler(newMultipartHandler(faker.Server(), backend)), nil
 }
@@ -108,6 +108,11 @@ func WebDAVAuth(c *gin.Context) {
 			c.Next()
 			return
 		}
+		reason := "wrong password"
+		if err != nil {
+			reason = "user not found"
+		}
+		common.PublishLoginFailed(username, c.ClientIP(), reason)
 		c.Status(http.StatusUnauthorized)
 		c.Abort()
 		return
@@ -15,6 +15,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 // slashClean is equivalent to but slightly more efficient than
@@ -56,6 +57,9 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
+	if err := op.MoveWebdavProps(src, dst); err != nil {
+		log.Warnf("failed move webdav props from %s to %s: %+v", src, dst, err)
+	}
 	// TODO if there are no files copy, should return 204
 	return http.StatusCreated, nil
 }
@@ -69,6 +73,11 @@ func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
+	// the copy keeps the name of src
+	copied := path.Join(dstDir, path.Base(src))
+	if err := op.CopyWebdavProps(src, copied); err != nil {
+		log.Warnf("failed copy webdav props from %s to %s: %+v", src, copied, err)
+	}
 	// TODO if there are no files copy, should return 204
 	return http.StatusCreated, nil
 }
@@ -13,11 +13,14 @@ import (
 	"mime"
 	"net/http"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
 )
 
@@ -102,6 +105,9 @@ type DeadPropsHolder interface {
 	Patch([]Proppatch) ([]Propstat, error)
 }
 
+// errPropNotFound is returned by the findFn of a property the resource doesn't have
+var errPropNotFound = errors.New("property not found")
+
 // liveProps contains all supported properties.
 var liveProps = map[xml.Name]struct {
 	// findFn implements the propfind function of this property. If nil,
@@ -109,6 +115,9 @@ var liveProps = map[xml.Name]struct {
 	findFn func(context.Context, LockSystem, string, model.Obj) (string, error)
 	// dir is true if the property applies to directories.
 	dir bool
+	// named is true if the property is only returned when asked for by name,
+	// not with allprop, see RFC 4331 section 3.
+	named bool
 }{
 	{Space: "DAV:", Local: "resourcetype"}: {
 		findFn: findResourceType,
@@ -165,6 +174,30 @@ var liveProps = map[xml.Name]struct {
 		findFn: findChecksums,
 		dir:    false,
 	},
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn: findQuotaAvailableBytes,
+		dir:    true,
+		named:  true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn: findQuotaUsedBytes,
+		dir:    true,
+		named:  true,
+	},
+}
+
+// loadDeadProps returns the dead properties of the resource set with PROPPATCH
+func loadDeadProps(reqPath string) (map[xml.Name]Property, error) {
+	stored, err := op.GetWebdavProps(reqPath)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[xml.Name]Property, len(stored))
+	for _, p := range stored {
+		name := xml.Name{Space: p.Space, Local: p.Local}
+		props[name] = Property{XMLName: name, Lang: p.Lang, InnerXML: []byte(p.InnerXML)}
+	}
+	return props, nil
 }
 
 // TODO(nigeltao) merge props and allprop?
@@ -173,7 +206,7 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -185,14 +218,10 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 	//}
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps, err := loadDeadProps(name)
+	if err != nil {
+		return nil, err
+	}
 
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
@@ -204,7 +233,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
-			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			innerXML, err := prop.findFn(ctx, ls, name, fi)
+			if errors.Is(err, errPropNotFound) {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -222,7 +257,7 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
+func propnames(ctx context.Context, ls LockSystem, name string, fi model.Obj) ([]xml.Name, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -234,14 +269,10 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 	//}
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps, err := loadDeadProps(name)
+	if err != nil {
+		return nil, err
+	}
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
@@ -263,11 +294,17 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+	all, err := propnames(ctx, ls, name, fi)
 	if err != nil {
 		return nil, err
 	}
+	pnames := all[:0]
+	for _, pn := range all {
+		if !liveProps[pn].named {
+			pnames = append(pnames, pn)
+		}
+	}
 	// Add names from include if they are not already covered in pnames.
 	nameset := make(map[xml.Name]bool)
 	for _, pn := range pnames {
@@ -278,7 +315,7 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, pnames)
 }
 
 // Patch patches the properties of resource name. The return values are
@@ -314,32 +351,34 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
-
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
-	pstat := Propstat{Status: http.StatusForbidden}
+	// the patches are applied in document order, a nil value removes the property
+	final := make(map[xml.Name]*Property)
+	for _, patch := range patches {
+		for i, p := range patch.Props {
+			if patch.Remove {
+				final[p.XMLName] = nil
+			} else {
+				final[p.XMLName] = &patch.Props[i]
+			}
+		}
+	}
+	var set, remove []model.WebdavProp
+	for pn, p := range final {
+		prop := model.WebdavProp{Space: pn.Space, Local: pn.Local}
+		if p == nil {
+			remove = append(remove, prop)
+			continue
+		}
+		prop.Lang, prop.InnerXML = p.Lang, string(p.InnerXML)
+		set = append(set, prop)
+	}
+	if err := op.PatchWebdavProps(name, set, remove); err != nil {
+		return nil, err
+	}
+	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
+	// "The contents of the prop XML element must only list the names of
+	// properties to which the result in the status element applies."
+	pstat := Propstat{Status: http.StatusOK}
 	for _, patch := range patches {
 		for _, p := range patch.Props {
 			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
@@ -489,10 +528,38 @@ func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model
 		`</D:lockentry>`, nil
 }
 
+// findChecksums returns the hashes of the file the way owncloud does,
+// e.g. <oc:checksum>SHA1:... MD5:...</oc:checksum>
 func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
-	checksums := ""
+	var checksums []string
 	for hashType, hashValue := range fi.GetHash().All() {
-		checksums += fmt.Sprintf("<checksum>%s:%s</checksum>", hashType.Name, hashValue)
+		if hashValue == "" {
+			continue
+		}
+		checksums = append(checksums, fmt.Sprintf("%s:%s", strings.ToUpper(hashType.Name), hashValue))
+	}
+	if len(checksums) == 0 {
+		return "", errPropNotFound
+	}
+	sort.Strings(checksums)
+	return `<oc:checksum xmlns:oc="http://owncloud.org/ns">` +
+		escapeXML(strings.Join(checksums, " ")) + `</oc:checksum>`, nil
+}
+
+func findQuotaAvailableBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := fs.GetStorageDetails(ctx, name)
+	// the free space of a storage without a total capacity is unknown, not 0
+	if err != nil || details.TotalSpace <= 0 {
+		return "", errPropNotFound
+	}
+	return strconv.FormatInt(details.FreeSpace, 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := fs.GetStorageDetails(ctx, name)
+	// a partial count is not reported as the used space
+	if err != nil || details.UsedSpaceTruncated {
+		return "", errPropNotFound
 	}
-	return checksums, nil
+	return strconv.FormatInt(details.UsedSpace, 10), nil
 }
@@ -21,8 +21,10 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 type Handler struct {
@@ -296,6 +298,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err := fs.Remove(ctx, reqPath); err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	if err := op.DeleteWebdavProps(reqPath); err != nil {
+		log.Warnf("failed delete webdav props of %s: %+v", reqPath, err)
+	}
 	//fs.ClearCache(path.Dir(reqPath))
 	return http.StatusNoContent, nil
 }
@@ -694,7 +699,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			for _, item := range infos {
 				var pstats []Propstat
 				if pf.Propname != nil {
-					pnames, err := propnames(ctx, h.LockSystem, item.info)
+					pnames, err := propnames(ctx, h.LockSystem, item.path, item.info)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -704,12 +709,12 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 					}
 					pstats = append(pstats, pstat)
 				} else if pf.Allprop != nil {
-					pstats, err = allprop(ctx, h.LockSystem, item.info, pf.Prop)
+					pstats, err = allprop(ctx, h.LockSystem, item.path, item.info, pf.Prop)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
 				} else {
-					pstats, err = props(ctx, h.LockSystem, item.info, pf.Prop)
+					pstats, err = props(ctx, h.LockSystem, item.path, item.info, pf.Prop)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -742,7 +747,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -752,9 +757,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err